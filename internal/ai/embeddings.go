@@ -0,0 +1,65 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// embeddingBatchSize is how many inputs are sent per embedding request
+const embeddingBatchSize = 32
+
+// maxEmbeddingInput truncates long texts, which embedding models cap anyway
+const maxEmbeddingInput = 8000
+
+// Embedder computes embeddings with a provider's embedding model, in batches
+type Embedder struct {
+	Provider LLMProvider
+	Model    string
+}
+
+func NewEmbedder(provider LLMProvider, model string) *Embedder {
+	return &Embedder{Provider: provider, Model: model}
+}
+
+// Embed returns one vector per input text, in order
+func (e *Embedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += embeddingBatchSize {
+		end := min(start+embeddingBatchSize, len(texts))
+		input := make([]string, 0, end-start)
+		for _, t := range texts[start:end] {
+			if r := []rune(t); len(r) > maxEmbeddingInput {
+				t = string(r[:maxEmbeddingInput])
+			}
+			input = append(input, t)
+		}
+		batch, err := e.Provider.Embed(ctx, e.Model, input)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) != len(input) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(input), len(batch))
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// Cosine returns the cosine similarity of two vectors, or 0 if their
+// dimensions differ or either is zero
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,197 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// embeddingServer answers both the OpenAI-compatible /v1/embeddings and
+// Ollama's native /api/embed with one-element vectors holding each input's
+// length, and records the requested paths and batch sizes
+type embeddingServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	paths   []string
+	batches []int
+}
+
+func newEmbeddingServer(t *testing.T) *embeddingServer {
+	t.Helper()
+	s := &embeddingServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "nomic-embed-text" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.paths = append(s.paths, r.URL.Path)
+		s.batches = append(s.batches, len(req.Input))
+		s.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/v1/embeddings":
+			// Out of order, as the API allows
+			var data []map[string]interface{}
+			for i := len(req.Input) - 1; i >= 0; i-- {
+				data = append(data, map[string]interface{}{"index": i, "embedding": []float32{float32(len([]rune(req.Input[i])))}})
+			}
+			writeTestJSON(w, map[string]interface{}{"data": data})
+		case "/api/embed":
+			var embeddings [][]float32
+			for _, in := range req.Input {
+				embeddings = append(embeddings, []float32{float32(len([]rune(in)))})
+			}
+			writeTestJSON(w, map[string]interface{}{"embeddings": embeddings})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func writeTestJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestEmbedderProviders(t *testing.T) {
+	server := newEmbeddingServer(t)
+
+	tests := []struct {
+		name     string
+		kind     string
+		url      string
+		wantPath string
+	}{
+		{"openai", ProviderOpenAI, server.URL + "/v1", "/v1/embeddings"},
+		{"openai trailing slash", ProviderOpenAI, server.URL + "/v1/", "/v1/embeddings"},
+		{"ollama", ProviderOllama, server.URL, "/api/embed"},
+		{"ollama with /v1", ProviderOllama, server.URL + "/v1", "/api/embed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewProvider(tt.kind, tt.url, "", "gemma3:4b")
+			if err != nil {
+				t.Fatal(err)
+			}
+			server.mu.Lock()
+			server.paths = nil
+			server.mu.Unlock()
+
+			vectors, err := NewEmbedder(provider, "nomic-embed-text").Embed(context.Background(), []string{"a", "bb", "ccc"})
+			if err != nil {
+				t.Fatalf("Embed() error = %v", err)
+			}
+			for i, v := range vectors {
+				if len(v) != 1 || v[0] != float32(i+1) {
+					t.Errorf("vector %d = %v, want [%d]", i, v, i+1)
+				}
+			}
+			if len(server.paths) != 1 || server.paths[0] != tt.wantPath {
+				t.Errorf("requested %v, want %s", server.paths, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestEmbedderBatchesAndTruncates(t *testing.T) {
+	server := newEmbeddingServer(t)
+	provider, _ := NewProvider(ProviderOllama, server.URL, "", "")
+
+	texts := make([]string, embeddingBatchSize+8)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("text %d", i)
+	}
+	texts[5] = strings.Repeat("é", maxEmbeddingInput+100)
+
+	vectors, err := NewEmbedder(provider, "nomic-embed-text").Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) != len(texts) {
+		t.Fatalf("%d vectors for %d texts", len(vectors), len(texts))
+	}
+	if got := fmt.Sprint(server.batches); got != fmt.Sprintf("[%d 8]", embeddingBatchSize) {
+		t.Errorf("batches = %s", got)
+	}
+	if vectors[5][0] != maxEmbeddingInput {
+		t.Errorf("long text was sent with %v characters, want %d", vectors[5][0], maxEmbeddingInput)
+	}
+	if vectors[6][0] != float32(len("text 6")) {
+		t.Errorf("vectors are out of order: %v", vectors[6])
+	}
+}
+
+func TestEmbedderStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	provider, _ := NewProvider(ProviderOpenAI, server.URL, "", "")
+	_, err := NewEmbedder(provider, "nomic-embed-text").Embed(context.Background(), []string{"a"})
+	statusErr, ok := err.(*StatusError)
+	if !ok {
+		t.Fatalf("Embed() error = %v, want a StatusError", err)
+	}
+	if !statusErr.Temporary() || statusErr.RetryAfter.Seconds() != 3 {
+		t.Errorf("status error = %+v", statusErr)
+	}
+}
+
+func TestFakeEmbeddings(t *testing.T) {
+	e := NewEmbedder(&FakeProvider{}, "fake")
+	vectors, err := e.Embed(context.Background(), []string{
+		"autonomous drone swarms for maritime surveillance",
+		"Maritime surveillance for autonomous drone swarms",
+		"office furniture and janitorial services",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	same := Cosine(vectors[0], vectors[1])
+	other := Cosine(vectors[0], vectors[2])
+	if math.Abs(same-1) > 1e-6 {
+		t.Errorf("reordered text has similarity %v, want 1", same)
+	}
+	if other >= same {
+		t.Errorf("unrelated text is as similar (%v) as a reworded one (%v)", other, same)
+	}
+}
+
+func TestCosine(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{"identical", []float32{1, 2, 3}, []float32{1, 2, 3}, 1},
+		{"scaled", []float32{1, 2, 3}, []float32{2, 4, 6}, 1},
+		{"orthogonal", []float32{1, 0}, []float32{0, 1}, 0},
+		{"opposite", []float32{1, 1}, []float32{-1, -1}, -1},
+		{"zero vector", []float32{0, 0}, []float32{1, 1}, 0},
+		{"dimension mismatch", []float32{1, 2}, []float32{1, 2, 3}, 0},
+		{"empty", nil, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Cosine(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Cosine() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FakeProvider answers without a model, deterministically, for tests and
+// offline development. Replies, if set, are returned in order with the last
+// one repeating. Otherwise a request with a schema gets the smallest JSON
+// value that conforms to it, with numbers derived from a hash of the
+// messages, and a plain request gets its last message echoed back.
+// Embeddings are hashed bags of words, so texts that share words are similar.
+type FakeProvider struct {
+	Replies []string
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (p *FakeProvider) Complete(ctx context.Context, req ChatRequest) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	call := p.calls
+	p.calls++
+	p.mu.Unlock()
+	if len(p.Replies) > 0 {
+		return p.Replies[min(call, len(p.Replies)-1)], nil
+	}
+
+	h := fnv.New64a()
+	for _, m := range req.Messages {
+		h.Write([]byte(m.Role))
+		h.Write([]byte(m.Content))
+	}
+	seed := h.Sum64()
+
+	if len(req.Schema) > 0 {
+		schema, err := ParseSchema(req.Schema)
+		if err != nil {
+			return "", fmt.Errorf("fake provider: %w", err)
+		}
+		out, err := json.Marshal(fakeValue(schema, seed))
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	}
+
+	last := ""
+	if len(req.Messages) > 0 {
+		last = req.Messages[len(req.Messages)-1].Content
+	}
+	return "Fake reply to: " + last, nil
+}
+
+// Stream sends the Complete reply one word at a time
+func (p *FakeProvider) Stream(ctx context.Context, req ChatRequest, onChunk func(string) error) error {
+	reply, err := p.Complete(ctx, req)
+	if err != nil {
+		return err
+	}
+	for _, word := range strings.SplitAfter(reply, " ") {
+		if err := onChunk(word); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fakeEmbeddingSize is the dimension of the fake provider's vectors
+const fakeEmbeddingSize = 64
+
+// Embed counts each input's lowercased words into fakeEmbeddingSize buckets
+func (p *FakeProvider) Embed(ctx context.Context, model string, input []string) ([][]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, len(input))
+	for i, text := range input {
+		v := make([]float32, fakeEmbeddingSize)
+		for _, word := range strings.Fields(strings.ToLower(text)) {
+			h := fnv.New32a()
+			h.Write([]byte(word))
+			v[h.Sum32()%fakeEmbeddingSize]++
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+// fakeValue builds the smallest value that satisfies the schema: required
+// properties only, empty arrays, the first enum value, and numbers within
+// their bounds chosen by seed
+func fakeValue(s *Schema, seed uint64) interface{} {
+	switch s.Type {
+	case "object":
+		obj := make(map[string]interface{})
+		required := append([]string{}, s.Required...)
+		sort.Strings(required)
+		for i, name := range required {
+			if prop, ok := s.Properties[name]; ok {
+				obj[name] = fakeValue(prop, seed+uint64(i)*7919)
+			}
+		}
+		return obj
+	case "array":
+		return []interface{}{}
+	case "string":
+		if len(s.Enum) > 0 {
+			return s.Enum[0]
+		}
+		return "fake"
+	case "integer":
+		lo, hi := bounds(s, 0, 100)
+		return int64(lo) + int64(seed%(uint64(hi-lo)+1))
+	case "number":
+		lo, hi := bounds(s, 0, 1)
+		return lo + float64(seed%1001)/1000*(hi-lo)
+	case "boolean":
+		return seed%2 == 0
+	}
+	return nil
+}
+
+func bounds(s *Schema, lo, hi float64) (float64, float64) {
+	if s.Minimum != nil {
+		lo = *s.Minimum
+	}
+	if s.Maximum != nil {
+		hi = *s.Maximum
+	}
+	if hi < lo {
+		hi = lo
+	}
+	return lo, hi
+}
@@ -1,125 +1,184 @@
 package ai
 
 import (
+	"bd_bot/internal/prompt"
 	"bd_bot/internal/scraper"
-	"bytes"
+	"context"
+	_ "embed"
 	"encoding/json"
 	"fmt"
 	"log/slog"
-	"net/http"
-	"time"
+	"strings"
 )
 
+// Matcher scores solicitations against a narrative with an LLM
 type Matcher struct {
-	LLMURL string
-	APIKey string
-	Model  string
-	Client *http.Client
+	Provider LLMProvider
+	// Prompts supplies the match prompt; nil uses the built-in default
+	Prompts *prompt.Store
 }
 
+// MatchResult is the matcher's structured verdict on one solicitation
 type MatchResult struct {
 	Score       int    `json:"score"`
 	Explanation string `json:"explanation"`
+	// Confidence is how sure the model is of its score, from 0 to 1
+	Confidence float64 `json:"confidence"`
+	// Criteria holds a verdict for each rubric criterion found in the narrative
+	Criteria []CriterionVerdict `json:"criteria"`
+	// MatchedKeywords are narrative keywords that appear in the opportunity
+	MatchedKeywords []string `json:"matched_keywords"`
+	// Disqualifiers are narrative disqualifiers the opportunity hits
+	Disqualifiers []string `json:"disqualifiers"`
+	// PromptVersion is the version of the match prompt that produced the result
+	PromptVersion int `json:"-"`
 }
 
-func NewMatcher(url, key, model string) *Matcher {
-	return &Matcher{
-		LLMURL: url,
-		APIKey: key,
-		Model:  model,
-		Client: &http.Client{Timeout: 60 * time.Second},
+// Criterion verdicts
+const (
+	VerdictMet     = "met"
+	VerdictNotMet  = "not_met"
+	VerdictUnclear = "unclear"
+)
+
+// CriterionVerdict is the model's finding for one rubric criterion
+type CriterionVerdict struct {
+	Criterion string `json:"criterion"`
+	Verdict   string `json:"verdict"`
+	Evidence  string `json:"evidence"`
+}
+
+// Details is everything in the result beyond the score and explanation
+func (r *MatchResult) Details() MatchDetails {
+	return MatchDetails{
+		Confidence:      r.Confidence,
+		Criteria:        r.Criteria,
+		MatchedKeywords: r.MatchedKeywords,
+		Disqualifiers:   r.Disqualifiers,
 	}
 }
 
-func (m *Matcher) Match(narrative string, sol scraper.Solicitation) (*MatchResult, error) {
-	prompt := fmt.Sprintf(`
-You are a Business Development expert. Evaluate if the following opportunity matches the user's business capabilities.
+// MatchDetails is the structured part of a match result, stored alongside the score
+type MatchDetails struct {
+	Confidence      float64            `json:"confidence"`
+	Criteria        []CriterionVerdict `json:"criteria"`
+	MatchedKeywords []string           `json:"matched_keywords"`
+	Disqualifiers   []string           `json:"disqualifiers"`
+}
 
-**User Narrative & Matching Rubric:**
-"%s"
+//go:embed match_result.schema.json
+var matchResultSchemaJSON []byte
 
-**Opportunity:**
-Title: %s
-Agency: %s
-Description: %s
+// matchResultSchema validates LLM responses before they are accepted
+var matchResultSchema = func() *Schema {
+	s, err := ParseSchema(matchResultSchemaJSON)
+	if err != nil {
+		panic(fmt.Sprintf("invalid match result schema: %v", err))
+	}
+	return s
+}()
+
+// maxResponseAttempts bounds how often a malformed response is sent back to
+// the model for correction
+const maxResponseAttempts = 3
+
+// ResponseError is returned when the LLM never produced a response matching
+// the schema
+type ResponseError struct {
+	Content string
+	Errors  []string
+}
 
-**Instructions:**
-1. Analyze the User Narrative for any specific matching rubric, keywords, or disqualifiers.
-2. Score the opportunity from 0-100 based on alignment with the narrative and rubric.
-3. Provide a concise explanation.
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("invalid match result: %s", strings.Join(e.Errors, "; "))
+}
 
-Respond with a JSON object ONLY:
-{
-  "score": <0-100 integer confidence>,
-  "explanation": "<concise reason>"
+func NewMatcher(provider LLMProvider, prompts *prompt.Store) *Matcher {
+	return &Matcher{Provider: provider, Prompts: prompts}
 }
-`, narrative, sol.Title, sol.Agency, sol.Description)
-
-	reqBody := map[string]interface{}{
-		"model":  m.Model,
-		"messages": []map[string]string{
-			{"role": "user", "content": prompt},
-		},
-		"stream": false,
-		"format": "json", // Force JSON mode if supported (Ollama supports this)
-	}
 
-	jsonBody, _ := json.Marshal(reqBody)
-	req, err := http.NewRequest("POST", m.LLMURL+"/chat/completions", bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	if m.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+m.APIKey)
+// maxDocumentExcerpt bounds how much attachment text is sent to the LLM
+const maxDocumentExcerpt = 8000
+
+// Match scores a solicitation against a narrative. Examples, if any, are the
+// narrative owner's rated solicitations and are shown to the model as
+// few-shot context.
+func (m *Matcher) Match(ctx context.Context, narrative string, examples []prompt.MatchExample, sol scraper.Solicitation) (*MatchResult, error) {
+	documents := ""
+	if sol.DocumentText != "" {
+		excerpt := []rune(sol.DocumentText)
+		if len(excerpt) > maxDocumentExcerpt {
+			excerpt = excerpt[:maxDocumentExcerpt]
+		}
+		documents = string(excerpt)
 	}
 
-	resp, err := m.Client.Do(req)
+	text, version, err := m.Prompts.Render(ctx, prompt.Match, prompt.MatchData{
+		Narrative:   narrative,
+		Title:       sol.Title,
+		Agency:      sol.Agency,
+		Description: sol.Description,
+		Documents:   documents,
+		Examples:    examples,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("LLM returned status: %s", resp.Status)
+	messages := []ChatMessage{
+		{Role: "user", Content: text},
 	}
 
-	var chatResp struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-	}
+	var invalid *ResponseError
+	for attempt := 1; attempt <= maxResponseAttempts; attempt++ {
+		content, err := m.Provider.Complete(ctx, ChatRequest{
+			Messages:   messages,
+			Schema:     matchResultSchemaJSON,
+			SchemaName: "match_result",
+		})
+		if err != nil {
+			return nil, err
+		}
+		content = stripCodeFence(content)
+
+		errs := matchResultSchema.Validate([]byte(content))
+		if len(errs) == 0 {
+			var result MatchResult
+			err := json.Unmarshal([]byte(content), &result)
+			if err == nil {
+				result.PromptVersion = version
+				return &result, nil
+			}
+			errs = []string{err.Error()}
+		}
 
-	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
-		return nil, err
-	}
+		invalid = &ResponseError{Content: content, Errors: errs}
+		slog.Warn("LLM returned an invalid match result", "attempt", attempt, "errors", errs)
 
-	if len(chatResp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from LLM")
+		// Show the model its reply and what was wrong with it
+		messages = append(messages,
+			ChatMessage{Role: "assistant", Content: content},
+			ChatMessage{Role: "user", Content: "That response does not match the required format:\n- " +
+				strings.Join(errs, "\n- ") + "\nRespond again with the corrected JSON object ONLY."},
+		)
 	}
 
-	content := chatResp.Choices[0].Message.Content
-	
-	// Sanitize content (strip markdown code blocks)
-	if len(content) > 3 && content[:3] == "```" {
-		// Find first newline
-		if idx := bytes.IndexByte([]byte(content), '\n'); idx != -1 {
-			content = content[idx+1:]
-		}
-		// Find last code block
-		if idx := bytes.LastIndex([]byte(content), []byte("```")); idx != -1 {
-			content = content[:idx]
-		}
+	slog.Error("Failed to parse LLM JSON", "content", invalid.Content)
+	return nil, invalid
+}
+
+// stripCodeFence removes a markdown code block wrapped around a reply
+func stripCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") {
+		return content
 	}
-	
-	// Parse the JSON inside the content
-	var result MatchResult
-	if err := json.Unmarshal([]byte(content), &result); err != nil {
-		slog.Error("Failed to parse LLM JSON", "content", content)
-		return nil, fmt.Errorf("failed to parse match result: %w", err)
+	// Drop the opening fence line and the closing fence
+	if idx := strings.IndexByte(content, '\n'); idx != -1 {
+		content = content[idx+1:]
 	}
-
-	return &result, nil
+	if idx := strings.LastIndex(content, "```"); idx != -1 {
+		content = content[:idx]
+	}
+	return strings.TrimSpace(content)
 }
@@ -0,0 +1,200 @@
+package ai
+
+import (
+	"bd_bot/internal/scraper"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const validResult = `{"score": 82, "explanation": "Strong fit", "confidence": 0.7,
+	"criteria": [{"criterion": "Autonomy", "verdict": "met", "evidence": "UAS swarms"}],
+	"matched_keywords": ["autonomy"], "disqualifiers": []}`
+
+var testSolicitation = scraper.Solicitation{ID: 1, Title: "Autonomous swarms", Agency: "DARPA", Description: "Research on UAS swarms"}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		replies []string
+		// wantScore is checked when wantErr is empty
+		wantScore int
+		wantCalls int
+		wantErr   string
+	}{
+		{
+			name:      "valid",
+			replies:   []string{validResult},
+			wantScore: 82,
+			wantCalls: 1,
+		},
+		{
+			name:      "code fence",
+			replies:   []string{"```json\n" + validResult + "\n```"},
+			wantScore: 82,
+			wantCalls: 1,
+		},
+		{
+			name: "score above 100 is sent back",
+			replies: []string{
+				strings.Replace(validResult, `"score": 82`, `"score": 150`, 1),
+				validResult,
+			},
+			wantScore: 82,
+			wantCalls: 2,
+		},
+		{
+			name: "negative score is sent back",
+			replies: []string{
+				strings.Replace(validResult, `"score": 82`, `"score": -5`, 1),
+				strings.Replace(validResult, `"score": 82`, `"score": 0`, 1),
+			},
+			wantScore: 0,
+			wantCalls: 2,
+		},
+		{
+			name:      "never valid",
+			replies:   []string{strings.Replace(validResult, `"score": 82`, `"score": 101`, 1)},
+			wantCalls: maxResponseAttempts,
+			wantErr:   "score",
+		},
+		{
+			name:      "missing field",
+			replies:   []string{`{"score": 50, "explanation": "ok"}`},
+			wantCalls: maxResponseAttempts,
+			wantErr:   "confidence",
+		},
+		{
+			name:      "unknown verdict",
+			replies:   []string{strings.Replace(validResult, `"met"`, `"maybe"`, 1)},
+			wantCalls: maxResponseAttempts,
+			wantErr:   "verdict",
+		},
+		{
+			name:      "not json",
+			replies:   []string{"I think this is a good match, 80/100."},
+			wantCalls: maxResponseAttempts,
+			wantErr:   "invalid match result",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &FakeProvider{Replies: tt.replies}
+			result, err := NewMatcher(provider, nil).Match(context.Background(), "We build autonomy", nil, testSolicitation)
+			if provider.calls != tt.wantCalls {
+				t.Errorf("provider called %d times, want %d", provider.calls, tt.wantCalls)
+			}
+
+			if tt.wantErr != "" {
+				var respErr *ResponseError
+				if !errors.As(err, &respErr) {
+					t.Fatalf("Match() error = %v, want a ResponseError", err)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Match() error = %q, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Match() error = %v", err)
+			}
+			if result.Score != tt.wantScore {
+				t.Errorf("score = %d, want %d", result.Score, tt.wantScore)
+			}
+			if result.PromptVersion != 0 {
+				t.Errorf("prompt version = %d, want the default (0)", result.PromptVersion)
+			}
+		})
+	}
+}
+
+// TestMatchCorrection checks that an invalid reply is shown back to the model
+// with what was wrong with it
+func TestMatchCorrection(t *testing.T) {
+	provider := &recordingProvider{FakeProvider: FakeProvider{Replies: []string{`{"score": 500}`, validResult}}}
+	if _, err := NewMatcher(provider, nil).Match(context.Background(), "We build autonomy", nil, testSolicitation); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(provider.requests) != 2 {
+		t.Fatalf("%d requests, want 2", len(provider.requests))
+	}
+	retry := provider.requests[1].Messages
+	if len(retry) != 3 || retry[1].Role != "assistant" || retry[1].Content != `{"score": 500}` {
+		t.Fatalf("retry does not include the invalid reply: %+v", retry)
+	}
+	if !strings.Contains(retry[2].Content, "score") {
+		t.Errorf("correction %q does not mention the score", retry[2].Content)
+	}
+	for _, req := range provider.requests {
+		if string(req.Schema) != string(matchResultSchemaJSON) {
+			t.Error("request was sent without the match result schema")
+		}
+	}
+}
+
+// TestFakeProviderMatchResults checks that schema-derived fake replies always
+// validate, with scores kept within 0-100, whatever the prompt
+func TestFakeProviderMatchResults(t *testing.T) {
+	matcher := NewMatcher(&FakeProvider{}, nil)
+	seen := make(map[int]bool)
+	for i := 0; i < 200; i++ {
+		sol := testSolicitation
+		sol.Title = fmt.Sprintf("Solicitation %d", i)
+		result, err := matcher.Match(context.Background(), "We build autonomy", nil, sol)
+		if err != nil {
+			t.Fatalf("Match(%q) error = %v", sol.Title, err)
+		}
+		if result.Score < 0 || result.Score > 100 {
+			t.Fatalf("score %d is outside 0-100", result.Score)
+		}
+		if result.Confidence < 0 || result.Confidence > 1 {
+			t.Fatalf("confidence %v is outside 0-1", result.Confidence)
+		}
+		seen[result.Score] = true
+	}
+	if len(seen) < 20 {
+		t.Errorf("only %d distinct scores over 200 prompts", len(seen))
+	}
+
+	// The same prompt always gets the same score
+	a, _ := matcher.Match(context.Background(), "We build autonomy", nil, testSolicitation)
+	b, _ := matcher.Match(context.Background(), "We build autonomy", nil, testSolicitation)
+	if a.Score != b.Score {
+		t.Errorf("scores %d and %d for the same prompt", a.Score, b.Score)
+	}
+}
+
+func TestMatchResultDetails(t *testing.T) {
+	var result MatchResult
+	if err := json.Unmarshal([]byte(validResult), &result); err != nil {
+		t.Fatal(err)
+	}
+	details, err := json.Marshal(result.Details())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"confidence", "criteria", "matched_keywords", "disqualifiers"} {
+		if !strings.Contains(string(details), `"`+field+`"`) {
+			t.Errorf("details %s are missing %s", details, field)
+		}
+	}
+	if strings.Contains(string(details), "score") || strings.Contains(string(details), "explanation") {
+		t.Errorf("details %s repeat the score or explanation", details)
+	}
+}
+
+// recordingProvider keeps the requests it is sent
+type recordingProvider struct {
+	FakeProvider
+	requests []ChatRequest
+}
+
+func (p *recordingProvider) Complete(ctx context.Context, req ChatRequest) (string, error) {
+	p.requests = append(p.requests, ChatRequest{Messages: append([]ChatMessage(nil), req.Messages...), Schema: req.Schema, SchemaName: req.SchemaName})
+	return p.FakeProvider.Complete(ctx, req)
+}
@@ -0,0 +1,150 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// OllamaProvider talks to Ollama's native /api/chat endpoint, which enforces
+// a JSON Schema through its "format" field
+type OllamaProvider struct {
+	// BaseURL is the Ollama server, e.g. http://127.0.0.1:11434. A trailing
+	// /v1 (the OpenAI-compatible path) is ignored.
+	BaseURL string
+	Model   string
+	Client  *http.Client
+}
+
+type ollamaChunk struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done  bool   `json:"done"`
+	Error string `json:"error"`
+}
+
+func (p *OllamaProvider) request(ctx context.Context, req ChatRequest, stream bool) (*http.Response, error) {
+	body := map[string]interface{}{
+		"model":    p.Model,
+		"messages": req.Messages,
+		"stream":   stream,
+	}
+	if len(req.Schema) > 0 {
+		body["format"] = req.Schema
+	}
+
+	base := strings.TrimSuffix(trimBaseURL(p.BaseURL), "/v1")
+	jsonBody, _ := json.Marshal(body)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", base+"/api/chat", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.Client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, statusError(resp)
+	}
+	return resp, nil
+}
+
+func (p *OllamaProvider) Complete(ctx context.Context, req ChatRequest) (string, error) {
+	resp, err := p.request(ctx, req, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var chunk ollamaChunk
+	if err := json.NewDecoder(resp.Body).Decode(&chunk); err != nil {
+		return "", err
+	}
+	if chunk.Error != "" {
+		return "", errors.New(chunk.Error)
+	}
+	return chunk.Message.Content, nil
+}
+
+// Stream reads the newline-delimited JSON objects of a streamed reply
+func (p *OllamaProvider) Stream(ctx context.Context, req ChatRequest, onChunk func(string) error) error {
+	resp, err := p.request(ctx, req, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var chunk ollamaChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			// Skip malformed chunks
+			continue
+		}
+		if chunk.Error != "" {
+			return errors.New(chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			if err := onChunk(chunk.Message.Content); err != nil {
+				return err
+			}
+		}
+		if chunk.Done {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// Embed calls the native /api/embed endpoint
+func (p *OllamaProvider) Embed(ctx context.Context, model string, input []string) ([][]float32, error) {
+	jsonBody, _ := json.Marshal(map[string]interface{}{
+		"model": model,
+		"input": input,
+	})
+	base := strings.TrimSuffix(trimBaseURL(p.BaseURL), "/v1")
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", base+"/api/embed", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.Client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	var embResp struct {
+		Embeddings [][]float32 `json:"embeddings"`
+		Error      string      `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
+		return nil, err
+	}
+	if embResp.Error != "" {
+		return nil, errors.New(embResp.Error)
+	}
+	if len(embResp.Embeddings) != len(input) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(input), len(embResp.Embeddings))
+	}
+	return embResp.Embeddings, nil
+}
@@ -0,0 +1,165 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// OpenAIProvider talks to an OpenAI-compatible /chat/completions endpoint,
+// which includes Ollama's /v1, vLLM, LM Studio and hosted APIs
+type OpenAIProvider struct {
+	BaseURL string
+	APIKey  string
+	Model   string
+	Client  *http.Client
+}
+
+func (p *OpenAIProvider) request(ctx context.Context, req ChatRequest, stream bool) (*http.Response, error) {
+	body := map[string]interface{}{
+		"model":    p.Model,
+		"messages": req.Messages,
+		"stream":   stream,
+	}
+	if len(req.Schema) > 0 {
+		body["response_format"] = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   req.SchemaName,
+				"schema": req.Schema,
+			},
+		}
+	}
+
+	jsonBody, _ := json.Marshal(body)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", trimBaseURL(p.BaseURL)+"/chat/completions", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	setAuth(httpReq, p.APIKey)
+
+	resp, err := p.Client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, statusError(resp)
+	}
+	return resp, nil
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, req ChatRequest) (string, error) {
+	resp, err := p.request(ctx, req, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var chatResp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return "", err
+	}
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("no response from LLM")
+	}
+	return chatResp.Choices[0].Message.Content, nil
+}
+
+// Stream reads the server-sent events of a streamed completion
+func (p *OpenAIProvider) Stream(ctx context.Context, req ChatRequest, onChunk func(string) error) error {
+	resp, err := p.request(ctx, req, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		// Expected: "data: {JSON}"
+		line := scanner.Bytes()
+		if !bytes.HasPrefix(line, []byte("data: ")) {
+			continue
+		}
+
+		data := bytes.TrimPrefix(line, []byte("data: "))
+		if string(data) == "[DONE]" {
+			return nil
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			// Skip malformed chunks
+			continue
+		}
+
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			if err := onChunk(chunk.Choices[0].Delta.Content); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// Embed calls the /embeddings endpoint
+func (p *OpenAIProvider) Embed(ctx context.Context, model string, input []string) ([][]float32, error) {
+	jsonBody, _ := json.Marshal(map[string]interface{}{
+		"model": model,
+		"input": input,
+	})
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", trimBaseURL(p.BaseURL)+"/embeddings", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	setAuth(httpReq, p.APIKey)
+
+	resp, err := p.Client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	var embResp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
+		return nil, err
+	}
+	if len(embResp.Data) != len(input) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(input), len(embResp.Data))
+	}
+
+	vectors := make([][]float32, len(input))
+	for _, d := range embResp.Data {
+		if d.Index < 0 || d.Index >= len(input) || vectors[d.Index] != nil {
+			return nil, fmt.Errorf("unexpected embedding index %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
@@ -0,0 +1,109 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Provider kinds
+const (
+	ProviderOpenAI = "openai"
+	ProviderOllama = "ollama"
+	ProviderFake   = "fake"
+)
+
+// ChatRequest is a provider-neutral chat completion request
+type ChatRequest struct {
+	Messages []ChatMessage
+	// Schema, if set, asks for a JSON reply that conforms to this JSON Schema
+	Schema json.RawMessage
+	// SchemaName identifies the schema to providers that want a name
+	SchemaName string
+}
+
+// LLMProvider sends chat requests to a language model
+type LLMProvider interface {
+	// Complete returns the model's whole reply
+	Complete(ctx context.Context, req ChatRequest) (string, error)
+	// Stream passes the reply to onChunk as it is generated
+	Stream(ctx context.Context, req ChatRequest, onChunk func(string) error) error
+	// Embed returns one vector per input, in order, from the named embedding model
+	Embed(ctx context.Context, model string, input []string) ([][]float32, error)
+}
+
+// StatusError is returned when the LLM endpoint answers with a non-200 status
+type StatusError struct {
+	StatusCode int
+	Status     string
+	// RetryAfter is the delay requested by the server, if any
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("LLM returned status: %s", e.Status)
+}
+
+// Temporary reports whether the request may succeed if retried: rate limiting
+// and server errors
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// NewProvider creates a provider of the given kind. An empty kind means an
+// OpenAI-compatible endpoint.
+func NewProvider(kind, url, key, model string) (LLMProvider, error) {
+	switch kind {
+	case "", ProviderOpenAI:
+		return &OpenAIProvider{BaseURL: url, APIKey: key, Model: model, Client: newLLMClient()}, nil
+	case ProviderOllama:
+		return &OllamaProvider{BaseURL: url, Model: model, Client: newLLMClient()}, nil
+	case ProviderFake:
+		return &FakeProvider{}, nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q (use openai, ollama or fake)", kind)
+	}
+}
+
+// newLLMClient bounds how long a request may wait for response headers. The
+// body is not bounded so that long streams are not cut off.
+func newLLMClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 120 * time.Second
+	return &http.Client{Transport: transport}
+}
+
+// statusError builds a StatusError from a non-200 response
+func statusError(resp *http.Response) error {
+	return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, RetryAfter: retryAfter(resp.Header.Get("Retry-After"))}
+}
+
+// setAuth adds a bearer token unless the key is empty or the config placeholder
+func setAuth(req *http.Request, key string) {
+	if key != "" && key != "sk-..." {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+}
+
+// trimBaseURL drops a trailing slash so paths can be appended
+func trimBaseURL(url string) string {
+	return strings.TrimRight(url, "/")
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
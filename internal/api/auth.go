@@ -1,22 +1,37 @@
 package api
 
 import (
+	"bd_bot/internal/config"
+	"bd_bot/internal/oidc"
+	"bd_bot/internal/rbac"
 	"bd_bot/internal/repository"
 	"context"
+	"database/sql"
 	"encoding/json"
-	"fmt"
+	"errors"
+	"log/slog"
+	"net"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
 )
 
+// sessionCookie holds the opaque session token
+const sessionCookie = "session"
+
 type AuthHandler struct {
-	repo *repository.UserRepository
+	repo          *repository.UserRepository
+	sessions      *repository.SessionRepository
+	sessionTTL    time.Duration
+	secureCookies bool
+
+	// oidc is nil when single sign-on is not configured
+	oidc    *oidc.Provider
+	oidcCfg config.OIDCConfig
 }
 
 type LoginRequest struct {
 	Email    string `json:"email"`
-	FullName string `json:"full_name"`
 	Password string `json:"password"`
 }
 
@@ -37,47 +52,71 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.repo.FindByEmail(r.Context(), req.Email)
-	if err != nil {
-		// User not found
-		if req.Password != "" {
-			http.Error(w, "Invalid email or password", http.StatusUnauthorized)
-			return
-		}
+	if req.Password == "" {
+		http.Error(w, "Password is required", http.StatusBadRequest)
+		return
+	}
 
-		// Auto-provisioning (Mock SSO flow)
-		if req.FullName == "" {
-			req.FullName = "New User"
-		}
-		user, err = h.repo.Create(r.Context(), req.Email, req.FullName)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Error creating user: %v", err), http.StatusInternalServerError)
-			return
-		}
-	} else {
-		// User found
-		if req.Password != "" {
-			if !h.repo.VerifyPassword(r.Context(), user, req.Password) {
-				http.Error(w, "Invalid email or password", http.StatusUnauthorized)
-				return
-			}
-		}
+	user, err := h.repo.FindByEmail(r.Context(), req.Email)
+	if err != nil || !h.repo.VerifyPassword(r.Context(), user, req.Password) {
+		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
+		return
 	}
 
 	// Update Activity
 	h.repo.UpdateLastActive(r.Context(), user.ID)
 
-	// Set Session Cookie
+	// Start Session
+	if err := h.startSession(w, r, user.ID); err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newMeResponse(user))
+}
+
+// MeResponse is the current user together with the permissions granted by their role
+type MeResponse struct {
+	*repository.User
+	Permissions []string `json:"permissions"`
+}
+
+func newMeResponse(user *repository.User) MeResponse {
+	return MeResponse{User: user, Permissions: rbac.Permissions(user.Role)}
+}
+
+// startSession creates a session for the user and sets the session cookie
+func (h *AuthHandler) startSession(w http.ResponseWriter, r *http.Request, userID int) error {
+	token, session, err := h.sessions.Create(r.Context(), userID, r.UserAgent(), clientIP(r), h.sessionTTL)
+	if err != nil {
+		slog.Error("Failed to create session", "user_id", userID, "error", err)
+		return err
+	}
+
 	http.SetCookie(w, &http.Cookie{
-		Name:     "user_id",
-		Value:    strconv.Itoa(user.ID),
-		Expires:  time.Now().Add(24 * time.Hour),
+		Name:     sessionCookie,
+		Value:    token,
+		Expires:  session.ExpiresAt,
 		HttpOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: http.SameSiteLaxMode,
 		Path:     "/",
 	})
+	return nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(user)
+func (h *AuthHandler) clearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+		Path:     "/",
+	})
 }
 
 type ChangePasswordRequest struct {
@@ -119,60 +158,193 @@ func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Sessions opened with the old password end; this one stays logged in.
+	// Through an API token there is no current session, so all of them end.
+	currentID, _ := r.Context().Value("session_id").(int)
+	if _, err := h.sessions.RevokeOthersForUser(r.Context(), userID, currentID); err != nil {
+		slog.Error("Failed to revoke sessions after password change", "user_id", userID, "error", err)
+		http.Error(w, "Password updated, but other sessions could not be logged out", http.StatusInternalServerError)
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"message": "Password updated successfully"}`))
 }
 
 func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
-	cookie, err := r.Cookie("user_id")
+	userID := r.Context().Value("user_id").(int)
+
+	user, err := h.repo.FindByID(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		http.Error(w, "User not found", http.StatusUnauthorized)
 		return
 	}
 
-	userID, err := strconv.Atoi(cookie.Value)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newMeResponse(user))
+}
+
+// Logout revokes the current session
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookie); err == nil && cookie.Value != "" {
+		if session, err := h.sessions.Validate(r.Context(), cookie.Value); err == nil {
+			h.sessions.Revoke(r.Context(), session.ID)
+		}
+	}
+	h.clearSessionCookie(w)
+	w.WriteHeader(http.StatusOK)
+}
+
+// LogoutEverywhere revokes every session of the current user, including this one
+func (h *AuthHandler) LogoutEverywhere(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	count, err := h.sessions.RevokeAllForUser(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		http.Error(w, "Failed to revoke sessions", http.StatusInternalServerError)
 		return
 	}
+	h.clearSessionCookie(w)
 
-	user, err := h.repo.FindByID(r.Context(), userID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"revoked": count})
+}
+
+type SessionInfo struct {
+	repository.Session
+	Current bool `json:"current"`
+}
+
+// ListSessions returns the current user's active sessions
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	currentID, _ := r.Context().Value("session_id").(int)
+
+	sessions, err := h.sessions.ListActive(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "User not found", http.StatusUnauthorized)
+		http.Error(w, "Failed to fetch sessions", http.StatusInternalServerError)
 		return
 	}
 
+	result := make([]SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		result = append(result, SessionInfo{Session: s, Current: s.ID == currentID})
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(user)
+	json.NewEncoder(w).Encode(result)
 }
 
-func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	http.SetCookie(w, &http.Cookie{
-		Name:     "user_id",
-		Value:    "",
-		Expires:  time.Now().Add(-1 * time.Hour),
-		HttpOnly: true,
-		Path:     "/",
-	})
-	w.WriteHeader(http.StatusOK)
+// clientIP returns the remote address without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
 }
 
-// Middleware to check session
-func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie("user_id")
-		if err != nil {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
+// AuthMiddleware returns a middleware that resolves the session cookie, or a
+// personal API token sent as "Authorization: Bearer", to a user and stores
+// user_id and session_id (or token_id) in the request context
+func AuthMiddleware(sessions *repository.SessionRepository, tokens *repository.APITokenRepository) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx, status, msg := authenticate(r, sessions, tokens)
+			if status != 0 {
+				http.Error(w, msg, status)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
 		}
+	}
+}
 
-		userID, err := strconv.Atoi(cookie.Value)
+// OptionalAuthMiddleware is like AuthMiddleware but lets anonymous requests
+// through without a user_id. A stale session cookie is treated as anonymous;
+// an invalid bearer token is still rejected.
+func OptionalAuthMiddleware(sessions *repository.SessionRepository, tokens *repository.APITokenRepository) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx, status, msg := authenticate(r, sessions, tokens)
+			if status != 0 {
+				if _, ok := bearerToken(r); ok {
+					http.Error(w, msg, status)
+					return
+				}
+				ctx = r.Context()
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+	}
+}
+
+// authenticate resolves the request's credentials. On failure it returns the
+// HTTP status and message to respond with.
+func authenticate(r *http.Request, sessions *repository.SessionRepository, tokens *repository.APITokenRepository) (context.Context, int, string) {
+	if secret, ok := bearerToken(r); ok {
+		token, err := tokens.Validate(r.Context(), secret)
 		if err != nil {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-			return
+			if !errors.Is(err, sql.ErrNoRows) {
+				slog.Error("Failed to validate API token", "error", err)
+			}
+			return nil, http.StatusUnauthorized, "Invalid or expired token"
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && !token.HasScope(repository.ScopeWrite) {
+			return nil, http.StatusForbidden, "Token does not have the write scope"
+		}
+		tokens.Touch(r.Context(), token.ID)
+
+		ctx := context.WithValue(r.Context(), "user_id", token.UserID)
+		return context.WithValue(ctx, "token_id", token.ID), 0, ""
+	}
+
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil || cookie.Value == "" {
+		return nil, http.StatusUnauthorized, "Unauthorized"
+	}
+
+	session, err := sessions.Validate(r.Context(), cookie.Value)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("Failed to validate session", "error", err)
 		}
+		return nil, http.StatusUnauthorized, "Invalid or expired session"
+	}
+	sessions.Touch(r.Context(), session.ID)
+
+	ctx := context.WithValue(r.Context(), "user_id", session.UserID)
+	return context.WithValue(ctx, "session_id", session.ID), 0, ""
+}
 
-		ctx := context.WithValue(r.Context(), "user_id", userID)
-		next.ServeHTTP(w, r.WithContext(ctx))
+// bearerToken extracts the token from an "Authorization: Bearer" header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
 	}
-}
\ No newline at end of file
+	return strings.TrimSpace(token), true
+}
+
+// RequireRole returns a middleware that only lets users with one of roles
+// through. It must run inside AuthMiddleware.
+func RequireRole(users *repository.UserRepository, roles ...string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			userID := r.Context().Value("user_id").(int)
+			user, err := users.FindByID(r.Context(), userID)
+			if err != nil {
+				http.Error(w, "User not found", http.StatusUnauthorized)
+				return
+			}
+
+			for _, role := range roles {
+				if user.Role == role {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bd_bot/internal/ai"
+	"bd_bot/internal/prompt"
 	"bd_bot/internal/repository"
 	"context"
 	"encoding/json"
@@ -15,10 +16,11 @@ type ChatHandler struct {
 	chatSvc   *ai.ChatService
 	userRepo  *repository.UserRepository
 	chatRepo  *repository.ChatRepository
+	prompts   *prompt.Store
 }
 
-func NewChatHandler(svc *ai.ChatService, userRepo *repository.UserRepository, chatRepo *repository.ChatRepository) *ChatHandler {
-	return &ChatHandler{chatSvc: svc, userRepo: userRepo, chatRepo: chatRepo}
+func NewChatHandler(svc *ai.ChatService, userRepo *repository.UserRepository, chatRepo *repository.ChatRepository, prompts *prompt.Store) *ChatHandler {
+	return &ChatHandler{chatSvc: svc, userRepo: userRepo, chatRepo: chatRepo, prompts: prompts}
 }
 
 type ChatRequest struct {
@@ -63,19 +65,18 @@ func (h *ChatHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	var messages []ai.ChatMessage
 
 	// System Prompt
-	systemMsg := fmt.Sprintf(`You are Joshua, a business intelligence system designed for GTRI (Georgia Tech Research Institute).
-User: %s (%s)
-Role: %s
-Organization: %s
-
-Current View Context:
-%s
-
-Instructions:
-- Provide helpful, context-aware responses.
-- Use the provided View Context to answer specific questions about what the user is seeing.
-- Maintain professional tone.
-`, user.FullName, user.Email, user.Role, user.Organization, req.Context)
+	systemMsg, _, err := h.prompts.Render(r.Context(), prompt.Chat, prompt.ChatData{
+		UserName:     user.FullName,
+		UserEmail:    user.Email,
+		Role:         user.Role,
+		Organization: user.Organization,
+		Context:      req.Context,
+	})
+	if err != nil {
+		slog.Error("Failed to render chat prompt", "error", err)
+		http.Error(w, "Failed to build chat prompt", http.StatusInternalServerError)
+		return
+	}
 
 	messages = append(messages, ai.ChatMessage{Role: "system", Content: systemMsg})
 
@@ -105,7 +106,7 @@ Instructions:
 
 	var fullResponse strings.Builder
 
-	err = h.chatSvc.ChatStream(messages, func(chunk string) error {
+	err = h.chatSvc.ChatStream(r.Context(), messages, func(chunk string) error {
 		fullResponse.WriteString(chunk)
 		payloadBytes, _ := json.Marshal(map[string]string{"content": chunk})
 		fmt.Fprintf(w, "data: %s\n\n", payloadBytes)
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"bd_bot/internal/matching"
+	"bd_bot/internal/repository"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// sseKeepAlive is how often an idle event stream sends a comment so proxies
+// don't close it
+const sseKeepAlive = 15 * time.Second
+
+type MatchJobHandler struct {
+	jobs      *matching.JobManager
+	auditRepo *repository.AuditRepository
+}
+
+// Run queues a matching job for the caller. If one is already queued or
+// running it is returned with 409 Conflict.
+func (h *MatchJobHandler) Run(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	job, err := h.jobs.Enqueue(r.Context(), userID)
+	switch {
+	case errors.Is(err, matching.ErrJobActive):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(job)
+		return
+	case errors.Is(err, matching.ErrNoNarrative):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, matching.ErrQueueFull):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		http.Error(w, "Failed to start matching", http.StatusInternalServerError)
+		return
+	}
+
+	h.auditRepo.Log(r.Context(), userID, "run_matching", "match_job", job.ID, nil, r.RemoteAddr)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// job loads the job named in the path, answering 404 unless it belongs to the caller
+func (h *MatchJobHandler) job(w http.ResponseWriter, r *http.Request) (*repository.MatchJob, bool) {
+	userID := r.Context().Value("user_id").(int)
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return nil, false
+	}
+	job, err := h.jobs.Get(r.Context(), id)
+	if err != nil || job.UserID != userID {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return nil, false
+	}
+	return job, true
+}
+
+// Get returns a job's status and progress
+func (h *MatchJobHandler) Get(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.job(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// Events streams a job's progress as server-sent events: "progress" with the
+// job state, "match" for each saved score, and a final "done"
+func (h *MatchJobHandler) Events(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.job(w, r)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	send := func(event matching.JobEvent) {
+		payload, _ := json.Marshal(event)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, payload)
+		flusher.Flush()
+	}
+
+	events, unsubscribe, live := h.jobs.Subscribe(job.ID)
+	if !live {
+		// Finished before we subscribed; reload in case it finished since h.job
+		if final, err := h.jobs.Get(r.Context(), job.ID); err == nil {
+			job = final
+		}
+		send(matching.JobEvent{Type: matching.EventDone, Job: *job})
+		return
+	}
+	defer unsubscribe()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			send(event)
+			if event.Type == matching.EventDone {
+				return
+			}
+		}
+	}
+}
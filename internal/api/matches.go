@@ -2,7 +2,9 @@ package api
 
 import (
 	"bd_bot/internal/repository"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 )
@@ -17,24 +19,105 @@ func (h *MatchHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cookie, err := r.Cookie("user_id")
+	userID := r.Context().Value("user_id").(int)
+
+	matches, err := h.repo.GetUserInbox(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		http.Error(w, "Failed to fetch matches", http.StatusInternalServerError)
 		return
 	}
 
-	userID, err := strconv.Atoi(cookie.Value)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(matches)
+}
+
+// Distribution returns a histogram of all the user's scores, including those
+// below their threshold
+func (h *MatchHandler) Distribution(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	dist, err := h.repo.GetScoreDistribution(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusUnauthorized)
+		http.Error(w, "Failed to fetch score distribution", http.StatusInternalServerError)
 		return
 	}
 
-	matches, err := h.repo.GetUserInbox(r.Context(), userID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dist)
+}
+
+type MatchFeedbackRequest struct {
+	// Rating is "up" or "down"
+	Rating string `json:"rating"`
+}
+
+// SetFeedback records the caller's thumbs-up or thumbs-down on one of their matches
+func (h *MatchHandler) SetFeedback(w http.ResponseWriter, r *http.Request) {
+	matchID, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
-		http.Error(w, "Failed to fetch matches", http.StatusInternalServerError)
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+
+	var req MatchFeedbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Rating != repository.RatingUp && req.Rating != repository.RatingDown {
+		http.Error(w, "rating must be \"up\" or \"down\"", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(int)
+	if err := h.repo.SetFeedback(r.Context(), userID, matchID, req.Rating); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Match not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to save feedback", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(matches)
+	json.NewEncoder(w).Encode(map[string]interface{}{"match_id": matchID, "rating": req.Rating})
+}
+
+// ClearFeedback removes the caller's rating of a match
+func (h *MatchHandler) ClearFeedback(w http.ResponseWriter, r *http.Request) {
+	matchID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(int)
+	if err := h.repo.ClearFeedback(r.Context(), userID, matchID); err != nil {
+		http.Error(w, "Failed to clear feedback", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// FeedbackReport shows how often matches scored at least min_score (default
+// 75) were rated thumbs-down, across all users
+func (h *MatchHandler) FeedbackReport(w http.ResponseWriter, r *http.Request) {
+	minScore := 75
+	if s := r.URL.Query().Get("min_score"); s != "" {
+		var err error
+		minScore, err = strconv.Atoi(s)
+		if err != nil || minScore < 0 || minScore > 100 {
+			http.Error(w, "Invalid min_score", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.repo.GetFeedbackReport(r.Context(), 0, minScore, 50)
+	if err != nil {
+		http.Error(w, "Failed to build feedback report", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
 }
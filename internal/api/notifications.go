@@ -0,0 +1,52 @@
+package api
+
+import (
+	"bd_bot/internal/repository"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type NotificationHandler struct {
+	repo *repository.NotificationRepository
+}
+
+func (h *NotificationHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+
+	notifications, err := h.repo.ListForUser(r.Context(), userID, unreadOnly, 50)
+	if err != nil {
+		http.Error(w, "Failed to fetch notifications", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notifications)
+}
+
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid notification ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.MarkRead(r.Context(), id, userID); err != nil {
+		http.Error(w, "Notification not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	if err := h.repo.MarkAllRead(r.Context(), userID); err != nil {
+		http.Error(w, "Failed to update notifications", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"bd_bot/internal/oidc"
+	"bd_bot/internal/repository"
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// oidcCookie carries the state, nonce and PKCE verifier across the IdP redirect
+const oidcCookie = "oidc_auth"
+
+type oidcPending struct {
+	oidc.AuthRequest
+	Redirect string `json:"redirect"`
+}
+
+type AuthConfigResponse struct {
+	OIDCEnabled  bool   `json:"oidc_enabled"`
+	ProviderName string `json:"provider_name,omitempty"`
+}
+
+// Config tells the login UI which sign-in methods are available
+func (h *AuthHandler) Config(w http.ResponseWriter, r *http.Request) {
+	resp := AuthConfigResponse{OIDCEnabled: h.oidc != nil}
+	if h.oidc != nil {
+		resp.ProviderName = h.oidcCfg.ProviderName
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// OIDCLogin starts the authorization code flow
+func (h *AuthHandler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if h.oidc == nil {
+		http.Error(w, "Single sign-on is not configured", http.StatusNotFound)
+		return
+	}
+
+	req, err := oidc.NewAuthRequest()
+	if err != nil {
+		http.Error(w, "Failed to start login", http.StatusInternalServerError)
+		return
+	}
+
+	authURL, err := h.oidc.AuthCodeURL(r.Context(), req)
+	if err != nil {
+		slog.Error("Failed to build OIDC authorization URL", "error", err)
+		http.Error(w, "Identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+
+	pending, _ := json.Marshal(oidcPending{AuthRequest: *req, Redirect: safeRedirect(r.URL.Query().Get("redirect"))})
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookie,
+		Value:    base64.RawURLEncoding.EncodeToString(pending),
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+		Path:     "/api/auth/oidc",
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback finishes the flow, links or provisions the user and starts a session
+func (h *AuthHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if h.oidc == nil {
+		http.Error(w, "Single sign-on is not configured", http.StatusNotFound)
+		return
+	}
+
+	pending, err := h.readPending(r)
+	http.SetCookie(w, &http.Cookie{Name: oidcCookie, Value: "", MaxAge: -1, Path: "/api/auth/oidc"})
+	if err != nil {
+		http.Error(w, "Login session expired; please try again", http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	if idpErr := q.Get("error"); idpErr != "" {
+		slog.Warn("Identity provider returned an error", "error", idpErr, "description", q.Get("error_description"))
+		http.Redirect(w, r, "/?login_error="+url.QueryEscape(idpErr), http.StatusFound)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(q.Get("state")), []byte(pending.State)) != 1 {
+		http.Error(w, "Invalid login state", http.StatusBadRequest)
+		return
+	}
+
+	claims, err := h.oidc.Exchange(r.Context(), q.Get("code"), &pending.AuthRequest)
+	if err != nil {
+		slog.Error("OIDC code exchange failed", "error", err)
+		http.Error(w, "Single sign-on failed", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.resolveOIDCUser(r.Context(), claims)
+	if err != nil {
+		slog.Warn("OIDC login rejected", "sub", claims.String("sub"), "error", err)
+		http.Redirect(w, r, "/?login_error="+url.QueryEscape(err.Error()), http.StatusFound)
+		return
+	}
+
+	h.repo.UpdateLastActive(r.Context(), user.ID)
+	if err := h.startSession(w, r, user.ID); err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, pending.Redirect, http.StatusFound)
+}
+
+func (h *AuthHandler) readPending(r *http.Request) (*oidcPending, error) {
+	cookie, err := r.Cookie(oidcCookie)
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+	var pending oidcPending
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil, err
+	}
+	if pending.State == "" {
+		return nil, errors.New("empty state")
+	}
+	return &pending, nil
+}
+
+// resolveOIDCUser finds the user linked to the IdP subject. An existing
+// account with the same email is linked only when the IdP has verified the
+// address; otherwise a new user is provisioned from the mapped claims.
+func (h *AuthHandler) resolveOIDCUser(ctx context.Context, claims oidc.Claims) (*repository.User, error) {
+	provider := h.oidcCfg.ProviderName
+	sub := claims.String("sub")
+
+	user, err := h.repo.FindByExternalID(ctx, provider, sub)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	email := strings.TrimSpace(claims.String(h.oidcCfg.EmailClaim))
+	if email == "" {
+		return nil, errors.New("identity provider did not return an email address")
+	}
+	name := claims.String(h.oidcCfg.NameClaim)
+	if name == "" {
+		name = email
+	}
+	org := ""
+	if h.oidcCfg.OrganizationClaim != "" {
+		org = claims.String(h.oidcCfg.OrganizationClaim)
+	}
+
+	existing, err := h.repo.FindByEmail(ctx, email)
+	if err == nil {
+		if !claims.Bool("email_verified") {
+			return nil, errors.New("an account with this email already exists; ask an admin to link it")
+		}
+		if err := h.repo.LinkExternal(ctx, existing.ID, provider, sub); err != nil {
+			return nil, err
+		}
+		slog.Info("Linked existing user to identity provider", "user_id", existing.ID, "provider", provider)
+		return h.repo.FindByID(ctx, existing.ID)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	user, err = h.repo.CreateExternal(ctx, email, name, org, provider, sub)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Provisioned user from identity provider", "user_id", user.ID, "provider", provider)
+	return user, nil
+}
+
+// safeRedirect only allows local paths so the callback cannot be used as an open redirect
+func safeRedirect(target string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return "/"
+	}
+	return target
+}
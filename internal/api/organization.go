@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bd_bot/internal/repository"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// OrganizationHandler serves the caller's organization: its narrative, version
+// history, match settings and shared inbox
+type OrganizationHandler struct {
+	repo      *repository.OrganizationRepository
+	userRepo  *repository.UserRepository
+	matchRepo *repository.MatchRepository
+	auditRepo *repository.AuditRepository
+}
+
+// organization loads the caller's organization, answering 404 if they don't have one
+func (h *OrganizationHandler) organization(w http.ResponseWriter, r *http.Request) (*repository.Organization, bool) {
+	userID := r.Context().Value("user_id").(int)
+	user, err := h.userRepo.FindByID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return nil, false
+	}
+	if user.Organization == "" {
+		http.Error(w, "You are not in an organization", http.StatusNotFound)
+		return nil, false
+	}
+	org, err := h.repo.FindByName(r.Context(), user.Organization)
+	if err != nil {
+		http.Error(w, "Organization not found", http.StatusNotFound)
+		return nil, false
+	}
+	return org, true
+}
+
+func (h *OrganizationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	org, ok := h.organization(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(org)
+}
+
+func (h *OrganizationHandler) UpdateNarrative(w http.ResponseWriter, r *http.Request) {
+	org, ok := h.organization(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateNarrativeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(int)
+	if err := h.repo.UpdateNarrative(r.Context(), org.ID, userID, req.Narrative); err != nil {
+		http.Error(w, "Failed to update narrative", http.StatusInternalServerError)
+		return
+	}
+	h.auditRepo.Log(r.Context(), userID, "update_org_narrative", "organization", org.ID, nil, r.RemoteAddr)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type UpdateOrganizationSettingsRequest struct {
+	MatchThreshold *int `json:"match_threshold"`
+	MatchTopK      *int `json:"match_top_k"`
+}
+
+// UpdateSettings changes the organization's match threshold and pre-filter
+// size; fields left out keep their current value
+func (h *OrganizationHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	org, ok := h.organization(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateOrganizationSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.MatchThreshold != nil {
+		if *req.MatchThreshold < 1 || *req.MatchThreshold > 100 {
+			http.Error(w, "match_threshold must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		org.MatchThreshold = *req.MatchThreshold
+	}
+	if req.MatchTopK != nil {
+		if *req.MatchTopK < 0 || *req.MatchTopK > 10000 {
+			http.Error(w, "match_top_k must be between 0 and 10000", http.StatusBadRequest)
+			return
+		}
+		org.MatchTopK = *req.MatchTopK
+	}
+
+	if err := h.repo.UpdateMatchSettings(r.Context(), org.ID, org.MatchThreshold, org.MatchTopK); err != nil {
+		http.Error(w, "Failed to update organization", http.StatusInternalServerError)
+		return
+	}
+	userID := r.Context().Value("user_id").(int)
+	h.auditRepo.Log(r.Context(), userID, "update_org_settings", "organization", org.ID, map[string]interface{}{
+		"match_threshold": org.MatchThreshold,
+		"match_top_k":     org.MatchTopK,
+	}, r.RemoteAddr)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(org)
+}
+
+func (h *OrganizationHandler) ListNarrativeVersions(w http.ResponseWriter, r *http.Request) {
+	org, ok := h.organization(w, r)
+	if !ok {
+		return
+	}
+	versions, err := h.repo.ListNarrativeVersions(r.Context(), org.ID)
+	if err != nil {
+		http.Error(w, "Failed to list narrative versions", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+func (h *OrganizationHandler) GetNarrativeVersion(w http.ResponseWriter, r *http.Request) {
+	org, ok := h.organization(w, r)
+	if !ok {
+		return
+	}
+	versionID, err := strconv.Atoi(r.URL.Query().Get("version"))
+	if err != nil {
+		http.Error(w, "invalid version ID", http.StatusBadRequest)
+		return
+	}
+
+	version, err := h.repo.GetNarrativeVersion(r.Context(), versionID, org.ID)
+	if err != nil {
+		http.Error(w, "version not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(version)
+}
+
+// Matches returns the organization's shared inbox, with the caller's own
+// score alongside where they have one
+func (h *OrganizationHandler) Matches(w http.ResponseWriter, r *http.Request) {
+	org, ok := h.organization(w, r)
+	if !ok {
+		return
+	}
+	userID := r.Context().Value("user_id").(int)
+
+	matches, err := h.matchRepo.GetOrganizationInbox(r.Context(), org.ID, userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch matches", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(matches)
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"bd_bot/internal/prompt"
+	"bd_bot/internal/repository"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// PromptHandler lets admins read and edit the LLM prompt templates
+type PromptHandler struct {
+	prompts   *prompt.Store
+	auditRepo *repository.AuditRepository
+}
+
+// List returns the version in use of every prompt
+func (h *PromptHandler) List(w http.ResponseWriter, r *http.Request) {
+	current := []*repository.PromptTemplate{}
+	for _, name := range prompt.Names() {
+		p, err := h.prompts.Current(r.Context(), name)
+		if err != nil {
+			http.Error(w, "Failed to load prompts", http.StatusInternalServerError)
+			return
+		}
+		current = append(current, p)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(current)
+}
+
+func (h *PromptHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !prompt.Valid(name) {
+		http.Error(w, "Unknown prompt", http.StatusNotFound)
+		return
+	}
+	versions, err := h.prompts.Versions(r.Context(), name)
+	if err != nil {
+		http.Error(w, "Failed to list prompt versions", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+func (h *PromptHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !prompt.Valid(name) {
+		http.Error(w, "Unknown prompt", http.StatusNotFound)
+		return
+	}
+	version, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil || version < 0 {
+		http.Error(w, "Invalid version", http.StatusBadRequest)
+		return
+	}
+	p, err := h.prompts.Version(r.Context(), name, version)
+	if err != nil {
+		http.Error(w, "Failed to load prompt", http.StatusInternalServerError)
+		return
+	}
+	if p == nil {
+		http.Error(w, "Version not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+type SavePromptRequest struct {
+	Body string `json:"body"`
+}
+
+// Save stores a new version of a prompt, which is used from then on. Templates
+// that do not parse or render are rejected.
+func (h *PromptHandler) Save(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !prompt.Valid(name) {
+		http.Error(w, "Unknown prompt", http.StatusNotFound)
+		return
+	}
+
+	var req SavePromptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := prompt.Check(name, req.Body); err != nil {
+		http.Error(w, "Invalid template: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(int)
+	p, err := h.prompts.Save(r.Context(), name, req.Body, userID)
+	if err != nil {
+		http.Error(w, "Failed to save prompt", http.StatusInternalServerError)
+		return
+	}
+	h.auditRepo.Log(r.Context(), userID, "update_prompt", "prompt", p.ID, map[string]interface{}{
+		"name":    p.Name,
+		"version": p.Version,
+	}, r.RemoteAddr)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(p)
+}
@@ -10,28 +10,17 @@ import (
 
 type RequirementsHandler struct {
 	repo     *repository.RequirementsRepository
-	userRepo *repository.UserRepository
 	taskRepo *repository.TaskRepository
 }
 
 func (h *RequirementsHandler) Get(w http.ResponseWriter, r *http.Request) {
-	// Check Role
-	userID := r.Context().Value("user_id").(int)
-	user, err := h.userRepo.FindByID(r.Context(), userID)
-	if err != nil {
-		http.Error(w, "User not found", http.StatusUnauthorized)
-		return
-	}
-	if user.Role != "admin" && user.Role != "developer" {
-		http.Error(w, "Forbidden", http.StatusForbidden)
-		return
-	}
-
 	versionStr := r.URL.Query().Get("version")
 	var doc *repository.RequirementsDoc
+	var err error
 
 	if versionStr != "" {
-		id, err := strconv.Atoi(versionStr)
+		var id int
+		id, err = strconv.Atoi(versionStr)
 		if err != nil {
 			http.Error(w, "Invalid version ID", http.StatusBadRequest)
 			return
@@ -59,18 +48,6 @@ func (h *RequirementsHandler) Get(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *RequirementsHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
-	// Check Role
-	userID := r.Context().Value("user_id").(int)
-	user, err := h.userRepo.FindByID(r.Context(), userID)
-	if err != nil {
-		http.Error(w, "User not found", http.StatusUnauthorized)
-		return
-	}
-	if user.Role != "admin" && user.Role != "developer" {
-		http.Error(w, "Forbidden", http.StatusForbidden)
-		return
-	}
-
 	versions, err := h.repo.ListVersions(r.Context())
 	if err != nil {
 		http.Error(w, "Internal error", http.StatusInternalServerError)
@@ -89,17 +66,7 @@ func (h *RequirementsHandler) Save(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check Role
 	userID := r.Context().Value("user_id").(int)
-	user, err := h.userRepo.FindByID(r.Context(), userID)
-	if err != nil {
-		http.Error(w, "User not found", http.StatusUnauthorized)
-		return
-	}
-	if user.Role != "admin" && user.Role != "developer" {
-		http.Error(w, "Forbidden", http.StatusForbidden)
-		return
-	}
 
 	var req SaveRequirementsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
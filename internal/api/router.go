@@ -2,8 +2,16 @@ package api
 
 import (
 	"bd_bot/internal/ai"
+	"bd_bot/internal/config"
+	"bd_bot/internal/mail"
+	"bd_bot/internal/matching"
+	"bd_bot/internal/oidc"
+	"bd_bot/internal/prompt"
+	"bd_bot/internal/rbac"
 	"bd_bot/internal/repository"
 	"net/http"
+	"strings"
+	"time"
 )
 
 func NewRouter(
@@ -17,63 +25,156 @@ func NewRouter(
 	chatSvc *ai.ChatService,
 	auditRepo *repository.AuditRepository,
 	chatRepo *repository.ChatRepository,
+	scrapeRunRepo *repository.ScrapeRunRepository,
+	notifRepo *repository.NotificationRepository,
+	outbox *mail.Outbox,
+	publicURL string,
+	sessionRepo *repository.SessionRepository,
+	sessionTTL time.Duration,
+	oidcCfg config.OIDCConfig,
+	tokenRepo *repository.APITokenRepository,
+	docRepo *repository.DocumentRepository,
+	matchJobs *matching.JobManager,
+	orgRepo *repository.OrganizationRepository,
+	prompts *prompt.Store,
 ) *http.ServeMux {
 	mux := http.NewServeMux()
+	requireAuth := AuthMiddleware(sessionRepo, tokenRepo)
+	optionalAuth := OptionalAuthMiddleware(sessionRepo, tokenRepo)
+	requirePerm := func(perm string) func(http.HandlerFunc) http.HandlerFunc {
+		return RequireRole(userRepo, rbac.RolesWith(perm)...)
+	}
+	canEngage := requirePerm(rbac.EngageSolicitations)
+	canReadReqs := requirePerm(rbac.ReadRequirements)
+	canWriteReqs := requirePerm(rbac.WriteRequirements)
+	canReadTasks := requirePerm(rbac.ReadTasks)
+	canWriteTasks := requirePerm(rbac.WriteTasks)
+	canReadIRAD := requirePerm(rbac.ReadIRAD)
+	canProposeIRAD := requirePerm(rbac.ProposeIRAD)
+	canManageIRAD := requirePerm(rbac.ManageIRAD)
+	canReviewIRAD := requirePerm(rbac.ReviewIRAD)
+	canManageOrg := requirePerm(rbac.ManageOrganization)
+	isAdmin := RequireRole(userRepo, rbac.Admin)
 
-	solHandler := &SolicitationHandler{repo: solRepo, auditRepo: auditRepo}
-	authHandler := &AuthHandler{repo: userRepo}
+	searchHandler := &SearchHandler{repo: solRepo}
+	solHandler := &SolicitationHandler{repo: solRepo, docRepo: docRepo, auditRepo: auditRepo, userRepo: userRepo, outbox: outbox, publicURL: publicURL}
+	authHandler := &AuthHandler{
+		repo:          userRepo,
+		sessions:      sessionRepo,
+		sessionTTL:    sessionTTL,
+		secureCookies: strings.HasPrefix(publicURL, "https://"),
+		oidcCfg:       oidcCfg,
+	}
+	if oidcCfg.IssuerURL != "" {
+		redirectURL := oidcCfg.RedirectURL
+		if redirectURL == "" {
+			redirectURL = strings.TrimRight(publicURL, "/") + "/api/auth/oidc/callback"
+		}
+		authHandler.oidc = oidc.NewProvider(oidc.Config{
+			IssuerURL:    oidcCfg.IssuerURL,
+			ClientID:     oidcCfg.ClientID,
+			ClientSecret: oidcCfg.ClientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       oidcCfg.Scopes,
+		})
+	}
 	userHandler := &UserHandler{repo: userRepo, auditRepo: auditRepo}
+	tokenHandler := &TokenHandler{repo: tokenRepo, auditRepo: auditRepo}
 	matchHandler := &MatchHandler{repo: matchRepo}
+	matchJobHandler := &MatchJobHandler{jobs: matchJobs, auditRepo: auditRepo}
+	orgHandler := &OrganizationHandler{repo: orgRepo, userRepo: userRepo, matchRepo: matchRepo, auditRepo: auditRepo}
 	feedbackHandler := &FeedbackHandler{repo: feedbackRepo}
-	reqHandler := &RequirementsHandler{repo: reqRepo, userRepo: userRepo, taskRepo: taskRepo}
+	reqHandler := &RequirementsHandler{repo: reqRepo, taskRepo: taskRepo}
 	taskHandler := NewTaskHandler(taskRepo)
-	chatHandler := NewChatHandler(chatSvc, userRepo, chatRepo)
+	chatHandler := NewChatHandler(chatSvc, userRepo, chatRepo, prompts)
 	iradHandler := NewIRADHandler(iradRepo, userRepo)
+	scraperHandler := &ScraperHandler{runRepo: scrapeRunRepo}
+	notifHandler := &NotificationHandler{repo: notifRepo}
+	promptHandler := &PromptHandler{prompts: prompts, auditRepo: auditRepo}
 
 	// Solicitations
-	mux.HandleFunc("GET /api/solicitations", solHandler.List)
+	mux.HandleFunc("GET /api/solicitations", optionalAuth(solHandler.List))
 	mux.HandleFunc("GET /api/solicitations/{id}", solHandler.Get)
-	mux.HandleFunc("POST /api/solicitations/{id}/claim", AuthMiddleware(solHandler.Claim))
-	mux.HandleFunc("POST /api/solicitations/{id}/comments", AuthMiddleware(solHandler.AddComment))
-	mux.HandleFunc("POST /api/solicitations/{id}/archive", AuthMiddleware(solHandler.Archive))
-	mux.HandleFunc("POST /api/solicitations/{id}/share", AuthMiddleware(solHandler.Share))
+	mux.HandleFunc("GET /api/solicitations/{id}/history", solHandler.History)
+	mux.HandleFunc("GET /api/solicitations/{id}/documents", solHandler.Documents)
+	mux.HandleFunc("POST /api/solicitations/{id}/claim", requireAuth(canEngage(solHandler.Claim)))
+	mux.HandleFunc("POST /api/solicitations/{id}/comments", requireAuth(canEngage(solHandler.AddComment)))
+	mux.HandleFunc("POST /api/solicitations/{id}/archive", requireAuth(canEngage(solHandler.Archive)))
+	mux.HandleFunc("POST /api/solicitations/{id}/share", requireAuth(canEngage(solHandler.Share)))
+
+	mux.HandleFunc("GET /api/search", searchHandler.Search)
 
 	// Matches
-	mux.HandleFunc("GET /api/matches", matchHandler.List)
+	mux.HandleFunc("GET /api/matches", requireAuth(matchHandler.List))
+	mux.HandleFunc("GET /api/matches/distribution", requireAuth(matchHandler.Distribution))
+	mux.HandleFunc("POST /api/matches/{id}/feedback", requireAuth(matchHandler.SetFeedback))
+	mux.HandleFunc("DELETE /api/matches/{id}/feedback", requireAuth(matchHandler.ClearFeedback))
+	mux.HandleFunc("POST /api/matches/run", requireAuth(matchJobHandler.Run))
+	mux.HandleFunc("GET /api/matches/jobs/{id}", requireAuth(matchJobHandler.Get))
+	mux.HandleFunc("GET /api/matches/jobs/{id}/events", requireAuth(matchJobHandler.Events))
+
+	// Organization
+	mux.HandleFunc("GET /api/organization", requireAuth(orgHandler.Get))
+	mux.HandleFunc("GET /api/organization/matches", requireAuth(orgHandler.Matches))
+	mux.HandleFunc("PUT /api/organization/narrative", requireAuth(canManageOrg(orgHandler.UpdateNarrative)))
+	mux.HandleFunc("GET /api/organization/narrative/versions", requireAuth(orgHandler.ListNarrativeVersions))
+	mux.HandleFunc("GET /api/organization/narrative/version", requireAuth(orgHandler.GetNarrativeVersion))
+	mux.HandleFunc("PUT /api/organization/settings", requireAuth(canManageOrg(orgHandler.UpdateSettings)))
 
 	// Auth & User
+	mux.HandleFunc("GET /api/auth/config", authHandler.Config)
 	mux.HandleFunc("POST /api/auth/login", authHandler.Login)
+	mux.HandleFunc("GET /api/auth/oidc/login", authHandler.OIDCLogin)
+	mux.HandleFunc("GET /api/auth/oidc/callback", authHandler.OIDCCallback)
 	mux.HandleFunc("POST /api/auth/logout", authHandler.Logout)
-	mux.HandleFunc("GET /api/auth/me", authHandler.Me)
-	mux.HandleFunc("POST /api/auth/password", AuthMiddleware(authHandler.ChangePassword))
+	mux.HandleFunc("GET /api/auth/me", requireAuth(authHandler.Me))
+	mux.HandleFunc("POST /api/auth/logout-all", requireAuth(authHandler.LogoutEverywhere))
+	mux.HandleFunc("GET /api/auth/sessions", requireAuth(authHandler.ListSessions))
+	mux.HandleFunc("POST /api/auth/password", requireAuth(authHandler.ChangePassword))
 	
-	mux.HandleFunc("PUT /api/user/narrative", AuthMiddleware(userHandler.UpdateNarrative))
-	mux.HandleFunc("GET /api/user/narrative/versions", AuthMiddleware(userHandler.ListNarrativeVersions))
-	mux.HandleFunc("GET /api/user/narrative/version", AuthMiddleware(userHandler.GetNarrativeVersion))
-	mux.HandleFunc("PUT /api/user/profile", AuthMiddleware(userHandler.UpdateProfile))
-	mux.HandleFunc("POST /api/user/avatar", AuthMiddleware(userHandler.UploadAvatar))
-	mux.HandleFunc("GET /api/organizations", AuthMiddleware(userHandler.ListOrganizations))
+	mux.HandleFunc("PUT /api/user/narrative", requireAuth(userHandler.UpdateNarrative))
+	mux.HandleFunc("GET /api/user/narrative/versions", requireAuth(userHandler.ListNarrativeVersions))
+	mux.HandleFunc("GET /api/user/narrative/version", requireAuth(userHandler.GetNarrativeVersion))
+	mux.HandleFunc("PUT /api/user/profile", requireAuth(userHandler.UpdateProfile))
+	mux.HandleFunc("POST /api/user/avatar", requireAuth(userHandler.UploadAvatar))
+	mux.HandleFunc("GET /api/organizations", requireAuth(userHandler.ListOrganizations))
+	mux.HandleFunc("GET /api/user/tokens", requireAuth(tokenHandler.List))
+	mux.HandleFunc("POST /api/user/tokens", requireAuth(tokenHandler.Create))
+	mux.HandleFunc("DELETE /api/user/tokens/{id}", requireAuth(tokenHandler.Revoke))
+
+	// Notifications
+	mux.HandleFunc("GET /api/notifications", requireAuth(notifHandler.List))
+	mux.HandleFunc("POST /api/notifications/{id}/read", requireAuth(notifHandler.MarkRead))
+	mux.HandleFunc("POST /api/notifications/read-all", requireAuth(notifHandler.MarkAllRead))
 
 	// Apps
-	mux.HandleFunc("POST /api/feedback", AuthMiddleware(feedbackHandler.Create))
-	mux.HandleFunc("GET /api/requirements", AuthMiddleware(reqHandler.Get))
-	mux.HandleFunc("GET /api/requirements/versions", AuthMiddleware(reqHandler.ListVersions))
-	mux.HandleFunc("POST /api/requirements", AuthMiddleware(reqHandler.Save))
-	mux.HandleFunc("GET /api/tasks", AuthMiddleware(taskHandler.List))
-	mux.HandleFunc("GET /api/tasks/{id}", AuthMiddleware(taskHandler.Get))
-	mux.HandleFunc("POST /api/tasks/{id}/select", AuthMiddleware(taskHandler.ToggleSelection))
-	mux.HandleFunc("PUT /api/tasks/{id}/plan", AuthMiddleware(taskHandler.UpdatePlan))
-	mux.HandleFunc("POST /api/tasks/{id}/comments", AuthMiddleware(taskHandler.AddComment))
-	mux.HandleFunc("POST /api/chat", AuthMiddleware(chatHandler.Handle))
+	mux.HandleFunc("POST /api/feedback", requireAuth(feedbackHandler.Create))
+	mux.HandleFunc("GET /api/requirements", requireAuth(canReadReqs(reqHandler.Get)))
+	mux.HandleFunc("GET /api/requirements/versions", requireAuth(canReadReqs(reqHandler.ListVersions)))
+	mux.HandleFunc("POST /api/requirements", requireAuth(canWriteReqs(reqHandler.Save)))
+	mux.HandleFunc("GET /api/tasks", requireAuth(canReadTasks(taskHandler.List)))
+	mux.HandleFunc("GET /api/tasks/{id}", requireAuth(canReadTasks(taskHandler.Get)))
+	mux.HandleFunc("POST /api/tasks/{id}/select", requireAuth(canWriteTasks(taskHandler.ToggleSelection)))
+	mux.HandleFunc("PUT /api/tasks/{id}/plan", requireAuth(canWriteTasks(taskHandler.UpdatePlan)))
+	mux.HandleFunc("POST /api/tasks/{id}/comments", requireAuth(canWriteTasks(taskHandler.AddComment)))
+	mux.HandleFunc("POST /api/chat", requireAuth(chatHandler.Handle))
 
 	// IRAD
-	mux.HandleFunc("GET /api/irad/stats", AuthMiddleware(iradHandler.GetStrategyStats))
-	mux.HandleFunc("GET /api/irad/scos", AuthMiddleware(iradHandler.ListSCOs))
-	mux.HandleFunc("POST /api/irad/scos", AuthMiddleware(iradHandler.CreateSCO))
-	mux.HandleFunc("GET /api/irad/projects", AuthMiddleware(iradHandler.ListProjects))
-	mux.HandleFunc("POST /api/irad/projects", AuthMiddleware(iradHandler.CreateProject))
-	mux.HandleFunc("GET /api/irad/reviews", AuthMiddleware(iradHandler.ListReviews))
-	mux.HandleFunc("POST /api/irad/reviews", AuthMiddleware(iradHandler.CreateReview))
+	mux.HandleFunc("GET /api/irad/stats", requireAuth(canReadIRAD(iradHandler.GetStrategyStats)))
+	mux.HandleFunc("GET /api/irad/scos", requireAuth(canReadIRAD(iradHandler.ListSCOs)))
+	mux.HandleFunc("POST /api/irad/scos", requireAuth(canManageIRAD(iradHandler.CreateSCO)))
+	mux.HandleFunc("GET /api/irad/projects", requireAuth(canReadIRAD(iradHandler.ListProjects)))
+	mux.HandleFunc("POST /api/irad/projects", requireAuth(canProposeIRAD(iradHandler.CreateProject)))
+	mux.HandleFunc("GET /api/irad/reviews", requireAuth(canReadIRAD(iradHandler.ListReviews)))
+	mux.HandleFunc("POST /api/irad/reviews", requireAuth(canReviewIRAD(iradHandler.CreateReview)))
+
+	// Admin
+	mux.HandleFunc("GET /api/admin/scraper/runs", requireAuth(isAdmin(scraperHandler.ListRuns)))
+	mux.HandleFunc("GET /api/admin/match-feedback", requireAuth(isAdmin(matchHandler.FeedbackReport)))
+	mux.HandleFunc("GET /api/admin/prompts", requireAuth(isAdmin(promptHandler.List)))
+	mux.HandleFunc("GET /api/admin/prompts/{name}/versions", requireAuth(isAdmin(promptHandler.ListVersions)))
+	mux.HandleFunc("GET /api/admin/prompts/{name}/versions/{version}", requireAuth(isAdmin(promptHandler.GetVersion)))
+	mux.HandleFunc("POST /api/admin/prompts/{name}", requireAuth(isAdmin(promptHandler.Save)))
 
 	// Serve uploaded files
 	fs := http.FileServer(http.Dir("uploads"))
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"bd_bot/internal/repository"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type ScraperHandler struct {
+	runRepo *repository.ScrapeRunRepository
+}
+
+type ScraperRunsResponse struct {
+	Sources []repository.SourceHealth `json:"sources"`
+	Runs    []repository.ScrapeRun    `json:"runs"`
+}
+
+func (h *ScraperHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	health, err := h.runRepo.SourceHealth(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load scraper health", http.StatusInternalServerError)
+		return
+	}
+	runs, err := h.runRepo.ListWithSources(r.Context(), limit)
+	if err != nil {
+		http.Error(w, "Failed to list scrape runs", http.StatusInternalServerError)
+		return
+	}
+
+	if health == nil {
+		health = []repository.SourceHealth{}
+	}
+	if runs == nil {
+		runs = []repository.ScrapeRun{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ScraperRunsResponse{Sources: health, Runs: runs})
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"bd_bot/internal/repository"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type SearchHandler struct {
+	repo *repository.SolicitationRepository
+}
+
+// Search runs a full-text search. Query parameters: q (required), agency,
+// due (a due-date bucket), limit and offset.
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	q := repository.SearchQuery{
+		Query:  strings.TrimSpace(params.Get("q")),
+		Agency: params.Get("agency"),
+		Due:    params.Get("due"),
+	}
+	if q.Query == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	if q.Due != "" && !repository.ValidDueBucket(q.Due) {
+		http.Error(w, "due must be one of expired, 0-7, 8-14, 15-30, 30+, none", http.StatusBadRequest)
+		return
+	}
+	var err error
+	if v := params.Get("limit"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil || q.Limit < 1 {
+			http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := params.Get("offset"); v != "" {
+		if q.Offset, err = strconv.Atoi(v); err != nil || q.Offset < 0 {
+			http.Error(w, "offset must not be negative", http.StatusBadRequest)
+			return
+		}
+	}
+
+	results, err := h.repo.Search(r.Context(), q)
+	if err != nil {
+		slog.Error("Search failed", "query", q.Query, "error", err)
+		http.Error(w, "Search failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
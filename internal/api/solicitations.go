@@ -1,25 +1,139 @@
 package api
 
 import (
+	"bd_bot/internal/mail"
 	"bd_bot/internal/repository"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type SolicitationHandler struct {
 	repo      *repository.SolicitationRepository
+	docRepo   *repository.DocumentRepository
 	auditRepo *repository.AuditRepository
+	userRepo  *repository.UserRepository
+	outbox    *mail.Outbox
+	publicURL string
 }
 
+// List returns one page of solicitations. See parseSolicitationFilter for the
+// supported query parameters; pass next_cursor back as cursor for the next page.
 func (h *SolicitationHandler) List(w http.ResponseWriter, r *http.Request) {
-	solicitations, err := h.repo.List(r.Context())
+	filter, err := parseSolicitationFilter(r)
 	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.ViewerID == 0 && (filter.ClaimedByViewer || filter.Archived != nil || filter.Sort == repository.SortScore) {
+		http.Error(w, "Sign in to filter by claims, archive or match score", http.StatusUnauthorized)
+		return
+	}
+
+	page, err := h.repo.ListPage(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		slog.Error("Failed to list solicitations", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(solicitations)
+	json.NewEncoder(w).Encode(page)
+}
+
+// parseSolicitationFilter reads agency, due_after, due_before, has_lead,
+// claimed_by_me, archived, q, sort (due|created|score), order (asc|desc),
+// cursor and limit from the query string
+func parseSolicitationFilter(r *http.Request) (repository.SolicitationFilter, error) {
+	q := r.URL.Query()
+	userID, _ := r.Context().Value("user_id").(int)
+	f := repository.SolicitationFilter{
+		Agency:   q.Get("agency"),
+		Text:     q.Get("q"),
+		ViewerID: userID,
+		Sort:     q.Get("sort"),
+		Cursor:   q.Get("cursor"),
+	}
+
+	switch f.Sort {
+	case "", repository.SortDue, repository.SortCreated, repository.SortScore:
+	default:
+		return f, fmt.Errorf("sort must be one of due, created, score")
+	}
+	switch q.Get("order") {
+	case "":
+	case "asc":
+		f.Desc = boolPtr(false)
+	case "desc":
+		f.Desc = boolPtr(true)
+	default:
+		return f, fmt.Errorf("order must be asc or desc")
+	}
+
+	var err error
+	if f.DueAfter, err = parseDateParam(q.Get("due_after")); err != nil {
+		return f, fmt.Errorf("invalid due_after: %w", err)
+	}
+	if f.DueBefore, err = parseDateParam(q.Get("due_before")); err != nil {
+		return f, fmt.Errorf("invalid due_before: %w", err)
+	}
+	if f.HasLead, err = parseBoolParam(q.Get("has_lead")); err != nil {
+		return f, fmt.Errorf("invalid has_lead: %w", err)
+	}
+	if f.Archived, err = parseBoolParam(q.Get("archived")); err != nil {
+		return f, fmt.Errorf("invalid archived: %w", err)
+	}
+	if mine, err := parseBoolParam(q.Get("claimed_by_me")); err != nil {
+		return f, fmt.Errorf("invalid claimed_by_me: %w", err)
+	} else if mine != nil {
+		f.ClaimedByViewer = *mine
+	}
+	if limit := q.Get("limit"); limit != "" {
+		if f.Limit, err = strconv.Atoi(limit); err != nil || f.Limit < 1 {
+			return f, fmt.Errorf("limit must be a positive number")
+		}
+	}
+	return f, nil
+}
+
+// parseDateParam accepts a date (2006-01-02) or an RFC 3339 timestamp
+func parseDateParam(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		if t, err = time.Parse(time.RFC3339, s); err != nil {
+			return nil, errors.New("expected YYYY-MM-DD or RFC 3339")
+		}
+	}
+	return &t, nil
+}
+
+func parseBoolParam(s string) (*bool, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return nil, errors.New("expected true or false")
+	}
+	return &b, nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
 }
 
 func (h *SolicitationHandler) Get(w http.ResponseWriter, r *http.Request) {
@@ -39,6 +153,44 @@ func (h *SolicitationHandler) Get(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(detail)
 }
 
+func (h *SolicitationHandler) History(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	sol, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Solicitation not found", http.StatusNotFound)
+		return
+	}
+
+	revisions, err := h.repo.ListRevisions(r.Context(), sol.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
+
+// Documents lists the attachments fetched for a solicitation and whether their
+// text could be extracted
+func (h *SolicitationHandler) Documents(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	sol, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Solicitation not found", http.StatusNotFound)
+		return
+	}
+
+	docs, err := h.docRepo.ListForSolicitation(r.Context(), sol.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch documents", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(docs)
+}
+
 type ClaimRequest struct {
 	Type string `json:"type"` // 'interested', 'lead', 'none'
 }
@@ -424,6 +576,10 @@ func (h *SolicitationHandler) Claim(w http.ResponseWriter, r *http.Request) {
 
 				h.auditRepo.Log(r.Context(), userID, "share", "solicitation", sol.ID, map[string]string{"recipient": req.Email}, r.RemoteAddr)
 
+				if err := h.sendShareEmail(r.Context(), userID, sol, req); err != nil {
+					slog.Error("Failed to queue share email", "solicitation_id", sol.ID, "recipient", req.Email, "error", err)
+				}
+
 	
 
 		
@@ -518,4 +674,27 @@ func (h *SolicitationHandler) Claim(w http.ResponseWriter, r *http.Request) {
 
 	}
 
-	
\ No newline at end of file
+// sendShareEmail queues the share notification for the recipient
+func (h *SolicitationHandler) sendShareEmail(ctx context.Context, senderID int, sol *repository.SolicitationDetail, req ShareRequest) error {
+	sender, err := h.userRepo.FindByID(ctx, senderID)
+	if err != nil {
+		return err
+	}
+
+	dueDate := "N/A"
+	if !sol.DueDate.IsZero() {
+		dueDate = sol.DueDate.Format("Jan 2, 2006")
+	}
+
+	data := map[string]string{
+		"SenderName":  sender.FullName,
+		"SenderEmail": sender.Email,
+		"Message":     req.Message,
+		"Title":       sol.Title,
+		"Agency":      sol.Agency,
+		"DueDate":     dueDate,
+		"Link":        strings.TrimRight(h.publicURL, "/") + "/solicitation/" + url.PathEscape(sol.SourceID),
+		"SourceURL":   sol.URL,
+	}
+	return h.outbox.EnqueueTemplate(ctx, "share", req.Email, data)
+}
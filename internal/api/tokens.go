@@ -0,0 +1,132 @@
+package api
+
+import (
+	"bd_bot/internal/repository"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TokenHandler struct {
+	repo      *repository.APITokenRepository
+	auditRepo *repository.AuditRepository
+}
+
+type CreateTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+type CreateTokenResponse struct {
+	repository.APIToken
+	// Token is the secret. It is shown once and cannot be recovered.
+	Token string `json:"token"`
+}
+
+// sessionOnly rejects requests authenticated with an API token, so a leaked
+// token cannot be used to mint or revoke other tokens
+func sessionOnly(w http.ResponseWriter, r *http.Request) bool {
+	if _, ok := r.Context().Value("token_id").(int); ok {
+		http.Error(w, "API tokens cannot manage tokens; sign in to the web app", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// List returns the current user's active tokens
+func (h *TokenHandler) List(w http.ResponseWriter, r *http.Request) {
+	if !sessionOnly(w, r) {
+		return
+	}
+	userID := r.Context().Value("user_id").(int)
+
+	tokens, err := h.repo.List(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch tokens", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// Create issues a new token and returns its secret once
+func (h *TokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if !sessionOnly(w, r) {
+		return
+	}
+	userID := r.Context().Value("user_id").(int)
+
+	var req CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Scopes) == 0 {
+		req.Scopes = []string{repository.ScopeRead}
+	}
+	for _, s := range req.Scopes {
+		if !repository.ValidTokenScope(s) {
+			http.Error(w, "Unknown scope: "+s, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.ExpiresInDays < 0 {
+		http.Error(w, "expires_in_days must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	secret, token, err := h.repo.Create(r.Context(), userID, req.Name, req.Scopes, expiresAt)
+	if err != nil {
+		slog.Error("Failed to create API token", "error", err)
+		http.Error(w, "Failed to create token", http.StatusInternalServerError)
+		return
+	}
+	h.auditRepo.Log(r.Context(), userID, "create_token", "api_token", token.ID, map[string]interface{}{"name": token.Name, "scopes": token.Scopes}, clientIP(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateTokenResponse{APIToken: *token, Token: secret})
+}
+
+// Revoke disables one of the current user's tokens
+func (h *TokenHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	if !sessionOnly(w, r) {
+		return
+	}
+	userID := r.Context().Value("user_id").(int)
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Revoke(r.Context(), id, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Token not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to revoke token", http.StatusInternalServerError)
+		return
+	}
+	h.auditRepo.Log(r.Context(), userID, "revoke_token", "api_token", id, nil, clientIP(r))
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -82,6 +82,8 @@ type UpdateProfileRequest struct {
 	AvatarURL    string `json:"avatar_url"`
 	Organization string `json:"organization_name"`
 	MatchThreshold int  `json:"match_threshold"`
+	MatchTopK      *int `json:"match_top_k"`
+	DigestFrequency string `json:"digest_frequency"`
 }
 
 func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
@@ -108,7 +110,39 @@ func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		// Frontend should send current value.
 	}
 
-	if err := h.repo.UpdateProfile(r.Context(), userID, req.Email, req.FullName, req.AvatarURL, req.Organization, req.MatchThreshold); err != nil {
+	if req.MatchTopK != nil && (*req.MatchTopK < 0 || *req.MatchTopK > 10000) {
+		http.Error(w, "match_top_k must be between 0 and 10000", http.StatusBadRequest)
+		return
+	}
+
+	// Keep the current preferences for clients that don't send them
+	current, err := h.repo.FindByID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to update profile", http.StatusInternalServerError)
+		return
+	}
+	matchTopK := current.MatchTopK
+	if req.MatchTopK != nil {
+		matchTopK = *req.MatchTopK
+	}
+
+	// Membership opens the organization's narrative and shared inbox, so it
+	// is set by an admin; clients may echo the current value back
+	if req.Organization != "" && req.Organization != current.Organization {
+		http.Error(w, "Your organization is assigned by an administrator", http.StatusForbidden)
+		return
+	}
+
+	switch req.DigestFrequency {
+	case "daily", "weekly", "off":
+	case "":
+		req.DigestFrequency = current.DigestFrequency
+	default:
+		http.Error(w, "digest_frequency must be daily, weekly or off", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.UpdateProfile(r.Context(), userID, req.Email, req.FullName, req.AvatarURL, req.MatchThreshold, matchTopK, req.DigestFrequency); err != nil {
 		http.Error(w, "Failed to update profile", http.StatusInternalServerError)
 		return
 	}
@@ -1,13 +1,12 @@
 package cli
 
 import (
+	"bd_bot/internal/ai"
 	"bd_bot/internal/config"
-	"bytes"
+	"context"
 	"database/sql"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"log/slog"
 	"os"
 	"time"
 
@@ -51,9 +50,16 @@ var initCmd = &cobra.Command{
 						Title("Database URL").
 						Description("PostgreSQL connection string").
 						Value(&cfg.DatabaseURL),
+					huh.NewSelect[string]().
+							Title("LLM Provider").
+							Options(
+								huh.NewOption("OpenAI-compatible (/chat/completions)", ai.ProviderOpenAI),
+								huh.NewOption("Ollama (native /api/chat)", ai.ProviderOllama),
+							).
+							Value(&cfg.LLMProvider),
 					huh.NewInput().
 							Title("LLM API URL").
-							Description("OpenAI-compatible API endpoint").
+							Description("API endpoint, e.g. http://127.0.0.1:11434/v1").
 							Value(&cfg.LLMURL),
 					huh.NewInput().
 							Title("LLM API Key").
@@ -63,6 +69,26 @@ var initCmd = &cobra.Command{
 							Title("LLM Model").
 							Description("Model name (e.g., gemma3:4b)").
 							Value(&cfg.LLMModel),
+					huh.NewInput().
+							Title("Embedding Model").
+							Description("Used to pre-filter solicitations before LLM scoring (empty to disable)").
+							Value(&cfg.EmbeddingModel),
+					huh.NewInput().
+							Title("Scrape Schedule").
+							Description("Cron expression for the built-in scraper (empty to disable)").
+							Value(&cfg.ScrapeSchedule),
+					huh.NewInput().
+							Title("SMTP Host").
+							Description("Outbound mail server (empty to queue mail without sending)").
+							Value(&cfg.SMTPHost),
+					huh.NewInput().
+							Title("Mail From").
+							Description("Sender address for outgoing email").
+							Value(&cfg.MailFrom),
+					huh.NewInput().
+							Title("Public URL").
+							Description("Address users reach the portal at, used for links in email").
+							Value(&cfg.PublicURL),
 					huh.NewInput().
 							Title("Log Path").
 							Description("Path to log file").
@@ -117,8 +143,11 @@ var testCmd = &cobra.Command{
 		// Test Database
 		testDatabase(cfg.DatabaseURL)
 
-		// Test LLM
-		testLLM(cfg.LLMURL, cfg.LLMKey, cfg.LLMModel, testPrompt)
+		// Test the LLM behind each feature, once if they share one
+		testLLM("matching", cfg.MatchingLLM(), testPrompt)
+		if cfg.ChatLLM() != cfg.MatchingLLM() {
+			testLLM("chat", cfg.ChatLLM(), testPrompt)
+		}
 	},
 }
 
@@ -146,75 +175,44 @@ func testDatabase(url string) {
 	fmt.Printf("✅ Database: Connected\n   Info: %s\n", version)
 }
 
-type ChatRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
-	Stream   bool      `json:"stream"`
-}
-
-type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-type ChatResponse struct {
-	Choices []struct {
-		Message Message `json:"message"`
-	} `json:"choices"`
-}
-
-func testLLM(url, key, model, prompt string) {
-	client := http.Client{
-		Timeout: 30 * time.Second, // Increased timeout for actual generation
-	}
-
-	fmt.Printf("🤖 LLM: Sending prompt to model '%s'...\n", model)
-
-	reqBody := ChatRequest{
-		Model: model,
-		Messages: []Message{
-			{Role: "user", Content: prompt},
-		},
-		Stream: false,
-	}
-
-	jsonData, err := json.Marshal(reqBody)
+// newLLMProvider creates the provider for one feature, exiting on a bad
+// llm_provider setting
+func newLLMProvider(llm config.LLMConfig) ai.LLMProvider {
+	provider, err := ai.NewProvider(llm.Provider, llm.URL, llm.Key, llm.Model)
 	if err != nil {
-		fmt.Printf("❌ LLM: Error marshalling request: %v\n", err)
-		return
+		slog.Error("Invalid LLM configuration", "error", err)
+		os.Exit(1)
 	}
+	return provider
+}
 
-	req, err := http.NewRequest("POST", url+"/chat/completions", bytes.NewBuffer(jsonData))
+func testLLM(feature string, llm config.LLMConfig, prompt string) {
+	provider, err := ai.NewProvider(llm.Provider, llm.URL, llm.Key, llm.Model)
 	if err != nil {
-		fmt.Printf("❌ LLM: Request creation failed: %v\n", err)
+		fmt.Printf("❌ LLM (%s): %v\n", feature, err)
 		return
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	if key != "" && key != "sk-..." {
-		req.Header.Set("Authorization", "Bearer "+key)
-	}
+	fmt.Printf("🤖 LLM (%s): Sending prompt to model '%s' via %s...\n", feature, llm.Model, providerName(llm.Provider))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second) // Long enough for actual generation
+	defer cancel()
 
 	start := time.Now()
-	resp, err := client.Do(req)
+	reply, err := provider.Complete(ctx, ai.ChatRequest{
+		Messages: []ai.ChatMessage{{Role: "user", Content: prompt}},
+	})
 	if err != nil {
-		fmt.Printf("❌ LLM: Connection failed: %v\n", err)
+		fmt.Printf("❌ LLM (%s): %v\n", feature, err)
 		return
 	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
+	fmt.Printf("✅ LLM (%s): Connected (Time: %v)\n", feature, time.Since(start).Round(time.Millisecond))
+	fmt.Printf("   Response: %s\n", reply)
+}
 
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		var chatResp ChatResponse
-		if err := json.Unmarshal(body, &chatResp); err == nil && len(chatResp.Choices) > 0 {
-			fmt.Printf("✅ LLM: Connected (Time: %v)\n", time.Since(start).Round(time.Millisecond))
-			fmt.Printf("   Response: %s\n", chatResp.Choices[0].Message.Content)
-		} else {
-			fmt.Printf("✅ LLM: Connected (Status: %s)\n   Raw Response: %s\n", resp.Status, string(body))
-		}
-	} else {
-		fmt.Printf("❌ LLM: Returned status: %s\n", resp.Status)
-		fmt.Printf("   Body: %s\n", string(body))
+func providerName(kind string) string {
+	if kind == "" {
+		return ai.ProviderOpenAI
 	}
-}
\ No newline at end of file
+	return kind
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bd_bot/internal/config"
+	"bd_bot/internal/db"
+	"bd_bot/internal/documents"
+	"bd_bot/internal/repository"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	docsCmd.AddCommand(docsFetchCmd)
+	docsCmd.AddCommand(docsListCmd)
+	docsCmd.AddCommand(docsExtractCmd)
+
+	docsFetchCmd.Flags().String("sol", "", "Only fetch documents of this solicitation (source ID)")
+	docsFetchCmd.Flags().IntP("limit", "n", 200, "Maximum number of documents to fetch")
+
+	docsListCmd.Flags().String("sol", "", "Solicitation source ID")
+	docsListCmd.Flags().Bool("json", false, "Output in JSON format")
+	docsListCmd.MarkFlagRequired("sol")
+
+	rootCmd.AddCommand(docsCmd)
+}
+
+func newDocumentFetcher(cfg config.Config, database *sql.DB) *documents.Fetcher {
+	return documents.NewFetcher(repository.NewDocumentRepository(database), documents.NewStore(cfg.DocumentsDir))
+}
+
+var docsCmd = &cobra.Command{
+	Use:     "docs",
+	Short:   "Download and extract solicitation attachments",
+	GroupID: "intel",
+}
+
+var docsFetchCmd = &cobra.Command{
+	Use:   "fetch",
+	Short: "Download attachments that have not been fetched yet",
+	Run: func(cmd *cobra.Command, args []string) {
+		sourceID, _ := cmd.Flags().GetString("sol")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		cfg, _ := config.LoadConfig()
+		database, err := db.Connect(cfg.DatabaseURL)
+		if err != nil {
+			slog.Error("DB connect failed", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+
+		solID := 0
+		if sourceID != "" {
+			sol, err := repository.NewSolicitationRepository(database).GetByID(ctx, sourceID)
+			if err != nil {
+				slog.Error("Solicitation not found", "source_id", sourceID)
+				os.Exit(1)
+			}
+			solID = sol.ID
+		}
+
+		stats, err := newDocumentFetcher(cfg, database).FetchPending(ctx, solID, limit)
+		if err != nil {
+			slog.Error("Document fetch failed", "error", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Fetched %d document(s), %d failed\n", stats.Fetched, stats.Failed)
+	},
+}
+
+var docsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the fetched documents of a solicitation",
+	Run: func(cmd *cobra.Command, args []string) {
+		sourceID, _ := cmd.Flags().GetString("sol")
+
+		cfg, _ := config.LoadConfig()
+		database, err := db.Connect(cfg.DatabaseURL)
+		if err != nil {
+			slog.Error("DB connect failed", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		ctx := context.Background()
+		sol, err := repository.NewSolicitationRepository(database).GetByID(ctx, sourceID)
+		if err != nil {
+			slog.Error("Solicitation not found", "source_id", sourceID)
+			os.Exit(1)
+		}
+		docs, err := repository.NewDocumentRepository(database).ListForSolicitation(ctx, sol.ID)
+		if err != nil {
+			slog.Error("Failed to list documents", "error", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(docs); err != nil {
+				slog.Error("Failed to encode JSON", "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "ID\tSTATUS\tFORMAT\tSIZE\tTEXT\tTITLE\tERROR")
+		for _, d := range docs {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d KB\t%d chars\t%s\t%s\n", d.ID, d.Status, d.Format, d.SizeBytes/1024, d.TextLength, d.Title, d.Error)
+		}
+		w.Flush()
+	},
+}
+
+var docsExtractCmd = &cobra.Command{
+	Use:   "extract <file>",
+	Short: "Print the text extracted from a local file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			slog.Error("Failed to read file", "error", err)
+			os.Exit(1)
+		}
+		format := documents.Detect(data, "", filepath.Base(args[0]))
+		text, err := documents.Extract(data, format)
+		if err != nil {
+			slog.Error("Extraction failed", "format", format, "error", err)
+			os.Exit(1)
+		}
+		fmt.Println(text)
+	},
+}
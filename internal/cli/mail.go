@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"bd_bot/internal/config"
+	"bd_bot/internal/db"
+	"bd_bot/internal/mail"
+	"bd_bot/internal/notify"
+	"bd_bot/internal/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	mailTestCmd.Flags().String("to", "", "Recipient address")
+	mailTestCmd.MarkFlagRequired("to")
+
+	mailOutboxCmd.Flags().String("status", "", "Filter by status (pending, sending, sent, failed)")
+	mailOutboxCmd.Flags().IntP("limit", "n", 50, "Number of messages to show")
+	mailOutboxCmd.Flags().Bool("json", false, "Output in JSON format")
+
+	mailCmd.AddCommand(mailTestCmd)
+	mailCmd.AddCommand(mailOutboxCmd)
+	mailCmd.AddCommand(mailFlushCmd)
+	mailCmd.AddCommand(mailDigestCmd)
+	rootCmd.AddCommand(mailCmd)
+}
+
+var mailCmd = &cobra.Command{
+	Use:     "mail",
+	Short:   "Inspect and deliver outgoing email",
+	GroupID: "core",
+}
+
+var mailTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Send a test email directly through the configured SMTP server",
+	Run: func(cmd *cobra.Command, args []string) {
+		to, _ := cmd.Flags().GetString("to")
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			slog.Error("Error loading config", "error", err)
+			os.Exit(1)
+		}
+
+		sender := mail.NewSender(cfg)
+		if sender == nil {
+			fmt.Println("❌ SMTP is not configured. Set smtp_host in your config.")
+			os.Exit(1)
+		}
+
+		msg := mail.Message{
+			To:      to,
+			Subject: "[JOSHUA] Test email",
+			Text:    fmt.Sprintf("This is a test email from JOSHUA sent at %s.\n", time.Now().Format(time.RFC1123)),
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := sender.Deliver(ctx, msg); err != nil {
+			fmt.Printf("❌ Delivery failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Test email sent to %s via %s:%d\n", to, cfg.SMTPHost, cfg.SMTPPort)
+	},
+}
+
+var mailOutboxCmd = &cobra.Command{
+	Use:   "outbox",
+	Short: "List queued and delivered email",
+	Run: func(cmd *cobra.Command, args []string) {
+		status, _ := cmd.Flags().GetString("status")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		cfg, _ := config.LoadConfig()
+		database, err := db.Connect(cfg.DatabaseURL)
+		if err != nil {
+			slog.Error("DB connect failed", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		messages, err := repository.NewMailRepository(database).List(context.Background(), status, limit)
+		if err != nil {
+			slog.Error("Failed to list outbox", "error", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(messages)
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tCreated\tTo\tSubject\tStatus\tAttempts\tLast Error")
+		fmt.Fprintln(w, "--\t-------\t--\t-------\t------\t--------\t----------")
+		for _, m := range messages {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\t%s\n", m.ID, m.CreatedAt.Format("2006-01-02 15:04"), m.To, m.Subject, m.Status, m.Attempts, m.LastError)
+		}
+		w.Flush()
+	},
+}
+
+var mailFlushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Deliver every queued email that is due now",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			slog.Error("Error loading config", "error", err)
+			os.Exit(1)
+		}
+
+		sender := mail.NewSender(cfg)
+		if sender == nil {
+			fmt.Println("❌ SMTP is not configured. Set smtp_host in your config.")
+			os.Exit(1)
+		}
+
+		database, err := db.Connect(cfg.DatabaseURL)
+		if err != nil {
+			slog.Error("DB connect failed", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		outbox := mail.NewOutbox(repository.NewMailRepository(database), sender)
+		sent, err := outbox.Flush(context.Background())
+		if err != nil {
+			fmt.Printf("❌ Flush failed after sending %d: %v\n", sent, err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Sent %d queued email(s)\n", sent)
+	},
+}
+
+var mailDigestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Queue match digests for every user whose daily or weekly digest is due",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			slog.Error("Error loading config", "error", err)
+			os.Exit(1)
+		}
+
+		database, err := db.Connect(cfg.DatabaseURL)
+		if err != nil {
+			slog.Error("DB connect failed", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		outbox := mail.NewOutbox(repository.NewMailRepository(database), mail.NewSender(cfg))
+		digester := notify.NewDigester(
+			repository.NewUserRepository(database),
+			repository.NewMatchRepository(database),
+			repository.NewSolicitationRepository(database),
+			outbox,
+			cfg.PublicURL,
+		)
+
+		ctx := context.Background()
+		count, err := digester.SendDue(ctx, time.Now())
+		if err != nil {
+			fmt.Printf("❌ Digest run failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Queued %d digest(s)\n", count)
+
+		if _, err := outbox.Flush(ctx); err != nil {
+			slog.Error("Failed to deliver queued mail", "error", err)
+		}
+	},
+}
@@ -4,11 +4,21 @@ import (
 	"bd_bot/internal/ai"
 	"bd_bot/internal/config"
 	"bd_bot/internal/db"
+	"bd_bot/internal/matching"
+	"bd_bot/internal/prompt"
 	"bd_bot/internal/repository"
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -16,14 +26,95 @@ import (
 var (
 	matchUserEmail string
 	matchUserID    int
+	matchTopK      int
+	matchAllUsers  bool
+	matchOrg       string
+	matchAllOrgs   bool
+	matchSince     string
+	matchFull      bool
+	matchWorkers   int
+	matchRPM       int
 )
 
 func init() {
 	rootCmd.AddCommand(matchCmd)
 	matchCmd.AddCommand(matchClearCmd)
+	matchCmd.AddCommand(matchStatsCmd)
+	matchStatsCmd.Flags().Bool("json", false, "Output in JSON format")
+	matchCmd.AddCommand(matchFeedbackCmd)
+	matchFeedbackCmd.Flags().Int("min-score", 75, "Score from which a thumbs-down counts as a miss")
+	matchFeedbackCmd.Flags().Int("limit", 20, "Recent misses to list")
+	matchFeedbackCmd.Flags().Bool("json", false, "Output in JSON format")
 
 	matchCmd.PersistentFlags().StringVarP(&matchUserEmail, "email", "e", "", "User Email")
 	matchCmd.PersistentFlags().IntVarP(&matchUserID, "id", "i", 0, "User ID")
+	matchCmd.Flags().IntVar(&matchTopK, "top-k", -1, "Score only the K most similar solicitations (0 scores all; default: the user's setting)")
+	matchCmd.Flags().BoolVar(&matchAllUsers, "all-users", false, "Match every user with a narrative")
+	matchCmd.Flags().StringVar(&matchOrg, "org", "", "Match an organization's narrative into its shared inbox")
+	matchCmd.Flags().BoolVar(&matchAllOrgs, "all-orgs", false, "Match every organization with a narrative")
+	matchCmd.Flags().StringVar(&matchSince, "since", "", "Only score solicitations changed since this time (RFC 3339, YYYY-MM-DD or a duration like 24h)")
+	matchCmd.Flags().BoolVar(&matchFull, "full", false, "Re-score every pair, even if neither side changed")
+	matchCmd.Flags().IntVarP(&matchWorkers, "concurrency", "c", 0, "Concurrent LLM requests (default: llm_concurrency)")
+	matchCmd.Flags().IntVar(&matchRPM, "rpm", -1, "Maximum LLM requests per minute, 0 for no limit (default: llm_requests_per_minute)")
+}
+
+// parseSince accepts an RFC 3339 timestamp, a date, or a duration ago
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use RFC 3339, YYYY-MM-DD or a duration like 24h", value)
+}
+
+// newMatchRunner wires the LLM matcher, using the prompts from the store, and,
+// when an embedding model is configured, the semantic pre-filter
+func newMatchRunner(cfg config.Config, database *sql.DB, prompts *prompt.Store) *matching.Runner {
+	provider := newLLMProvider(cfg.MatchingLLM())
+	var prefilter *matching.Prefilter
+	if cfg.EmbeddingModel != "" {
+		embedder := ai.NewEmbedder(provider, cfg.EmbeddingModel)
+		prefilter = matching.NewPrefilter(embedder, repository.NewEmbeddingRepository(database))
+	}
+	matcher := ai.NewMatcher(provider, prompts)
+	limits := matching.Limits{Concurrency: cfg.LLMConcurrency, RequestsPerMinute: cfg.LLMRequestsPerMinute}
+	return matching.NewRunner(repository.NewUserRepository(database), repository.NewOrganizationRepository(database), repository.NewSolicitationRepository(database), repository.NewMatchRepository(database), matcher, prefilter, limits)
+}
+
+// printProgress redraws a one-line progress display on stderr, or prints a
+// line every 10% when stderr is not a terminal
+func printProgress() func(matching.Progress) {
+	interactive := false
+	if fi, err := os.Stderr.Stat(); err == nil {
+		interactive = fi.Mode()&os.ModeCharDevice != 0
+	}
+	lastDecile := -1
+	return func(p matching.Progress) {
+		if p.Total == 0 {
+			return
+		}
+		line := fmt.Sprintf("%s: %d/%d scored, %d failed, %.1f/min, ETA %s",
+			p.User, p.Done, p.Total, p.Failed, p.PerMinute(), p.ETA().Round(time.Second))
+		if interactive {
+			fmt.Fprintf(os.Stderr, "\r\033[K%s", line)
+			if p.Done == p.Total {
+				fmt.Fprintln(os.Stderr)
+			}
+			return
+		}
+		if decile := p.Done * 10 / p.Total; decile != lastDecile || p.Done == p.Total {
+			lastDecile = decile
+			fmt.Fprintln(os.Stderr, line)
+		}
+	}
 }
 
 func resolveUser(ctx context.Context, userRepo *repository.UserRepository) (*repository.User, error) {
@@ -33,8 +124,7 @@ func resolveUser(ctx context.Context, userRepo *repository.UserRepository) (*rep
 	if matchUserEmail != "" {
 		return userRepo.FindByEmail(ctx, matchUserEmail)
 	}
-	// Default to ID 1 for backward compatibility if no flags provided
-	return userRepo.FindByID(ctx, 1)
+	return nil, errors.New("specify the user with --email or --id")
 }
 
 var matchClearCmd = &cobra.Command{
@@ -72,10 +162,9 @@ var matchClearCmd = &cobra.Command{
 	},
 }
 
-var matchCmd = &cobra.Command{
-	Use:     "match",
-	Short:   "Run AI matching for a user",
-	GroupID: "intel",
+var matchStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the distribution of a user's match scores",
 	Run: func(cmd *cobra.Command, args []string) {
 		cfg, err := config.LoadConfig()
 		if err != nil {
@@ -90,49 +179,229 @@ var matchCmd = &cobra.Command{
 		}
 		defer database.Close()
 
-		userRepo := repository.NewUserRepository(database)
-		solRepo := repository.NewSolicitationRepository(database)
-		matchRepo := repository.NewMatchRepository(database)
-		matcher := ai.NewMatcher(cfg.LLMURL, cfg.LLMKey, cfg.LLMModel)
-
-		user, err := resolveUser(context.Background(), userRepo)
+		ctx := context.Background()
+		user, err := resolveUser(ctx, repository.NewUserRepository(database))
 		if err != nil {
 			slog.Error("User not found", "error", err)
 			os.Exit(1)
 		}
 
-		if user.Narrative == "" {
-			slog.Warn("User has no narrative defined", "id", user.ID)
+		dist, err := repository.NewMatchRepository(database).GetScoreDistribution(ctx, user.ID)
+		if err != nil {
+			slog.Error("Failed to load scores", "error", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(dist); err != nil {
+				slog.Error("Failed to encode JSON", "error", err)
+				os.Exit(1)
+			}
 			return
 		}
 
-		sols, err := solRepo.List(context.Background())
+		fmt.Printf("%s: %d scored, %d at or above threshold %d\n\n", user.Email, dist.Total, dist.AboveThreshold, dist.Threshold)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SCORE\tCOUNT\t")
+		for _, b := range dist.Buckets {
+			bar := ""
+			if dist.Total > 0 {
+				bar = strings.Repeat("█", b.Count*40/dist.Total)
+			}
+			fmt.Fprintf(w, "%d-%d\t%d\t%s\n", b.Min, b.Max, b.Count, bar)
+		}
+		w.Flush()
+	},
+}
+
+var matchFeedbackCmd = &cobra.Command{
+	Use:   "feedback",
+	Short: "Report how often high-scoring matches get a thumbs-down",
+	Long: `Summarizes users' thumbs-up/down ratings by the score each match had when
+it was rated, and lists recent thumbs-down matches scored at least --min-score.
+Covers every user unless -e/-i is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		minScore, _ := cmd.Flags().GetInt("min-score")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		cfg, err := config.LoadConfig()
 		if err != nil {
-			slog.Error("Failed to list solicitations", "error", err)
-			return
+			slog.Error("Error loading config", "error", err)
+			os.Exit(1)
 		}
 
-		slog.Info("Starting Matching", "user", user.Email, "solicitations", len(sols))
+		database, err := db.Connect(cfg.DatabaseURL)
+		if err != nil {
+			slog.Error("DB connect failed", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
 
-		for _, sol := range sols {
-			result, err := matcher.Match(user.Narrative, sol)
+		ctx := context.Background()
+		userID := 0
+		scope := "all users"
+		if matchUserEmail != "" || matchUserID != 0 {
+			user, err := resolveUser(ctx, repository.NewUserRepository(database))
 			if err != nil {
-				slog.Error("Match failed", "sol_id", sol.ID, "error", err)
-				continue
+				slog.Error("User not found", "error", err)
+				os.Exit(1)
+			}
+			userID = user.ID
+			scope = user.Email
+		}
+
+		report, err := repository.NewMatchRepository(database).GetFeedbackReport(ctx, userID, minScore, limit)
+		if err != nil {
+			slog.Error("Failed to load feedback", "error", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(report); err != nil {
+				slog.Error("Failed to encode JSON", "error", err)
+				os.Exit(1)
 			}
+			return
+		}
 
-			slog.Info("Match Result", "sol", sol.Title, "score", result.Score)
+		fmt.Printf("%s: %d 👍, %d 👎\n", scope, report.Up, report.Down)
+		fmt.Printf("Scored %d or more: %d rated, %d thumbs-down (%.0f%%)\n\n", minScore, report.HighScored, report.HighScoredDown, report.HighScoredRate*100)
 
-			if result.Score < user.MatchThreshold {
-				slog.Info("Skipping match below threshold", "score", result.Score, "threshold", user.MatchThreshold)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SCORE\tUP\tDOWN\tDOWN %")
+		for _, b := range report.Buckets {
+			if b.Up+b.Down == 0 {
 				continue
 			}
+			fmt.Fprintf(w, "%d-%d\t%d\t%d\t%.0f%%\n", b.Min, b.Max, b.Up, b.Down, float64(b.Down)*100/float64(b.Up+b.Down))
+		}
+		w.Flush()
+
+		if len(report.Misses) > 0 {
+			fmt.Printf("\nRecent thumbs-down scored %d or more:\n", minScore)
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "RATED\tUSER\tSCORE\tSOURCE ID\tTITLE")
+			for _, m := range report.Misses {
+				title := m.Title
+				if r := []rune(title); len(r) > 60 {
+					title = string(r[:57]) + "..."
+				}
+				fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", m.RatedAt.Format("2006-01-02"), m.UserEmail, m.Score, m.SourceID, title)
+			}
+			w.Flush()
+		}
+	},
+}
+
+var matchCmd = &cobra.Command{
+	Use:     "match",
+	Short:   "Run AI matching for a user or organization",
+	GroupID: "intel",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			slog.Error("Error loading config", "error", err)
+			os.Exit(1)
+		}
+
+		database, err := db.Connect(cfg.DatabaseURL)
+		if err != nil {
+			slog.Error("DB connect failed", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		since, err := parseSince(matchSince, time.Now())
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if matchFull && !since.IsZero() {
+			fmt.Println("❌ --full and --since cannot be combined")
+			os.Exit(1)
+		}
+		opts := matching.Options{Since: since, Full: matchFull, Progress: printProgress()}
+		if matchWorkers > 0 {
+			cfg.LLMConcurrency = matchWorkers
+		}
+		if matchRPM >= 0 {
+			cfg.LLMRequestsPerMinute = matchRPM
+		}
+		runner := newMatchRunner(cfg, database, prompt.NewStore(repository.NewPromptRepository(database)))
 
-			if err := matchRepo.Upsert(context.Background(), user.ID, sol.ID, result.Score, result.Explanation); err != nil {
-				slog.Error("Failed to save match", "error", err)
+		// Ctrl-C stops handing out work and waits for requests in flight
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if matchAllUsers || matchAllOrgs {
+			if matchTopK >= 0 {
+				fmt.Println("❌ --top-k applies to a single user or organization; set match_top_k on each instead")
+				os.Exit(1)
+			}
+			if matchAllUsers {
+				stats, err := runner.RunForAllUsers(ctx, opts)
+				if err != nil {
+					slog.Error("Matching failed", "error", err)
+					os.Exit(1)
+				}
+				slog.Info("Matching finished", "scored", stats.Scored, "saved", stats.Saved, "above_threshold", stats.AboveThreshold, "failed", stats.Failed, "filtered", stats.Filtered, "unchanged", stats.Unchanged)
+				fmt.Printf("✅ Matching complete for all users: %d scored (%d above threshold), %d unchanged.\n", stats.Scored, stats.AboveThreshold, stats.Unchanged)
 			}
+			if matchAllOrgs {
+				stats, err := runner.RunForAllOrganizations(ctx, opts)
+				if err != nil {
+					slog.Error("Organization matching failed", "error", err)
+					os.Exit(1)
+				}
+				slog.Info("Organization matching finished", "scored", stats.Scored, "saved", stats.Saved, "above_threshold", stats.AboveThreshold, "failed", stats.Failed, "filtered", stats.Filtered, "unchanged", stats.Unchanged)
+				fmt.Printf("✅ Matching complete for all organizations: %d scored (%d above threshold), %d unchanged.\n", stats.Scored, stats.AboveThreshold, stats.Unchanged)
+			}
+			return
 		}
-		
-		fmt.Println("✅ Matching complete.")
+
+		if matchOrg != "" {
+			org, err := repository.NewOrganizationRepository(database).FindByName(ctx, matchOrg)
+			if err != nil {
+				slog.Error("Organization not found", "name", matchOrg, "error", err)
+				os.Exit(1)
+			}
+			if matchTopK >= 0 {
+				org.MatchTopK = matchTopK
+			}
+
+			stats, err := runner.RunForOrganization(ctx, org, opts)
+			if err != nil {
+				slog.Error("Matching failed", "error", err)
+				return
+			}
+
+			slog.Info("Matching finished", "organization", org.Name, "scored", stats.Scored, "saved", stats.Saved, "above_threshold", stats.AboveThreshold, "failed", stats.Failed, "filtered", stats.Filtered, "unchanged", stats.Unchanged)
+			fmt.Printf("✅ Matching complete for %s: %d scored (%d above threshold), %d unchanged.\n", org.Name, stats.Scored, stats.AboveThreshold, stats.Unchanged)
+			return
+		}
+
+		userRepo := repository.NewUserRepository(database)
+
+		user, err := resolveUser(ctx, userRepo)
+		if err != nil {
+			slog.Error("User not found", "error", err)
+			os.Exit(1)
+		}
+		if matchTopK >= 0 {
+			user.MatchTopK = matchTopK
+		}
+
+		stats, err := runner.RunForUser(ctx, user, opts)
+		if err != nil {
+			slog.Error("Matching failed", "error", err)
+			return
+		}
+
+		slog.Info("Matching finished", "user", user.Email, "scored", stats.Scored, "saved", stats.Saved, "above_threshold", stats.AboveThreshold, "failed", stats.Failed, "filtered", stats.Filtered, "unchanged", stats.Unchanged)
+		fmt.Printf("✅ Matching complete: %d scored (%d above threshold), %d unchanged.\n", stats.Scored, stats.AboveThreshold, stats.Unchanged)
 	},
 }
\ No newline at end of file
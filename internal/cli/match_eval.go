@@ -0,0 +1,320 @@
+package cli
+
+import (
+	"bd_bot/internal/ai"
+	"bd_bot/internal/config"
+	"bd_bot/internal/db"
+	"bd_bot/internal/matching"
+	"bd_bot/internal/prompt"
+	"bd_bot/internal/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	matchCmd.AddCommand(matchEvalCmd)
+
+	matchEvalCmd.Flags().String("set", "", "Golden set YAML file")
+	matchEvalCmd.Flags().Bool("from-history", false, "Build the set from users' lead (relevant) and archive (irrelevant) decisions; -e/-i restricts it to one user")
+	matchEvalCmd.Flags().Int("limit", 200, "Most recent decisions to use with --from-history (0 for all)")
+	matchEvalCmd.Flags().Bool("with-feedback", false, "With --from-history, show the matcher each user's match feedback as examples, as matching does")
+	matchEvalCmd.Flags().String("model", "", "Model to evaluate (default: the matching model)")
+	matchEvalCmd.Flags().String("prompt", "", "Match prompt version, or a template file (default: the version in use)")
+	matchEvalCmd.Flags().String("vs-model", "", "Model of a second configuration to compare against")
+	matchEvalCmd.Flags().String("vs-prompt", "", "Match prompt version or template file of a second configuration to compare against")
+	matchEvalCmd.Flags().Int("threshold", 75, "Score at which a solicitation counts as relevant")
+	matchEvalCmd.Flags().IntP("concurrency", "c", 0, "Concurrent LLM requests (default: llm_concurrency)")
+	matchEvalCmd.Flags().Int("rpm", -1, "Maximum LLM requests per minute, 0 for no limit (default: llm_requests_per_minute)")
+	matchEvalCmd.Flags().Bool("all", false, "List every case in the comparison, not only those that changed")
+	matchEvalCmd.Flags().Bool("json", false, "Output in JSON format")
+}
+
+// evalConfig is one model and prompt combination under evaluation
+type evalConfig struct {
+	llm    config.LLMConfig
+	prompt *repository.PromptTemplate
+	// promptLabel names the prompt in the report
+	promptLabel string
+}
+
+// evalRun is the outcome of evaluating one configuration
+type evalRun struct {
+	Model   string                `json:"model"`
+	Prompt  string                `json:"prompt"`
+	Report  matching.EvalReport   `json:"report"`
+	Results []matching.EvalResult `json:"results"`
+}
+
+var matchEvalCmd = &cobra.Command{
+	Use:   "eval",
+	Short: "Measure matching quality against labeled solicitations",
+	Long: `Scores a labeled set of narrative/solicitation pairs and reports precision
+and recall at the threshold, and how well scores are calibrated. With
+--vs-model or --vs-prompt, a second configuration is scored too and the
+cases whose verdict or score changed are listed.
+
+The golden set (--set) is YAML:
+
+  cases:
+    - id: radar-1
+      narrative: &radar |
+        We build airborne radar signal processing...
+      solicitation:
+        title: Next-generation radar processor
+        agency: AFRL
+        description: ...
+        documents: ...        # optional attachment text
+      relevant: true
+    - id: radar-2
+      narrative: *radar
+      ...
+
+Nothing is written to the database.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setFile, _ := cmd.Flags().GetString("set")
+		fromHistory, _ := cmd.Flags().GetBool("from-history")
+		limit, _ := cmd.Flags().GetInt("limit")
+		withFeedback, _ := cmd.Flags().GetBool("with-feedback")
+		threshold, _ := cmd.Flags().GetInt("threshold")
+		showAll, _ := cmd.Flags().GetBool("all")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		if (setFile == "") == !fromHistory {
+			fmt.Println("❌ Give either --set FILE or --from-history")
+			os.Exit(1)
+		}
+		if withFeedback && !fromHistory {
+			fmt.Println("❌ --with-feedback needs --from-history")
+			os.Exit(1)
+		}
+		if threshold < 1 || threshold > 100 {
+			fmt.Println("❌ --threshold must be between 1 and 100")
+			os.Exit(1)
+		}
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			slog.Error("Error loading config", "error", err)
+			os.Exit(1)
+		}
+		database, err := db.Connect(cfg.DatabaseURL)
+		if err != nil {
+			slog.Error("DB connect failed", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		var cases []matching.EvalCase
+		if setFile != "" {
+			if cases, err = matching.LoadEvalSet(setFile); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			userID := 0
+			if matchUserEmail != "" || matchUserID != 0 {
+				user, err := resolveUser(ctx, repository.NewUserRepository(database))
+				if err != nil {
+					slog.Error("User not found", "error", err)
+					os.Exit(1)
+				}
+				userID = user.ID
+			}
+			claims, err := repository.NewSolicitationRepository(database).ListLabeledClaims(ctx, userID, limit)
+			if err != nil {
+				slog.Error("Failed to load lead and archive history", "error", err)
+				os.Exit(1)
+			}
+			if len(claims) == 0 {
+				fmt.Println("❌ No lead or archive decisions by users with a narrative")
+				os.Exit(1)
+			}
+			cases = matching.EvalCasesFromClaims(claims)
+			if withFeedback {
+				if err := matching.AddFeedbackExamples(ctx, repository.NewMatchRepository(database), cases); err != nil {
+					slog.Error("Failed to load match feedback", "error", err)
+					os.Exit(1)
+				}
+			}
+		}
+
+		store := prompt.NewStore(repository.NewPromptRepository(database))
+		configs := []evalConfig{evalConfigFromFlags(ctx, cmd, cfg, store, "model", "prompt")}
+		vsModel, _ := cmd.Flags().GetString("vs-model")
+		vsPrompt, _ := cmd.Flags().GetString("vs-prompt")
+		if vsModel != "" || vsPrompt != "" {
+			configs = append(configs, evalConfigFromFlags(ctx, cmd, cfg, store, "vs-model", "vs-prompt"))
+		}
+
+		limits := matching.Limits{Concurrency: cfg.LLMConcurrency, RequestsPerMinute: cfg.LLMRequestsPerMinute}
+		if workers, _ := cmd.Flags().GetInt("concurrency"); workers > 0 {
+			limits.Concurrency = workers
+		}
+		if rpm, _ := cmd.Flags().GetInt("rpm"); rpm >= 0 {
+			limits.RequestsPerMinute = rpm
+		}
+
+		var runs []evalRun
+		for _, c := range configs {
+			matcher := ai.NewMatcher(newLLMProvider(c.llm), prompt.Pinned(c.prompt))
+			results := matching.Evaluate(ctx, matcher, cases, limits, printProgress())
+			if ctx.Err() != nil {
+				fmt.Fprintln(os.Stderr, "Interrupted")
+				os.Exit(1)
+			}
+			runs = append(runs, evalRun{
+				Model:   c.llm.Model,
+				Prompt:  c.promptLabel,
+				Report:  matching.Report(results, threshold),
+				Results: results,
+			})
+		}
+
+		var diffs []matching.EvalDiff
+		if len(runs) == 2 {
+			diffs = matching.CompareEval(runs[0].Results, runs[1].Results, threshold)
+		}
+
+		if jsonOutput {
+			out := map[string]interface{}{"a": runs[0]}
+			if len(runs) == 2 {
+				out["b"] = runs[1]
+				out["diffs"] = diffs
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(out); err != nil {
+				slog.Error("Failed to encode JSON", "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		relevant := 0
+		for _, c := range cases {
+			if c.Relevant {
+				relevant++
+			}
+		}
+		fmt.Printf("%d cases (%d relevant), threshold %d\n\n", len(cases), relevant, threshold)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CONFIG\tMODEL\tPROMPT\tPRECISION\tRECALL\tF1\tBRIER\tTP/FP/TN/FN\tFAILED")
+		for i, run := range runs {
+			r := run.Report
+			fmt.Fprintf(w, "%c\t%s\t%s\t%.2f\t%.2f\t%.2f\t%.3f\t%d/%d/%d/%d\t%d\n", 'A'+i, run.Model, run.Prompt,
+				r.Precision, r.Recall, r.F1, r.Brier, r.TruePositives, r.FalsePositives, r.TrueNegatives, r.FalseNegatives, r.Failed)
+		}
+		w.Flush()
+
+		for i, run := range runs {
+			fmt.Printf("\nCalibration (%c): share of cases in each score range that are relevant\n", 'A'+i)
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SCORE\tCASES\tMEAN SCORE\tRELEVANT")
+			for _, b := range run.Report.Calibration {
+				if b.Count == 0 {
+					fmt.Fprintf(w, "%d-%d\t0\t-\t-\n", b.Min, b.Max)
+					continue
+				}
+				fmt.Fprintf(w, "%d-%d\t%d\t%.0f\t%.0f%%\n", b.Min, b.Max, b.Count, b.MeanScore, b.Relevant*100)
+			}
+			w.Flush()
+		}
+
+		if len(runs) == 2 {
+			fixed, broken := 0, 0
+			fmt.Println("\nChanges from A to B:")
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "CASE\tLABEL\tA\tB\tDELTA\tVERDICT")
+			for _, d := range diffs {
+				switch d.Change {
+				case matching.EvalFixed:
+					fixed++
+				case matching.EvalBroken:
+					broken++
+				}
+				if !showAll && d.Change == "" && d.Delta() > -10 && d.Delta() < 10 && d.ErrorA == "" && d.ErrorB == "" {
+					continue
+				}
+				label := "irrelevant"
+				if d.Relevant {
+					label = "relevant"
+				}
+				verdict := ""
+				switch d.Change {
+				case matching.EvalFixed:
+					verdict = "✅ fixed"
+				case matching.EvalBroken:
+					verdict = "❌ broken"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%+d\t%s\n", d.ID, label, evalScore(d.ScoreA, d.ErrorA), evalScore(d.ScoreB, d.ErrorB), d.Delta(), verdict)
+			}
+			w.Flush()
+			fmt.Printf("\nB fixes %d and breaks %d of A's verdicts.\n", fixed, broken)
+		}
+	},
+}
+
+// evalConfigFromFlags builds a configuration from the matching LLM settings
+// and the given model and prompt flags
+func evalConfigFromFlags(ctx context.Context, cmd *cobra.Command, cfg config.Config, store *prompt.Store, modelFlag, promptFlag string) evalConfig {
+	c := evalConfig{llm: cfg.MatchingLLM()}
+	if model, _ := cmd.Flags().GetString(modelFlag); model != "" {
+		c.llm.Model = model
+	}
+
+	spec, _ := cmd.Flags().GetString(promptFlag)
+	var err error
+	if spec == "" {
+		c.prompt, err = store.Current(ctx, prompt.Match)
+	} else if version, convErr := strconv.Atoi(spec); convErr == nil {
+		c.prompt, err = store.Version(ctx, prompt.Match, version)
+		if err == nil && c.prompt == nil {
+			fmt.Printf("❌ The match prompt has no version %d\n", version)
+			os.Exit(1)
+		}
+	} else {
+		c.prompt, err = evalPromptFile(spec)
+	}
+	if err != nil {
+		fmt.Printf("❌ --%s: %v\n", promptFlag, err)
+		os.Exit(1)
+	}
+
+	c.promptLabel = fmt.Sprintf("v%d", c.prompt.Version)
+	if spec != "" && c.prompt.Version < 0 {
+		c.promptLabel = spec
+	}
+	return c
+}
+
+// evalPromptFile reads an unsaved match prompt, which gets version -1
+func evalPromptFile(path string) (*repository.PromptTemplate, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := prompt.Check(prompt.Match, string(body)); err != nil {
+		return nil, err
+	}
+	return &repository.PromptTemplate{Name: prompt.Match, Version: -1, Body: string(body)}, nil
+}
+
+func evalScore(score int, errMsg string) string {
+	if errMsg != "" {
+		return "failed"
+	}
+	return strconv.Itoa(score)
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"bd_bot/internal/oidc"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var mockOIDCPort int
+
+func init() {
+	mockOIDCCmd.Flags().IntVarP(&mockOIDCPort, "port", "p", 9999, "Port to listen on")
+	rootCmd.AddCommand(mockOIDCCmd)
+}
+
+var mockOIDCCmd = &cobra.Command{
+	Use:     "mock-oidc",
+	Short:   "Run a local mock OpenID Connect issuer for testing SSO",
+	GroupID: "dev",
+	Run: func(cmd *cobra.Command, args []string) {
+		issuerURL := fmt.Sprintf("http://localhost:%d", mockOIDCPort)
+		issuer, err := oidc.NewMockIssuer(issuerURL)
+		if err != nil {
+			slog.Error("Failed to create mock issuer", "error", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("🔑 Mock OIDC issuer running at %s\n", issuerURL)
+		fmt.Println("   Add to config.yaml:")
+		fmt.Println("     oidc:")
+		fmt.Printf("       issuer_url: %s\n", issuerURL)
+		fmt.Println("       client_id: joshua")
+		fmt.Println("       organization_claim: org")
+
+		addr := fmt.Sprintf("127.0.0.1:%d", mockOIDCPort)
+		if err := http.ListenAndServe(addr, issuer.Handler()); err != nil {
+			slog.Error("Mock issuer error", "error", err)
+			os.Exit(1)
+		}
+	},
+}
@@ -113,7 +113,7 @@ var orgMoveCmd = &cobra.Command{
 var orgRemoveCmd = &cobra.Command{
 	Use:   "remove",
 	Short: "Remove an organization from the list",
-	Long:  "Removes the organization from the autocomplete list. Does not delete users.",
+	Long:  "Removes the organization from the autocomplete list, along with its narrative and shared matches. Does not delete users.",
 	Run: func(cmd *cobra.Command, args []string) {
 		name, _ := cmd.Flags().GetString("name")
 
@@ -0,0 +1,328 @@
+package cli
+
+import (
+	"bd_bot/internal/config"
+	"bd_bot/internal/db"
+	"bd_bot/internal/prompt"
+	"bd_bot/internal/repository"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(promptCmd)
+
+	promptListCmd.Flags().Bool("json", false, "Output in JSON format")
+	promptCmd.AddCommand(promptListCmd)
+
+	promptShowCmd.Flags().IntP("version", "v", -1, "Version to show (default: the one in use, 0 for the built-in default)")
+	promptCmd.AddCommand(promptShowCmd)
+
+	promptEditCmd.Flags().StringP("file", "f", "", "Read the new template from a file instead of opening $EDITOR")
+	promptEditCmd.Flags().StringP("user", "u", "", "User email to attribute the change to")
+	promptCmd.AddCommand(promptEditCmd)
+
+	promptDiffCmd.Flags().Int("from", -1, "Older version (default: the one before --to)")
+	promptDiffCmd.Flags().Int("to", -1, "Newer version (default: the one in use)")
+	promptCmd.AddCommand(promptDiffCmd)
+}
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Manage the LLM prompt templates",
+	Long: `Prompts are Go text/template templates. Each save creates a new version,
+and the newest version is used from then on. Version 0 is the built-in default,
+which applies until a prompt is first edited.
+
+Prompts: ` + strings.Join(prompt.Names(), ", "),
+	GroupID: "intel",
+}
+
+// openPromptStore connects to the database and returns the prompt store
+func openPromptStore() (*prompt.Store, *sql.DB) {
+	cfg, _ := config.LoadConfig()
+	database, err := db.Connect(cfg.DatabaseURL)
+	if err != nil {
+		slog.Error("DB connect failed", "error", err)
+		os.Exit(1)
+	}
+	return prompt.NewStore(repository.NewPromptRepository(database)), database
+}
+
+// validPromptArg rejects unknown prompt names
+func validPromptArg(cmd *cobra.Command, args []string) error {
+	if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+		return err
+	}
+	if !prompt.Valid(args[0]) {
+		return fmt.Errorf("unknown prompt %q (use %s)", args[0], strings.Join(prompt.Names(), " or "))
+	}
+	return nil
+}
+
+var promptListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the prompts and the version of each in use",
+	Run: func(cmd *cobra.Command, args []string) {
+		store, database := openPromptStore()
+		defer database.Close()
+
+		var current []*repository.PromptTemplate
+		for _, name := range prompt.Names() {
+			p, err := store.Current(context.Background(), name)
+			if err != nil {
+				slog.Error("Failed to load prompt", "prompt", name, "error", err)
+				os.Exit(1)
+			}
+			current = append(current, p)
+		}
+
+		if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(current); err != nil {
+				slog.Error("Failed to encode JSON", "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "NAME\tVERSION\tAUTHOR\tSAVED")
+		for _, p := range current {
+			saved := "-"
+			if !p.CreatedAt.IsZero() {
+				saved = p.CreatedAt.Format("2006-01-02 15:04")
+			}
+			author := p.AuthorName
+			if author == "" {
+				author = "-"
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", p.Name, p.Version, author, saved)
+		}
+		w.Flush()
+	},
+}
+
+// loadPromptVersion returns a version of a prompt, or the one in use if
+// version is negative, exiting if it does not exist
+func loadPromptVersion(store *prompt.Store, name string, version int) *repository.PromptTemplate {
+	var p *repository.PromptTemplate
+	var err error
+	if version < 0 {
+		p, err = store.Current(context.Background(), name)
+	} else {
+		p, err = store.Version(context.Background(), name, version)
+	}
+	if err != nil {
+		slog.Error("Failed to load prompt", "prompt", name, "error", err)
+		os.Exit(1)
+	}
+	if p == nil {
+		fmt.Printf("❌ %s has no version %d\n", name, version)
+		os.Exit(1)
+	}
+	return p
+}
+
+var promptShowCmd = &cobra.Command{
+	Use:   "show NAME",
+	Short: "Print a prompt template",
+	Args:  validPromptArg,
+	Run: func(cmd *cobra.Command, args []string) {
+		version, _ := cmd.Flags().GetInt("version")
+
+		store, database := openPromptStore()
+		defer database.Close()
+
+		fmt.Print(loadPromptVersion(store, args[0], version).Body)
+	},
+}
+
+var promptEditCmd = &cobra.Command{
+	Use:   "edit NAME",
+	Short: "Save a new version of a prompt",
+	Long: `Opens the prompt in use in $EDITOR and saves the result as a new version,
+or reads the new template from --file. The template must parse and render
+before it is saved.`,
+	Args: validPromptArg,
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		file, _ := cmd.Flags().GetString("file")
+		userEmail, _ := cmd.Flags().GetString("user")
+
+		store, database := openPromptStore()
+		defer database.Close()
+		current := loadPromptVersion(store, name, -1)
+
+		var body string
+		if file != "" {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				slog.Error("Failed to read file", "error", err)
+				os.Exit(1)
+			}
+			body = string(data)
+		} else {
+			var err error
+			if body, err = editInEditor(name, current.Body); err != nil {
+				slog.Error("Editor failed", "error", err)
+				os.Exit(1)
+			}
+		}
+
+		if body == current.Body {
+			fmt.Printf("No changes; %s stays at version %d\n", name, current.Version)
+			return
+		}
+		if err := prompt.Check(name, body); err != nil {
+			fmt.Printf("❌ Invalid template: %v\n", err)
+			os.Exit(1)
+		}
+
+		authorID := 0
+		if userEmail != "" {
+			user, err := repository.NewUserRepository(database).FindByEmail(context.Background(), userEmail)
+			if err != nil {
+				slog.Error("User not found", "email", userEmail)
+				os.Exit(1)
+			}
+			authorID = user.ID
+		}
+
+		p, err := store.Save(context.Background(), name, body, authorID)
+		if err != nil {
+			slog.Error("Failed to save prompt", "error", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Saved %s version %d\n", p.Name, p.Version)
+	},
+}
+
+// editInEditor opens content in $EDITOR (vi if unset) and returns the result
+func editInEditor(name, content string) (string, error) {
+	f, err := os.CreateTemp("", "joshua-prompt-"+name+"-*.tmpl")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return "", err
+	}
+	f.Close()
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	// $EDITOR may carry arguments, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	c := exec.Command(parts[0], append(parts[1:], f.Name())...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(f.Name())
+	return string(data), err
+}
+
+var promptDiffCmd = &cobra.Command{
+	Use:   "diff NAME",
+	Short: "Show what changed between two versions of a prompt",
+	Args:  validPromptArg,
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		from, _ := cmd.Flags().GetInt("from")
+		to, _ := cmd.Flags().GetInt("to")
+
+		store, database := openPromptStore()
+		defer database.Close()
+
+		newer := loadPromptVersion(store, name, to)
+		if from < 0 {
+			from = max(newer.Version-1, 0)
+		}
+		older := loadPromptVersion(store, name, from)
+
+		fmt.Printf("--- %s v%d\n+++ %s v%d\n", name, older.Version, name, newer.Version)
+		for _, line := range diffLines(strings.Split(older.Body, "\n"), strings.Split(newer.Body, "\n"), 3) {
+			fmt.Println(line)
+		}
+	},
+}
+
+// diffLines returns a line diff of a and b: removed lines start with "-",
+// added ones with "+", and unchanged ones with " ". Only the given number of
+// unchanged lines around each change is kept; gaps are marked with "@@".
+func diffLines(a, b []string, around int) []string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var all []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			all = append(all, " "+a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			all = append(all, "-"+a[i])
+			i++
+		default:
+			all = append(all, "+"+b[j])
+			j++
+		}
+	}
+
+	// Keep changed lines and their context
+	keep := make([]bool, len(all))
+	for k, line := range all {
+		if line[0] == ' ' {
+			continue
+		}
+		for c := max(k-around, 0); c <= min(k+around, len(all)-1); c++ {
+			keep[c] = true
+		}
+	}
+	var out []string
+	skipped := false
+	for k, line := range all {
+		if !keep[k] {
+			skipped = true
+			continue
+		}
+		if skipped && len(out) > 0 {
+			out = append(out, "@@")
+		}
+		skipped = false
+		out = append(out, line)
+	}
+	if len(out) == 0 {
+		return []string{"(no differences)"}
+	}
+	return out
+}
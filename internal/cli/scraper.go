@@ -3,13 +3,18 @@ package cli
 import (
 	"bd_bot/internal/config"
 	"bd_bot/internal/db"
+	"bd_bot/internal/mail"
+	"bd_bot/internal/notify"
 	"bd_bot/internal/repository"
+	"bd_bot/internal/scheduler"
 	"bd_bot/internal/scraper"
 	"bd_bot/internal/scraper/sources/georgia"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -17,9 +22,22 @@ import (
 
 func init() {
 	scraperCmd.AddCommand(runNowCmd)
+	scraperCmd.AddCommand(scraperStatusCmd)
+
+	runNowCmd.Flags().Bool("full", false, "Re-fetch details for every solicitation, ignoring stored fingerprints")
+
+	scraperStatusCmd.Flags().IntP("limit", "n", 10, "Number of recent runs to show")
+	scraperStatusCmd.Flags().Bool("json", false, "Output in JSON format")
 	rootCmd.AddCommand(scraperCmd)
 }
 
+// newScraperEngine builds an engine with every supported source registered
+func newScraperEngine() *scraper.Engine {
+	engine := scraper.NewEngine()
+	engine.Register(georgia.NewGPRScraper())
+	return engine
+}
+
 var scraperCmd = &cobra.Command{
 	Use:     "scraper",
 	Short:   "Manage the scraper bot",
@@ -30,6 +48,8 @@ var runNowCmd = &cobra.Command{
 	Use:   "run-now",
 	Short: "Trigger an immediate scraper run",
 	Run: func(cmd *cobra.Command, args []string) {
+		full, _ := cmd.Flags().GetBool("full")
+
 		cfg, err := config.LoadConfig()
 		if err != nil {
 			slog.Error("Error loading config", "error", err)
@@ -45,35 +65,102 @@ var runNowCmd = &cobra.Command{
 		defer database.Close()
 
 		solRepo := repository.NewSolicitationRepository(database)
+		runRepo := repository.NewScrapeRunRepository(database)
+		outbox := mail.NewOutbox(repository.NewMailRepository(database), mail.NewSender(cfg))
+		notifier := notify.NewNotifier(solRepo, repository.NewNotificationRepository(database), outbox)
 
-		// 2. Initialize Engine
-		engine := scraper.NewEngine()
+		// 2. Initialize Engine & Pipeline
+		pipeline := scheduler.NewPipeline(newScraperEngine(), solRepo, runRepo, notifier, newDocumentFetcher(cfg, database), nil, nil)
 
-		// 3. Register Sources
-		engine.Register(georgia.NewGPRScraper())
-
-		// 4. Run
+		// 3. Run
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute) // Increased timeout
 		defer cancel()
 
-		slog.Info("Launching Midnight Bot (Manual Trigger)")
-		results, err := engine.Run(ctx)
+		slog.Info("Launching Midnight Bot (Manual Trigger)", "full", full)
+		run, err := scheduler.RunLocked(ctx, database, pipeline, "manual", full)
 		if err != nil {
 			slog.Error("Error during scraping", "error", err)
+			fmt.Printf("❌ Scraper run failed: %v\n", err)
+			os.Exit(1)
 		}
 
-		// 5. Save results to DB
-		slog.Info("Saving results to database...")
-		successCount := 0
-		for _, sol := range results {
-			if err := solRepo.Upsert(ctx, sol); err != nil {
-				slog.Error("Failed to upsert solicitation", "source_id", sol.SourceID, "error", err)
-				continue
+		// Deliver any claimant emails queued by the run, even a failed one
+		if _, err := outbox.Flush(ctx); err != nil {
+			slog.Error("Failed to deliver queued mail", "error", err)
+		}
+
+		// The pipeline records source and matching failures on the run
+		// rather than returning them
+		if run.Status == "failed" {
+			fmt.Printf("❌ Scraper run %d failed (found %d, saved/updated %d): %s\n", run.ID, run.ItemsFound, run.ItemsSaved, run.Error)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Scraper run complete. Found %d, Saved/Updated %d.\n", run.ItemsFound, run.ItemsSaved)
+	},
+}
+
+var scraperStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show scraper health and recent run history",
+	Run: func(cmd *cobra.Command, args []string) {
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		cfg, _ := config.LoadConfig()
+		database, err := db.Connect(cfg.DatabaseURL)
+		if err != nil {
+			slog.Error("DB connect failed", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		repo := repository.NewScrapeRunRepository(database)
+		health, err := repo.SourceHealth(context.Background())
+		if err != nil {
+			slog.Error("Failed to load scraper health", "error", err)
+			os.Exit(1)
+		}
+		runs, err := repo.ListWithSources(context.Background(), limit)
+		if err != nil {
+			slog.Error("Failed to list scrape runs", "error", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(map[string]interface{}{"sources": health, "runs": runs}); err != nil {
+				slog.Error("Failed to encode JSON", "error", err)
+				os.Exit(1)
 			}
-			successCount++
+			return
 		}
 
-		slog.Info("Scraper run complete", "found", len(results), "saved_or_updated", successCount)
-		fmt.Printf("✅ Scraper run complete. Found %d, Saved/Updated %d.\n", len(results), successCount)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "SOURCE\tLAST RUN\tFOUND\tTRAILING AVG\tSTATUS")
+		for _, h := range health {
+			status := "OK"
+			if h.LastError != "" {
+				status = "⚠️  ERROR: " + h.LastError
+			} else if h.Alert {
+				status = "⚠️  ZERO ITEMS (below trailing average)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%.1f\t%s\n", h.Source, h.LastRunAt.Format("2006-01-02 15:04"), h.LastFound, h.TrailingAvg, status)
+		}
+		w.Flush()
+
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "RUN\tSTARTED\tTRIGGER\tSOURCE\tFOUND\tNEW\tUPDATED\tDURATION\tERROR")
+		for _, run := range runs {
+			if len(run.Sources) == 0 {
+				fmt.Fprintf(w, "%d\t%s\t%s\t-\t%d\t-\t-\t-\t%s\n", run.ID, run.StartedAt.Format("2006-01-02 15:04"), run.Trigger, run.ItemsFound, run.Error)
+				continue
+			}
+			for _, src := range run.Sources {
+				duration := time.Duration(src.DurationMS) * time.Millisecond
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s\n", run.ID, run.StartedAt.Format("2006-01-02 15:04"), run.Trigger, src.Source, src.ItemsFound, src.ItemsNew, src.ItemsUpdated, duration.Round(time.Second), src.Error)
+			}
+		}
+		w.Flush()
 	},
-}
\ No newline at end of file
+}
@@ -5,13 +5,20 @@ import (
 	"bd_bot/internal/api"
 	"bd_bot/internal/config"
 	"bd_bot/internal/db"
+	"bd_bot/internal/mail"
+	"bd_bot/internal/matching"
+	"bd_bot/internal/notify"
+	"bd_bot/internal/prompt"
 	"bd_bot/internal/repository"
+	"bd_bot/internal/scheduler"
 	"bd_bot/web"
+	"context"
 	"fmt"
 	"io/fs"
 	"log/slog"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -94,13 +101,79 @@ var serveCmd = &cobra.Command{
 
 		chatRepo := repository.NewChatRepository(database)
 
-		chatSvc := ai.NewChatService(cfg.LLMURL, cfg.LLMKey, cfg.LLMModel)
+		scrapeRunRepo := repository.NewScrapeRunRepository(database)
+
+		notifRepo := repository.NewNotificationRepository(database)
+
+		sessionRepo := repository.NewSessionRepository(database)
+
+		if n, err := sessionRepo.DeleteExpired(context.Background()); err != nil {
+
+			slog.Error("Failed to purge expired sessions", "error", err)
+
+		} else if n > 0 {
+
+			slog.Info("Purged expired sessions", "count", n)
+
+		}
+
+		outbox := mail.NewOutbox(repository.NewMailRepository(database), mail.NewSender(cfg))
+
+		notifier := notify.NewNotifier(solRepo, notifRepo, outbox)
+
+		chatSvc := ai.NewChatService(newLLMProvider(cfg.ChatLLM()))
+
+		// Shared so that prompt edits made through the API apply at once
+
+		prompts := prompt.NewStore(repository.NewPromptRepository(database))
+
+
+
+		// The scheduler and web-requested jobs share one runner, and so one LLM rate limit
+
+		runner := newMatchRunner(cfg, database, prompts)
+
+		matchJobs := matching.NewJobManager(runner, userRepo, repository.NewMatchJobRepository(database))
+
+		go matchJobs.Start(context.Background())
+
+
+
+		// Background scheduler ("Midnight Bot")
+
+		if cfg.ScrapeSchedule != "" {
+
+			digester := notify.NewDigester(userRepo, matchRepo, solRepo, outbox, cfg.PublicURL)
+
+			pipeline := scheduler.NewPipeline(newScraperEngine(), solRepo, scrapeRunRepo, notifier, newDocumentFetcher(cfg, database), runner, digester)
+
+			sched, err := scheduler.New(cfg.ScrapeSchedule, database, pipeline)
+
+			if err != nil {
+
+				slog.Error("Invalid scrape_schedule", "schedule", cfg.ScrapeSchedule, "error", err)
+
+				os.Exit(1)
+
+			}
+
+			go sched.Start(context.Background())
+
+			slog.Info("Scraper scheduler enabled", "schedule", cfg.ScrapeSchedule)
+
+		}
+
+
+
+		// Outbound mail delivery
+
+		go outbox.Run(context.Background(), 30*time.Second)
 
 
 
 		// 2. Router
 
-		mux := api.NewRouter(solRepo, userRepo, matchRepo, feedbackRepo, reqRepo, taskRepo, iradRepo, chatSvc, auditRepo, chatRepo)
+		mux := api.NewRouter(solRepo, userRepo, matchRepo, feedbackRepo, reqRepo, taskRepo, iradRepo, chatSvc, auditRepo, chatRepo, scrapeRunRepo, notifRepo, outbox, cfg.PublicURL, sessionRepo, time.Duration(cfg.SessionTTLHours)*time.Hour, cfg.OIDC, repository.NewAPITokenRepository(database), repository.NewDocumentRepository(database), matchJobs, repository.NewOrganizationRepository(database), prompts)
 
 
 
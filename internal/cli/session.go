@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"bd_bot/internal/config"
+	"bd_bot/internal/db"
+	"bd_bot/internal/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	userSessionsCmd.AddCommand(userSessionsListCmd)
+	userSessionsCmd.AddCommand(userSessionsRevokeCmd)
+
+	userSessionsListCmd.Flags().StringP("email", "e", "", "Only show sessions for this user")
+	userSessionsListCmd.Flags().Bool("json", false, "Output in JSON format")
+
+	userSessionsRevokeCmd.Flags().Int("id", 0, "Session ID to revoke")
+	userSessionsRevokeCmd.Flags().StringP("email", "e", "", "Revoke every session for this user")
+
+	userCmd.AddCommand(userSessionsCmd)
+}
+
+var userSessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Manage login sessions",
+}
+
+var userSessionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List active sessions",
+	Run: func(cmd *cobra.Command, args []string) {
+		email, _ := cmd.Flags().GetString("email")
+
+		cfg, _ := config.LoadConfig()
+		database, err := db.Connect(cfg.DatabaseURL)
+		if err != nil {
+			slog.Error("DB connect failed", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		ctx := context.Background()
+		userID := 0
+		if email != "" {
+			user, err := repository.NewUserRepository(database).FindByEmail(ctx, email)
+			if err != nil {
+				slog.Error("User not found", "email", email)
+				os.Exit(1)
+			}
+			userID = user.ID
+		}
+
+		sessions, err := repository.NewSessionRepository(database).ListActive(ctx, userID)
+		if err != nil {
+			slog.Error("Failed to list sessions", "error", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(sessions); err != nil {
+				slog.Error("Failed to encode JSON", "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "ID\tUSER\tIP\tCREATED\tLAST SEEN\tEXPIRES\tUSER AGENT")
+		for _, s := range sessions {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", s.ID, s.UserEmail, s.IPAddress, s.CreatedAt.Format("2006-01-02 15:04"), s.LastSeenAt.Format("2006-01-02 15:04"), s.ExpiresAt.Format("2006-01-02 15:04"), s.UserAgent)
+		}
+		w.Flush()
+	},
+}
+
+var userSessionsRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Revoke a session by ID, or all sessions for a user",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetInt("id")
+		email, _ := cmd.Flags().GetString("email")
+		if (id == 0) == (email == "") {
+			fmt.Println("❌ Specify exactly one of --id or --email")
+			os.Exit(1)
+		}
+
+		cfg, _ := config.LoadConfig()
+		database, err := db.Connect(cfg.DatabaseURL)
+		if err != nil {
+			slog.Error("DB connect failed", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		ctx := context.Background()
+		repo := repository.NewSessionRepository(database)
+
+		if id != 0 {
+			if err := repo.Revoke(ctx, id); err != nil {
+				slog.Error("Failed to revoke session", "id", id, "error", err)
+				os.Exit(1)
+			}
+			fmt.Printf("✅ Session %d revoked\n", id)
+			return
+		}
+
+		user, err := repository.NewUserRepository(database).FindByEmail(ctx, email)
+		if err != nil {
+			slog.Error("User not found", "email", email)
+			os.Exit(1)
+		}
+		count, err := repo.RevokeAllForUser(ctx, user.ID)
+		if err != nil {
+			slog.Error("Failed to revoke sessions", "error", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Revoked %d session(s) for %s\n", count, email)
+	},
+}
@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"bd_bot/internal/config"
+	"bd_bot/internal/db"
+	"bd_bot/internal/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	userTokenCmd.AddCommand(userTokenCreateCmd)
+	userTokenCmd.AddCommand(userTokenListCmd)
+	userTokenCmd.AddCommand(userTokenRevokeCmd)
+
+	userTokenCreateCmd.Flags().StringP("email", "e", "", "User to issue the token for")
+	userTokenCreateCmd.Flags().StringP("name", "n", "", "Name to identify the token")
+	userTokenCreateCmd.Flags().StringSliceP("scope", "s", []string{repository.ScopeRead}, "Scopes to grant (read, write)")
+	userTokenCreateCmd.Flags().Int("expires-days", 90, "Days until the token expires (0 for never)")
+	userTokenCreateCmd.MarkFlagRequired("email")
+	userTokenCreateCmd.MarkFlagRequired("name")
+
+	userTokenListCmd.Flags().StringP("email", "e", "", "Only show tokens for this user")
+	userTokenListCmd.Flags().Bool("json", false, "Output in JSON format")
+
+	userTokenRevokeCmd.Flags().Int("id", 0, "Token ID to revoke")
+	userTokenRevokeCmd.MarkFlagRequired("id")
+
+	userCmd.AddCommand(userTokenCmd)
+}
+
+var userTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage personal API tokens",
+}
+
+var userTokenCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Issue a personal API token",
+	Run: func(cmd *cobra.Command, args []string) {
+		email, _ := cmd.Flags().GetString("email")
+		name, _ := cmd.Flags().GetString("name")
+		scopes, _ := cmd.Flags().GetStringSlice("scope")
+		days, _ := cmd.Flags().GetInt("expires-days")
+
+		for _, s := range scopes {
+			if !repository.ValidTokenScope(s) {
+				fmt.Printf("❌ Unknown scope %q (use read or write)\n", s)
+				os.Exit(1)
+			}
+		}
+
+		cfg, _ := config.LoadConfig()
+		database, err := db.Connect(cfg.DatabaseURL)
+		if err != nil {
+			slog.Error("DB connect failed", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		ctx := context.Background()
+		user, err := repository.NewUserRepository(database).FindByEmail(ctx, email)
+		if err != nil {
+			slog.Error("User not found", "email", email)
+			os.Exit(1)
+		}
+
+		var expiresAt *time.Time
+		if days > 0 {
+			t := time.Now().AddDate(0, 0, days)
+			expiresAt = &t
+		}
+
+		secret, token, err := repository.NewAPITokenRepository(database).Create(ctx, user.ID, name, scopes, expiresAt)
+		if err != nil {
+			slog.Error("Failed to create token", "error", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✅ Token %d created for %s (scopes: %s)\n", token.ID, email, strings.Join(token.Scopes, ","))
+		fmt.Println("   Copy it now; it will not be shown again:")
+		fmt.Printf("\n   %s\n", secret)
+	},
+}
+
+var userTokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List active API tokens",
+	Run: func(cmd *cobra.Command, args []string) {
+		email, _ := cmd.Flags().GetString("email")
+
+		cfg, _ := config.LoadConfig()
+		database, err := db.Connect(cfg.DatabaseURL)
+		if err != nil {
+			slog.Error("DB connect failed", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		ctx := context.Background()
+		userID := 0
+		if email != "" {
+			user, err := repository.NewUserRepository(database).FindByEmail(ctx, email)
+			if err != nil {
+				slog.Error("User not found", "email", email)
+				os.Exit(1)
+			}
+			userID = user.ID
+		}
+
+		tokens, err := repository.NewAPITokenRepository(database).List(ctx, userID)
+		if err != nil {
+			slog.Error("Failed to list tokens", "error", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(tokens); err != nil {
+				slog.Error("Failed to encode JSON", "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "ID\tUSER\tNAME\tPREFIX\tSCOPES\tCREATED\tLAST USED\tEXPIRES")
+		for _, t := range tokens {
+			lastUsed, expires := "never", "never"
+			if t.LastUsedAt != nil {
+				lastUsed = t.LastUsedAt.Format("2006-01-02 15:04")
+			}
+			if t.ExpiresAt != nil {
+				expires = t.ExpiresAt.Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s…\t%s\t%s\t%s\t%s\n", t.ID, t.UserEmail, t.Name, t.Prefix, strings.Join(t.Scopes, ","), t.CreatedAt.Format("2006-01-02 15:04"), lastUsed, expires)
+		}
+		w.Flush()
+	},
+}
+
+var userTokenRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Revoke an API token by ID",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetInt("id")
+
+		cfg, _ := config.LoadConfig()
+		database, err := db.Connect(cfg.DatabaseURL)
+		if err != nil {
+			slog.Error("DB connect failed", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		if err := repository.NewAPITokenRepository(database).Revoke(context.Background(), id, 0); err != nil {
+			slog.Error("Failed to revoke token", "id", id, "error", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Token %d revoked\n", id)
+	},
+}
@@ -3,12 +3,14 @@ package cli
 import (
 	"bd_bot/internal/config"
 	"bd_bot/internal/db"
+	"bd_bot/internal/rbac"
 	"bd_bot/internal/repository"
 	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -19,6 +21,8 @@ func init() {
 	userCmd.AddCommand(userListCmd)
 	userCmd.AddCommand(userCreateCmd)
 	userCmd.AddCommand(userPasswdCmd)
+	userCmd.AddCommand(userSetRoleCmd)
+	userCmd.AddCommand(userSetOrgCmd)
 	
 	userListCmd.Flags().Bool("json", false, "Output in JSON format")
 
@@ -32,6 +36,15 @@ func init() {
 	userPasswdCmd.MarkFlagRequired("email")
 	userPasswdCmd.MarkFlagRequired("password")
 
+	userSetRoleCmd.Flags().StringP("email", "e", "", "User email (required)")
+	userSetRoleCmd.Flags().StringP("role", "r", "", "Role: "+strings.Join(rbac.Roles, ", ")+" (required)")
+	userSetRoleCmd.MarkFlagRequired("email")
+	userSetRoleCmd.MarkFlagRequired("role")
+
+	userSetOrgCmd.Flags().StringP("email", "e", "", "User email (required)")
+	userSetOrgCmd.Flags().StringP("org", "o", "", "Organization name; empty removes the user from their organization")
+	userSetOrgCmd.MarkFlagRequired("email")
+
 	rootCmd.AddCommand(userCmd)
 }
 
@@ -67,8 +80,88 @@ var userPasswdCmd = &cobra.Command{
 			slog.Error("Failed to set password", "error", err)
 			os.Exit(1)
 		}
+		revoked, err := repository.NewSessionRepository(database).RevokeAllForUser(context.Background(), user.ID)
+		if err != nil {
+			slog.Error("Failed to revoke sessions", "error", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✅ Password set for user: %s (%d session(s) logged out)\n", email, revoked)
+	},
+}
+
+var userSetRoleCmd = &cobra.Command{
+	Use:   "set-role",
+	Short: "Change a user's role",
+	Run: func(cmd *cobra.Command, args []string) {
+		email, _ := cmd.Flags().GetString("email")
+		role, _ := cmd.Flags().GetString("role")
+
+		if !rbac.ValidRole(role) {
+			fmt.Printf("❌ Unknown role %q. Valid roles: %s\n", role, strings.Join(rbac.Roles, ", "))
+			os.Exit(1)
+		}
+
+		cfg, _ := config.LoadConfig()
+		database, err := db.Connect(cfg.DatabaseURL)
+		if err != nil {
+			slog.Error("DB connect failed", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		repo := repository.NewUserRepository(database)
+		user, err := repo.FindByEmail(context.Background(), email)
+		if err != nil {
+			slog.Error("User not found", "email", email)
+			os.Exit(1)
+		}
 
-		fmt.Printf("✅ Password set for user: %s\n", email)
+		if err := repo.SetRole(context.Background(), user.ID, role); err != nil {
+			slog.Error("Failed to set role", "error", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✅ %s is now %s (was %s)\n", email, role, user.Role)
+	},
+}
+
+var userSetOrgCmd = &cobra.Command{
+	Use:   "set-org",
+	Short: "Put a user in an organization",
+	Long: `Members see the organization's narrative and shared inbox, so membership is
+set here rather than on the profile page. The organization is created if it
+does not exist yet.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		email, _ := cmd.Flags().GetString("email")
+		org, _ := cmd.Flags().GetString("org")
+		org = strings.TrimSpace(org)
+
+		cfg, _ := config.LoadConfig()
+		database, err := db.Connect(cfg.DatabaseURL)
+		if err != nil {
+			slog.Error("DB connect failed", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		repo := repository.NewUserRepository(database)
+		user, err := repo.FindByEmail(context.Background(), email)
+		if err != nil {
+			slog.Error("User not found", "email", email)
+			os.Exit(1)
+		}
+
+		if err := repo.SetOrganization(context.Background(), user.ID, org); err != nil {
+			slog.Error("Failed to set organization", "error", err)
+			os.Exit(1)
+		}
+
+		if org == "" {
+			fmt.Printf("✅ %s is no longer in an organization (was %q)\n", email, user.Organization)
+			return
+		}
+		fmt.Printf("✅ %s is now in %s\n", email, org)
 	},
 }
 
@@ -12,8 +12,106 @@ type Config struct {
 	LLMURL      string `yaml:"llm_url"`
 	LLMKey      string `yaml:"llm_key"`
 	LLMModel    string `yaml:"llm_model"`
+	// LLMProvider is the API spoken at llm_url: openai (the default, any
+	// OpenAI-compatible endpoint), ollama (native /api/chat) or fake (canned
+	// replies for tests)
+	LLMProvider string `yaml:"llm_provider"`
 	LogPath     string `yaml:"log_path"`
 	LogLevel    string `yaml:"log_level"`
+
+	// Matching and Chat override the llm_* settings for one feature; empty
+	// fields fall back to them
+	Matching LLMConfig `yaml:"matching,omitempty"`
+	Chat     LLMConfig `yaml:"chat,omitempty"`
+
+	// EmbeddingModel is served by the matching endpoint and used to
+	// pre-filter solicitations before LLM scoring, for users and organizations
+	// that set match_top_k. Empty (the default) sends every solicitation to
+	// the LLM.
+	EmbeddingModel string `yaml:"embedding_model"`
+
+	// LLMConcurrency is how many matching requests are sent at once, and
+	// LLMRequestsPerMinute caps their rate (0 for no limit)
+	LLMConcurrency       int `yaml:"llm_concurrency"`
+	LLMRequestsPerMinute int `yaml:"llm_requests_per_minute"`
+
+	// DocumentsDir is where downloaded solicitation attachments are stored. It
+	// must not be under the publicly served uploads/ directory.
+	DocumentsDir string `yaml:"documents_dir"`
+
+	// ScrapeSchedule is a cron expression for the built-in scraper/matching
+	// pipeline run by `joshua serve`. Leave empty to disable.
+	ScrapeSchedule string `yaml:"scrape_schedule"`
+
+	// Outbound mail. Leave SMTPHost empty to queue mail without delivering it.
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+	SMTPTLS      string `yaml:"smtp_tls"` // none, starttls, tls
+	MailFrom     string `yaml:"mail_from"`
+
+	// PublicURL is the externally reachable address of the portal, used for links in emails
+	PublicURL string `yaml:"public_url"`
+
+	// SessionTTLHours is how long a login session stays valid
+	SessionTTLHours int `yaml:"session_ttl_hours"`
+
+	// OIDC enables single sign-on when IssuerURL is set
+	OIDC OIDCConfig `yaml:"oidc"`
+}
+
+// LLMConfig selects the model behind one feature
+type LLMConfig struct {
+	Provider string `yaml:"provider,omitempty"`
+	URL      string `yaml:"url,omitempty"`
+	Key      string `yaml:"key,omitempty"`
+	Model    string `yaml:"model,omitempty"`
+}
+
+// MatchingLLM returns the model settings used to score solicitations
+func (c Config) MatchingLLM() LLMConfig {
+	return c.llmFor(c.Matching)
+}
+
+// ChatLLM returns the model settings used by the chat assistant
+func (c Config) ChatLLM() LLMConfig {
+	return c.llmFor(c.Chat)
+}
+
+func (c Config) llmFor(override LLMConfig) LLMConfig {
+	llm := LLMConfig{Provider: c.LLMProvider, URL: c.LLMURL, Key: c.LLMKey, Model: c.LLMModel}
+	if override.Provider != "" {
+		llm.Provider = override.Provider
+	}
+	if override.URL != "" {
+		llm.URL = override.URL
+	}
+	if override.Key != "" {
+		llm.Key = override.Key
+	}
+	if override.Model != "" {
+		llm.Model = override.Model
+	}
+	return llm
+}
+
+// OIDCConfig holds OpenID Connect provider settings
+type OIDCConfig struct {
+	// ProviderName is stored with each linked identity (user_identities) and
+	// shown on the login button
+	ProviderName string `yaml:"provider_name"`
+	IssuerURL    string `yaml:"issuer_url"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	// RedirectURL defaults to public_url + /api/auth/oidc/callback
+	RedirectURL string   `yaml:"redirect_url"`
+	Scopes      []string `yaml:"scopes"`
+
+	// Claim names mapped onto the user profile
+	NameClaim         string `yaml:"name_claim"`
+	EmailClaim        string `yaml:"email_claim"`
+	OrganizationClaim string `yaml:"organization_claim"`
 }
 
 // DefaultConfig returns the default configuration
@@ -23,8 +121,30 @@ func DefaultConfig() Config {
 		LLMURL:      "http://127.0.0.1:11434/v1",
 		LLMKey:      "sk-...",
 		LLMModel:    "gemma3:4b",
+		LLMProvider: "openai",
 		LogPath:     "bd_bot.log",
 		LogLevel:    "INFO",
+
+		LLMConcurrency: 2,
+
+		DocumentsDir: "data/documents",
+
+		ScrapeSchedule: "0 0 * * *",
+
+		SMTPPort: 587,
+		SMTPTLS:  "starttls",
+		MailFrom: "joshua@localhost",
+
+		PublicURL: "http://localhost:8080",
+
+		SessionTTLHours: 168,
+
+		OIDC: OIDCConfig{
+			ProviderName: "sso",
+			Scopes:       []string{"openid", "profile", "email"},
+			NameClaim:    "name",
+			EmailClaim:   "email",
+		},
 	}
 }
 
@@ -46,4 +166,4 @@ func LoadConfig() (Config, error) {
 	}
 
 	return cfg, nil
-}
\ No newline at end of file
+}
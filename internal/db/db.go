@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -26,3 +27,29 @@ func Connect(url string) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// TryAdvisoryLock attempts to take a session-level Postgres advisory lock on a
+// dedicated connection. When ok is true the caller must invoke release, which
+// unlocks and returns the connection to the pool.
+func TryAdvisoryLock(ctx context.Context, db *sql.DB, key int64) (release func(), ok bool, err error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("error acquiring connection: %w", err)
+	}
+
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("error taking advisory lock: %w", err)
+	}
+	if !ok {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release = func() {
+		// Use a fresh context so the lock is released even if ctx was cancelled
+		conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key)
+		conn.Close()
+	}
+	return release, true, nil
+}
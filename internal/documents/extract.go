@@ -0,0 +1,115 @@
+package documents
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Document formats recognised by Detect
+const (
+	FormatPDF     = "pdf"
+	FormatDOCX    = "docx"
+	FormatXLSX    = "xlsx"
+	FormatHTML    = "html"
+	FormatText    = "text"
+	FormatUnknown = "unknown"
+)
+
+// ErrUnsupported is returned for formats we cannot extract text from
+var ErrUnsupported = errors.New("unsupported document format")
+
+// Detect identifies the format of data from its content, falling back to the
+// Content-Type header and file name
+func Detect(data []byte, contentType, name string) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return FormatPDF
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return FormatUnknown
+		}
+		for _, f := range zr.File {
+			switch {
+			case f.Name == "word/document.xml":
+				return FormatDOCX
+			case f.Name == "xl/workbook.xml":
+				return FormatXLSX
+			}
+		}
+		return FormatUnknown
+	}
+
+	sniffed := http.DetectContentType(data)
+	lowerName := strings.ToLower(name)
+	switch {
+	case strings.HasPrefix(sniffed, "text/html"), strings.Contains(contentType, "html"),
+		strings.HasSuffix(lowerName, ".html"), strings.HasSuffix(lowerName, ".htm"):
+		return FormatHTML
+	case strings.HasPrefix(sniffed, "text/plain") && utf8.Valid(data):
+		return FormatText
+	}
+	return FormatUnknown
+}
+
+// Extract returns the plain text of a document in the given format
+func Extract(data []byte, format string) (string, error) {
+	var text string
+	var err error
+	switch format {
+	case FormatPDF:
+		text, err = extractPDF(data)
+	case FormatDOCX:
+		text, err = extractDOCX(data)
+	case FormatXLSX:
+		text, err = extractXLSX(data)
+	case FormatHTML:
+		text, err = extractHTML(data)
+	case FormatText:
+		text = string(data)
+	default:
+		return "", ErrUnsupported
+	}
+	if err != nil {
+		return "", err
+	}
+	return normalizeSpace(text), nil
+}
+
+func extractHTML(data []byte) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	doc.Find("script, style, noscript, nav, header, footer").Remove()
+	doc.Find("p, div, br, li, tr, h1, h2, h3, h4, h5, h6").Each(func(_ int, s *goquery.Selection) {
+		s.AppendHtml("\n")
+	})
+	return doc.Find("body").Text(), nil
+}
+
+var (
+	spaceRun = regexp.MustCompile(`[ \t\f\v\x00]+`)
+	blankRun = regexp.MustCompile(`\n\s*\n\s*(\n\s*)+`)
+)
+
+// normalizeSpace collapses runs of spaces and blank lines and drops invalid UTF-8
+func normalizeSpace(s string) string {
+	s = strings.ToValidUTF8(s, "")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	s = spaceRun.ReplaceAllString(s, " ")
+	s = blankRun.ReplaceAllString(s, "\n\n")
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,199 @@
+package documents
+
+import (
+	"bd_bot/internal/repository"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// DefaultMaxBytes is the largest attachment the fetcher will download
+const DefaultMaxBytes = 50 << 20
+
+var extensions = map[string]string{
+	FormatPDF:  ".pdf",
+	FormatDOCX: ".docx",
+	FormatXLSX: ".xlsx",
+	FormatHTML: ".html",
+	FormatText: ".txt",
+}
+
+// Fetcher downloads solicitation attachments, stores them and records their text
+type Fetcher struct {
+	repo     *repository.DocumentRepository
+	store    *Store
+	client   *http.Client
+	MaxBytes int64
+}
+
+// Stats summarizes a fetch run
+type Stats struct {
+	Fetched int `json:"fetched"`
+	Failed  int `json:"failed"`
+}
+
+func NewFetcher(repo *repository.DocumentRepository, store *Store) *Fetcher {
+	return &Fetcher{
+		repo:     repo,
+		store:    store,
+		client:   newPublicClient(),
+		MaxBytes: DefaultMaxBytes,
+	}
+}
+
+// errNotPublic is returned for attachments on loopback, link-local and
+// private addresses. Their URLs come from scraped pages, which must not be
+// able to make the server reach its own network.
+var errNotPublic = errors.New("address is not public")
+
+// newPublicClient returns a client that only connects to public addresses.
+// The check runs on every connection, after DNS resolution, so it covers
+// redirects and hostnames that resolve to internal addresses. Proxies are not
+// used, since the check would then only see the proxy.
+func newPublicClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, Control: dialPublic}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: 2 * time.Minute, Transport: transport}
+}
+
+func dialPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublic(ip) {
+		return fmt.Errorf("%s: %w", host, errNotPublic)
+	}
+	return nil
+}
+
+// isPublic reports whether ip is routable on the internet
+func isPublic(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast()
+}
+
+// FetchPending downloads up to limit outstanding attachments. A solicitationID
+// of 0 considers every solicitation.
+func (f *Fetcher) FetchPending(ctx context.Context, solicitationID, limit int) (Stats, error) {
+	var stats Stats
+
+	pending, err := f.repo.Pending(ctx, solicitationID, limit)
+	if err != nil {
+		return stats, err
+	}
+	if len(pending) > 0 {
+		slog.Info("Fetching solicitation documents", "count", len(pending))
+	}
+
+	touched := make(map[int]bool)
+	seen := make(map[string]bool)
+	for _, p := range pending {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		key := fmt.Sprintf("%d %s", p.SolicitationID, p.URL)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		doc := f.fetch(ctx, p)
+		if err := f.repo.Save(ctx, doc); err != nil {
+			slog.Error("Failed to save document", "url", p.URL, "error", err)
+			stats.Failed++
+			continue
+		}
+		if doc.Status == "failed" {
+			slog.Warn("Failed to fetch document", "source_id", p.SourceID, "url", p.URL, "attempt", doc.Attempts, "error", doc.Error)
+			stats.Failed++
+			continue
+		}
+		stats.Fetched++
+		touched[p.SolicitationID] = true
+	}
+
+	for id := range touched {
+		if err := f.repo.RefreshText(ctx, id); err != nil {
+			slog.Error("Failed to index document text", "solicitation_id", id, "error", err)
+		}
+	}
+	return stats, nil
+}
+
+// fetch downloads and extracts one attachment. Failures are reported on the
+// returned document rather than as an error so they can be recorded and retried.
+// A panic in the extractors fails this document only, not the whole run.
+func (f *Fetcher) fetch(ctx context.Context, p repository.PendingDocument) (doc *repository.SolicitationDocument) {
+	doc = &repository.SolicitationDocument{SolicitationID: p.SolicitationID, URL: p.URL, Title: p.Title, Status: "failed"}
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Panic while reading document", "source_id", p.SourceID, "url", p.URL, "panic", r)
+			doc.Text = ""
+			doc.Error = fmt.Sprintf("failed to read document: %v", r)
+		}
+	}()
+
+	if u, err := url.Parse(p.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		doc.Error = "only http and https links are fetched"
+		return doc
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", p.URL, nil)
+	if err != nil {
+		doc.Error = err.Error()
+		return doc
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; JOSHUA document fetcher)")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		doc.Error = err.Error()
+		return doc
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		doc.Error = fmt.Sprintf("server returned %s", resp.Status)
+		return doc
+	}
+	doc.ContentType = resp.Header.Get("Content-Type")
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.MaxBytes+1))
+	if err != nil {
+		doc.Error = err.Error()
+		return doc
+	}
+	if int64(len(data)) > f.MaxBytes {
+		doc.Error = fmt.Sprintf("document exceeds %d MB", f.MaxBytes>>20)
+		return doc
+	}
+
+	doc.Format = Detect(data, doc.ContentType, p.URL)
+	sum, path, size, err := f.store.Put(bytes.NewReader(data), extensions[doc.Format], f.MaxBytes)
+	if err != nil {
+		doc.Error = fmt.Sprintf("failed to store document: %v", err)
+		return doc
+	}
+	doc.SHA256, doc.StoragePath, doc.SizeBytes = sum, path, size
+
+	// A document we can't read is still stored; it just contributes no text
+	doc.Status = "fetched"
+	text, err := Extract(data, doc.Format)
+	if err != nil {
+		doc.Error = err.Error()
+		return doc
+	}
+	doc.Text = text
+	return doc
+}
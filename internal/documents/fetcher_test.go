@@ -0,0 +1,91 @@
+package documents
+
+import (
+	"bd_bot/internal/repository"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"2001:4860:4860::8888", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false}, // cloud metadata
+		{"fe80::1", false},
+		{"0.0.0.0", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"224.0.0.1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := isPublic(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("isPublic(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchRefusesInternalURLs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr string
+	}{
+		{"loopback", server.URL, "not public"},
+		{"localhost", strings.Replace(server.URL, "127.0.0.1", "localhost", 1), "not public"},
+		{"file", "file:///etc/passwd", "only http and https"},
+		{"ftp", "ftp://example.com/sow.pdf", "only http and https"},
+	}
+	f := &Fetcher{store: NewStore(t.TempDir()), client: newPublicClient(), MaxBytes: DefaultMaxBytes}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := f.fetch(context.Background(), repository.PendingDocument{SolicitationID: 1, URL: tt.url})
+			if doc.Status != "failed" || !strings.Contains(doc.Error, tt.wantErr) {
+				t.Errorf("fetch(%s) = %s %q, want failed with %q", tt.url, doc.Status, doc.Error, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFetchStoresDocument(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body><p>Statement of work</p><script>alert(1)</script></body></html>"))
+	}))
+	defer server.Close()
+
+	root := t.TempDir()
+	// The test server is on loopback, so the public-only client is swapped out
+	f := &Fetcher{store: NewStore(root), client: server.Client(), MaxBytes: DefaultMaxBytes}
+	doc := f.fetch(context.Background(), repository.PendingDocument{SolicitationID: 1, URL: server.URL + "/sow"})
+	if doc.Status != "fetched" || doc.Format != FormatHTML {
+		t.Fatalf("fetch = %s %s %q, want a fetched HTML document", doc.Status, doc.Format, doc.Error)
+	}
+	if !strings.Contains(doc.Text, "Statement of work") {
+		t.Errorf("text = %q", doc.Text)
+	}
+	file, err := f.store.Open(doc.StoragePath)
+	if err != nil {
+		t.Fatalf("stored file: %v", err)
+	}
+	file.Close()
+}
@@ -0,0 +1,301 @@
+package documents
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxZipEntry bounds how much of one archive member we will decompress, to
+// guard against zip bombs
+const maxZipEntry = 64 << 20
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxZipEntry+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxZipEntry {
+		return nil, fmt.Errorf("%s is too large", f.Name)
+	}
+	return data, nil
+}
+
+// extractDOCX reads the main document part, emitting a line per paragraph and
+// a tab between table cells
+func extractDOCX(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+
+	var parts []string
+	for _, f := range zr.File {
+		// Body first, then headers, footers and footnotes
+		if f.Name == "word/document.xml" {
+			parts = append([]string{f.Name}, parts...)
+		} else if strings.HasPrefix(f.Name, "word/") && (strings.Contains(f.Name, "header") || strings.Contains(f.Name, "footer") || f.Name == "word/footnotes.xml") {
+			parts = append(parts, f.Name)
+		}
+	}
+
+	var out strings.Builder
+	for _, name := range parts {
+		for _, f := range zr.File {
+			if f.Name != name {
+				continue
+			}
+			xmlData, err := readZipFile(f)
+			if err != nil {
+				return "", err
+			}
+			if err := wordText(xmlData, &out); err != nil {
+				return "", fmt.Errorf("%s: %w", name, err)
+			}
+			out.WriteString("\n")
+		}
+	}
+	return out.String(), nil
+}
+
+func wordText(data []byte, out *strings.Builder) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				out.WriteString("\t")
+			case "br", "cr":
+				out.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				out.WriteString("\n")
+			case "tc":
+				out.WriteString("\t")
+			}
+		case xml.CharData:
+			if inText {
+				out.Write(t)
+			}
+		}
+	}
+}
+
+// extractXLSX emits one line per row with tab-separated cell values, prefixed
+// by the sheet name
+func extractXLSX(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		xmlData, err := readZipFile(f)
+		if err != nil {
+			return "", err
+		}
+		if shared, err = sharedStrings(xmlData); err != nil {
+			return "", fmt.Errorf("shared strings: %w", err)
+		}
+	}
+
+	names := sheetNames(files)
+	var sheets []string
+	for name := range files {
+		if strings.HasPrefix(name, "xl/worksheets/") && strings.HasSuffix(name, ".xml") {
+			sheets = append(sheets, name)
+		}
+	}
+	sort.Slice(sheets, func(i, j int) bool { return sheetIndex(sheets[i]) < sheetIndex(sheets[j]) })
+
+	var out strings.Builder
+	for _, path := range sheets {
+		xmlData, err := readZipFile(files[path])
+		if err != nil {
+			return "", err
+		}
+		if name, ok := names[path]; ok {
+			out.WriteString(name + "\n")
+		}
+		if err := sheetText(xmlData, shared, &out); err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+		out.WriteString("\n")
+	}
+	return out.String(), nil
+}
+
+func sheetIndex(path string) int {
+	base := strings.TrimSuffix(strings.TrimPrefix(path, "xl/worksheets/sheet"), ".xml")
+	n, err := strconv.Atoi(base)
+	if err != nil {
+		return 1 << 30
+	}
+	return n
+}
+
+// sheetNames maps worksheet part paths to the names shown on their tabs
+func sheetNames(files map[string]*zip.File) map[string]string {
+	names := make(map[string]string)
+	wb, ok1 := files["xl/workbook.xml"]
+	rels, ok2 := files["xl/_rels/workbook.xml.rels"]
+	if !ok1 || !ok2 {
+		return names
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var relationships struct {
+		Rels []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if data, err := readZipFile(wb); err != nil || xml.Unmarshal(data, &workbook) != nil {
+		return names
+	}
+	if data, err := readZipFile(rels); err != nil || xml.Unmarshal(data, &relationships) != nil {
+		return names
+	}
+
+	targets := make(map[string]string)
+	for _, r := range relationships.Rels {
+		target := strings.TrimPrefix(r.Target, "/xl/")
+		targets[r.ID] = "xl/" + strings.TrimPrefix(target, "/")
+	}
+	for _, s := range workbook.Sheets {
+		if path, ok := targets[s.RID]; ok {
+			names[path] = s.Name
+		}
+	}
+	return names
+}
+
+func sharedStrings(data []byte) ([]string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var out []string
+	var cur strings.Builder
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				cur.Reset()
+			case "t":
+				inText = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				out = append(out, cur.String())
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				cur.Write(t)
+			}
+		}
+	}
+}
+
+func sheetText(data []byte, shared []string, out *strings.Builder) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var cellType string
+	var value strings.Builder
+	inValue := false
+	var row []string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				row = row[:0]
+			case "c":
+				cellType = ""
+				for _, a := range t.Attr {
+					if a.Name.Local == "t" {
+						cellType = a.Value
+					}
+				}
+				value.Reset()
+			case "v", "t":
+				inValue = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				v := value.String()
+				if cellType == "s" {
+					if i, err := strconv.Atoi(v); err == nil && i >= 0 && i < len(shared) {
+						v = shared[i]
+					}
+				}
+				if v != "" {
+					row = append(row, v)
+				}
+			case "row":
+				if len(row) > 0 {
+					out.WriteString(strings.Join(row, "\t"))
+					out.WriteString("\n")
+				}
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package documents
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestExtractOffice(t *testing.T) {
+	tests := []struct {
+		file   string
+		format string
+		want   string
+	}{
+		// Body before headers; table cells on their own lines
+		{"sample.docx", FormatDOCX, "Statement of Work\nDeliver a prototype\nCLIN\n0001\n\nCUI"},
+		// Sheets in tab order under their names, shared and inline strings resolved
+		{"sample.xlsx", FormatXLSX, "Pricing\nItem Price\nSensor kit 1250\n\nSchedule\nKickoff"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile("testdata/" + tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format := Detect(data, "", tt.file); format != tt.format {
+				t.Fatalf("Detect = %q, want %q", format, tt.format)
+			}
+			got, err := Extract(data, tt.format)
+			if err != nil {
+				t.Fatalf("Extract: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Extract = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractOfficeErrors(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("word/document.xml")
+	w.Write([]byte("<w:document><w:body><w:p>"))
+	zw.Close()
+
+	tests := []struct {
+		name   string
+		data   []byte
+		format string
+	}{
+		{"not a zip", []byte("PK\x03\x04garbage"), FormatDOCX},
+		{"malformed xml", buf.Bytes(), FormatDOCX},
+		{"not a zip xlsx", []byte("hello"), FormatXLSX},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Extract(tt.data, tt.format); err == nil {
+				t.Error("Extract succeeded, want an error")
+			}
+		})
+	}
+}
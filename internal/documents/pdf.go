@@ -0,0 +1,849 @@
+package documents
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// The PDF reader below is deliberately small: it locates objects by scanning
+// for "N G obj" (plus compressed object streams) instead of trusting the xref
+// table, walks the page tree, and interprets the text operators of each page's
+// content stream. Fonts with a ToUnicode CMap are decoded through it; simple
+// fonts without one are assumed to use WinAnsi. Text drawn inside form
+// XObjects and scanned (image-only) pages are not extracted.
+
+// maxStreamSize bounds a single decoded stream
+const maxStreamSize = 64 << 20
+
+type (
+	pdfName   string
+	pdfString []byte
+	pdfArray  []interface{}
+	pdfDict   map[pdfName]interface{}
+	keyword   string
+	pdfRef    struct{ num, gen int }
+	pdfStream struct {
+		dict pdfDict
+		raw  []byte
+	}
+)
+
+// lexer tokenizes PDF objects and content streams
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelim(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFSpace(c) {
+			l.pos++
+		} else if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		} else {
+			return
+		}
+	}
+}
+
+func (l *lexer) next() (interface{}, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+	c := l.data[l.pos]
+	switch c {
+	case '(':
+		return l.literalString(), nil
+	case '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return keyword("<<"), nil
+		}
+		return l.hexString(), nil
+	case '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return keyword(">>"), nil
+		}
+		l.pos++
+		return keyword(">"), nil
+	case '[', ']', '{', '}', ')':
+		l.pos++
+		return keyword(string(c)), nil
+	case '/':
+		l.pos++
+		return pdfName(l.regular()), nil
+	}
+
+	word := l.regular()
+	if word == "" {
+		l.pos++
+		return keyword(string(c)), nil
+	}
+	if (word[0] >= '0' && word[0] <= '9') || word[0] == '-' || word[0] == '+' || word[0] == '.' {
+		if f, err := strconv.ParseFloat(word, 64); err == nil {
+			return f, nil
+		}
+	}
+	return keyword(word), nil
+}
+
+// regular reads a run of regular characters, decoding #xx escapes
+func (l *lexer) regular() string {
+	var b strings.Builder
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFSpace(c) || isPDFDelim(c) {
+			break
+		}
+		if c == '#' && l.pos+2 < len(l.data) {
+			if v, err := strconv.ParseUint(string(l.data[l.pos+1:l.pos+3]), 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				l.pos += 3
+				continue
+			}
+		}
+		b.WriteByte(c)
+		l.pos++
+	}
+	return b.String()
+}
+
+func (l *lexer) literalString() pdfString {
+	l.pos++ // (
+	var b []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return b
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return b
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+func (l *lexer) hexString() pdfString {
+	l.pos++ // <
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++ // >
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, hex.DecodedLen(len(digits)))
+	n, _ := hex.Decode(out, digits)
+	return out[:n]
+}
+
+// readObject parses one object, resolving "N G R" into a pdfRef
+func (l *lexer) readObject() (interface{}, error) {
+	tok, err := l.next()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case keyword:
+		switch t {
+		case "[":
+			var arr pdfArray
+			for {
+				v, err := l.readObject()
+				if err != nil {
+					return nil, err
+				}
+				if v == keyword("]") {
+					return arr, nil
+				}
+				arr = append(arr, v)
+			}
+		case "<<":
+			dict := pdfDict{}
+			for {
+				k, err := l.readObject()
+				if err != nil {
+					return nil, err
+				}
+				if k == keyword(">>") {
+					return dict, nil
+				}
+				v, err := l.readObject()
+				if err != nil {
+					return nil, err
+				}
+				if name, ok := k.(pdfName); ok {
+					dict[name] = v
+				}
+			}
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	case float64:
+		if t == float64(int(t)) && t >= 0 {
+			save := l.pos
+			if gen, err := l.next(); err == nil {
+				if g, ok := gen.(float64); ok && g == float64(int(g)) {
+					if r, err := l.next(); err == nil && r == keyword("R") {
+						return pdfRef{num: int(t), gen: int(g)}, nil
+					}
+				}
+			}
+			l.pos = save
+		}
+	}
+	return tok, nil
+}
+
+type pdfFile struct {
+	objects map[int]interface{}
+}
+
+var objHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+func parsePDF(data []byte) *pdfFile {
+	f := &pdfFile{objects: make(map[int]interface{})}
+	skipUntil := 0 // headers inside stream data are not objects
+	for _, m := range objHeader.FindAllSubmatchIndex(data, -1) {
+		if m[0] < skipUntil || (m[0] > 0 && !isPDFSpace(data[m[0]-1]) && !isPDFDelim(data[m[0]-1])) {
+			continue
+		}
+		num, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+		l := &lexer{data: data, pos: m[1]}
+		v, err := l.readObject()
+		if err != nil {
+			continue
+		}
+		if dict, ok := v.(pdfDict); ok {
+			if s := readStreamBody(l, dict); s != nil {
+				v = s
+				skipUntil = l.pos
+			}
+		}
+		// Later definitions win, matching incremental updates
+		f.objects[num] = v
+	}
+
+	for _, v := range f.objects {
+		if s, ok := v.(*pdfStream); ok && s.dict["Type"] == pdfName("ObjStm") {
+			f.loadObjectStream(s)
+		}
+	}
+	return f
+}
+
+func readStreamBody(l *lexer, dict pdfDict) *pdfStream {
+	l.skipSpace()
+	if !bytes.HasPrefix(l.data[l.pos:], []byte("stream")) {
+		return nil
+	}
+	start := l.pos + len("stream")
+	if start < len(l.data) && l.data[start] == '\r' {
+		start++
+	}
+	if start < len(l.data) && l.data[start] == '\n' {
+		start++
+	}
+
+	// /Length is untrusted: check it fits before converting, so a huge value
+	// cannot overflow int
+	if n, ok := dict["Length"].(float64); ok && n >= 0 && n <= float64(len(l.data)-start) {
+		end := start + int(n)
+		if bytes.Contains(l.data[end:min(end+32, len(l.data))], []byte("endstream")) {
+			l.pos = end
+			return &pdfStream{dict: dict, raw: l.data[start:end]}
+		}
+	}
+	end := bytes.Index(l.data[start:], []byte("endstream"))
+	if end < 0 {
+		return nil
+	}
+	l.pos = start + end
+	return &pdfStream{dict: dict, raw: bytes.TrimRight(l.data[start:start+end], "\r\n")}
+}
+
+func (f *pdfFile) loadObjectStream(s *pdfStream) {
+	data, err := f.decode(s)
+	if err != nil {
+		return
+	}
+	n, _ := s.dict["N"].(float64)
+	first, _ := s.dict["First"].(float64)
+	l := &lexer{data: data}
+	type entry struct{ num, off int }
+	var entries []entry
+	for i := 0; i < int(n); i++ {
+		a, err1 := l.next()
+		b, err2 := l.next()
+		num, ok1 := a.(float64)
+		off, ok2 := b.(float64)
+		if err1 != nil || err2 != nil || !ok1 || !ok2 {
+			return
+		}
+		entries = append(entries, entry{int(num), int(off)})
+	}
+	for _, e := range entries {
+		if _, exists := f.objects[e.num]; exists {
+			continue
+		}
+		pos := int(first) + e.off
+		if pos < 0 || pos >= len(data) {
+			continue
+		}
+		obj := &lexer{data: data, pos: pos}
+		if v, err := obj.readObject(); err == nil {
+			f.objects[e.num] = v
+		}
+	}
+}
+
+func (f *pdfFile) resolve(v interface{}) interface{} {
+	for i := 0; i < 32; i++ {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		v = f.objects[ref.num]
+	}
+	return nil
+}
+
+func (f *pdfFile) dict(v interface{}) pdfDict {
+	switch t := f.resolve(v).(type) {
+	case pdfDict:
+		return t
+	case *pdfStream:
+		return t.dict
+	}
+	return nil
+}
+
+// decode applies the stream's filters
+func (f *pdfFile) decode(s *pdfStream) ([]byte, error) {
+	var filters []pdfName
+	switch v := f.resolve(s.dict["Filter"]).(type) {
+	case pdfName:
+		filters = []pdfName{v}
+	case pdfArray:
+		for _, item := range v {
+			if name, ok := f.resolve(item).(pdfName); ok {
+				filters = append(filters, name)
+			}
+		}
+	}
+
+	data := s.raw
+	for _, filter := range filters {
+		switch filter {
+		case "FlateDecode", "Fl":
+			zr, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			// Keep whatever decompressed before a corrupt tail
+			out, err := io.ReadAll(io.LimitReader(zr, maxStreamSize))
+			if err != nil && len(out) == 0 {
+				return nil, err
+			}
+			data = out
+		case "ASCIIHexDecode", "AHx":
+			l := &lexer{data: append([]byte("<"), data...)}
+			data = l.hexString()
+		case "ASCII85Decode", "A85":
+			trimmed := bytes.TrimSuffix(bytes.TrimSpace(data), []byte("~>"))
+			out := make([]byte, len(trimmed)*4/5+4)
+			n, _, err := ascii85.Decode(out, trimmed, true)
+			if err != nil {
+				return nil, err
+			}
+			data = out[:n]
+		default:
+			return nil, fmt.Errorf("unsupported filter %s", filter)
+		}
+	}
+	return data, nil
+}
+
+// pages returns page dictionaries in document order
+func (f *pdfFile) pages() []pdfDict {
+	var pages []pdfDict
+	var walk func(node pdfDict, depth int)
+	walk = func(node pdfDict, depth int) {
+		// The depth limit also stops cycles in malformed page trees
+		if node == nil || depth > 64 {
+			return
+		}
+		switch node["Type"] {
+		case pdfName("Page"):
+			pages = append(pages, node)
+		default:
+			kids, _ := f.resolve(node["Kids"]).(pdfArray)
+			for _, kid := range kids {
+				walk(f.dict(kid), depth+1)
+			}
+		}
+	}
+
+	nums := make([]int, 0, len(f.objects))
+	for num := range f.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		if d := f.dict(f.objects[num]); d != nil && d["Type"] == pdfName("Catalog") {
+			walk(f.dict(d["Pages"]), 0)
+			if len(pages) > 0 {
+				return pages
+			}
+		}
+	}
+
+	// No usable page tree: fall back to every page object in object order
+	for _, num := range nums {
+		if d, ok := f.resolve(f.objects[num]).(pdfDict); ok && d["Type"] == pdfName("Page") {
+			pages = append(pages, d)
+		}
+	}
+	return pages
+}
+
+// inherited looks up a page attribute, following /Parent
+func (f *pdfFile) inherited(page pdfDict, key pdfName) interface{} {
+	for i := 0; page != nil && i < 64; i++ {
+		if v, ok := page[key]; ok {
+			return v
+		}
+		page = f.dict(page["Parent"])
+	}
+	return nil
+}
+
+func (f *pdfFile) pageContent(page pdfDict) []byte {
+	var streams []interface{}
+	switch c := f.resolve(page["Contents"]).(type) {
+	case *pdfStream:
+		streams = append(streams, c)
+	case pdfArray:
+		streams = c
+	}
+	var out []byte
+	for _, s := range streams {
+		if stream, ok := f.resolve(s).(*pdfStream); ok {
+			if data, err := f.decode(stream); err == nil {
+				out = append(out, data...)
+				out = append(out, '\n')
+			}
+		}
+	}
+	return out
+}
+
+func extractPDF(data []byte) (string, error) {
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return "", errors.New("encrypted PDFs are not supported")
+	}
+	f := parsePDF(data)
+	pages := f.pages()
+	if len(pages) == 0 {
+		return "", errors.New("no pages found")
+	}
+
+	var out strings.Builder
+	fonts := make(map[interface{}]*fontDecoder)
+	for _, page := range pages {
+		resources := f.dict(f.inherited(page, "Resources"))
+		pageFonts := make(map[pdfName]*fontDecoder)
+		for name, ref := range f.dict(resources["Font"]) {
+			key := ref
+			if _, isRef := ref.(pdfRef); !isRef {
+				key = name // direct font dictionaries are not shared between pages
+			}
+			dec, ok := fonts[key]
+			if !ok {
+				dec = f.fontDecoder(f.dict(ref))
+				if _, isRef := ref.(pdfRef); isRef {
+					fonts[key] = dec
+				}
+			}
+			pageFonts[name] = dec
+		}
+		showText(f.pageContent(page), pageFonts, &out)
+		out.WriteString("\n\n")
+	}
+	return out.String(), nil
+}
+
+// showText interprets the text operators of a content stream
+func showText(content []byte, fonts map[pdfName]*fontDecoder, out *strings.Builder) {
+	l := &lexer{data: content}
+	var operands []interface{}
+	font := simpleFont
+	lastY := 0.0
+
+	for {
+		v, err := l.readObject()
+		if err != nil {
+			return
+		}
+		op, isOp := v.(keyword)
+		if !isOp {
+			operands = append(operands, v)
+			continue
+		}
+
+		switch op {
+		case "BI":
+			// Skip inline image data up to an EI delimited by whitespace
+			for from := l.pos; ; {
+				k := bytes.Index(l.data[from:], []byte("EI"))
+				if k < 0 {
+					return
+				}
+				j := from + k
+				if isPDFSpace(l.data[j-1]) && (j+2 == len(l.data) || isPDFSpace(l.data[j+2])) {
+					l.pos = j + 2
+					break
+				}
+				from = j + 2
+			}
+		case "Tf":
+			if len(operands) >= 2 {
+				if name, ok := operands[len(operands)-2].(pdfName); ok {
+					if dec, ok := fonts[name]; ok && dec != nil {
+						font = dec
+					} else {
+						font = simpleFont
+					}
+				}
+			}
+		case "Tj":
+			if len(operands) >= 1 {
+				if s, ok := operands[len(operands)-1].(pdfString); ok {
+					out.WriteString(font.decode(s))
+				}
+			}
+		case "'", "\"":
+			out.WriteString("\n")
+			if len(operands) >= 1 {
+				if s, ok := operands[len(operands)-1].(pdfString); ok {
+					out.WriteString(font.decode(s))
+				}
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				if arr, ok := operands[len(operands)-1].(pdfArray); ok {
+					for _, item := range arr {
+						switch t := item.(type) {
+						case pdfString:
+							out.WriteString(font.decode(t))
+						case float64:
+							// Large negative kerning is a word gap
+							if t < -200 {
+								out.WriteString(" ")
+							}
+						}
+					}
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				tx, _ := operands[len(operands)-2].(float64)
+				ty, _ := operands[len(operands)-1].(float64)
+				if ty != 0 {
+					out.WriteString("\n")
+				} else if tx != 0 {
+					out.WriteString(" ")
+				}
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				y, _ := operands[len(operands)-1].(float64)
+				if y != lastY {
+					out.WriteString("\n")
+				} else {
+					out.WriteString(" ")
+				}
+				lastY = y
+			}
+		case "T*":
+			out.WriteString("\n")
+		case "ET":
+			out.WriteString(" ")
+		}
+		operands = operands[:0]
+	}
+}
+
+// fontDecoder maps character codes of one font to text
+type fontDecoder struct {
+	cmap     map[string]string
+	codeLens []int // byte lengths of codes, longest first
+	twoByte  bool  // a composite font without a usable ToUnicode map
+}
+
+var simpleFont = &fontDecoder{}
+
+func (f *pdfFile) fontDecoder(font pdfDict) *fontDecoder {
+	if font == nil {
+		return simpleFont
+	}
+	dec := &fontDecoder{}
+	if s, ok := f.resolve(font["ToUnicode"]).(*pdfStream); ok {
+		if data, err := f.decode(s); err == nil {
+			dec.cmap, dec.codeLens = parseCMap(data)
+		}
+	}
+	if font["Subtype"] == pdfName("Type0") && len(dec.cmap) == 0 {
+		dec.twoByte = true
+	}
+	if len(dec.cmap) == 0 && !dec.twoByte {
+		return simpleFont
+	}
+	return dec
+}
+
+func (d *fontDecoder) decode(s pdfString) string {
+	if d.twoByte {
+		// Without a ToUnicode map, glyph IDs can't be turned into text
+		return ""
+	}
+	if len(d.cmap) == 0 {
+		return winAnsi(s)
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		matched := false
+		for _, n := range d.codeLens {
+			if i+n <= len(s) {
+				if text, ok := d.cmap[string(s[i:i+n])]; ok {
+					b.WriteString(text)
+					i += n
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			i += d.codeLens[len(d.codeLens)-1]
+		}
+	}
+	return b.String()
+}
+
+// parseCMap reads bfchar and bfrange mappings from a ToUnicode CMap
+func parseCMap(data []byte) (map[string]string, []int) {
+	cmap := make(map[string]string)
+	lens := make(map[int]bool)
+	l := &lexer{data: data}
+	var operands []interface{}
+	mode := ""
+	for {
+		v, err := l.readObject()
+		if err != nil {
+			break
+		}
+		if kw, ok := v.(keyword); ok && kw != "[" {
+			switch kw {
+			case "beginbfchar", "beginbfrange", "begincodespacerange":
+				mode = string(kw)
+			case "endbfchar":
+				for i := 0; i+1 < len(operands); i += 2 {
+					src, ok1 := operands[i].(pdfString)
+					dst, ok2 := operands[i+1].(pdfString)
+					if ok1 && ok2 && len(src) > 0 {
+						cmap[string(src)] = utf16BE(dst)
+						lens[len(src)] = true
+					}
+				}
+				mode = ""
+			case "endbfrange":
+				for i := 0; i+2 < len(operands); i += 3 {
+					lo, ok1 := operands[i].(pdfString)
+					hi, ok2 := operands[i+1].(pdfString)
+					if !ok1 || !ok2 || len(lo) == 0 || len(lo) != len(hi) || len(lo) > 4 {
+						continue
+					}
+					start, end := beInt(lo), beInt(hi)
+					if end < start || end-start > 0xFFFF {
+						continue
+					}
+					lens[len(lo)] = true
+					switch dst := operands[i+2].(type) {
+					case pdfString:
+						base := []byte(dst)
+						for code := start; code <= end; code++ {
+							cmap[string(beBytes(code, len(lo)))] = utf16BE(base)
+							base = incrementLast(base)
+						}
+					case pdfArray:
+						for j, item := range dst {
+							if s, ok := item.(pdfString); ok && start+j <= end {
+								cmap[string(beBytes(start+j, len(lo)))] = utf16BE(s)
+							}
+						}
+					}
+				}
+				mode = ""
+			case "endcodespacerange":
+				for i := 0; i+1 < len(operands); i += 2 {
+					if lo, ok := operands[i].(pdfString); ok && len(lo) > 0 {
+						lens[len(lo)] = true
+					}
+				}
+				mode = ""
+			}
+			if mode == "" || strings.HasPrefix(string(kw), "begin") {
+				operands = operands[:0]
+			}
+			continue
+		}
+		if mode != "" {
+			operands = append(operands, v)
+		}
+	}
+
+	var codeLens []int
+	for n := range lens {
+		codeLens = append(codeLens, n)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(codeLens)))
+	if len(codeLens) == 0 {
+		codeLens = []int{1}
+	}
+	return cmap, codeLens
+}
+
+func beInt(b []byte) int {
+	n := 0
+	for _, c := range b {
+		n = n<<8 | int(c)
+	}
+	return n
+}
+
+func beBytes(n, size int) []byte {
+	out := make([]byte, size)
+	for i := size - 1; i >= 0; i-- {
+		out[i] = byte(n)
+		n >>= 8
+	}
+	return out
+}
+
+func incrementLast(b []byte) []byte {
+	out := append([]byte(nil), b...)
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i]++
+		if out[i] != 0 {
+			break
+		}
+	}
+	return out
+}
+
+func utf16BE(b []byte) string {
+	if len(b)%2 == 1 {
+		b = append(b, 0)
+	}
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+	}
+	return string(utf16.Decode(units))
+}
+
+// winAnsiHigh maps the 0x80-0x9F range of Windows-1252; other bytes are Latin-1
+var winAnsiHigh = [32]rune{
+	'€', 0, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0, 'Ž', 0,
+	0, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0, 'ž', 'Ÿ',
+}
+
+func winAnsi(s []byte) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch {
+		case c >= 0x80 && c <= 0x9F:
+			if r := winAnsiHigh[c-0x80]; r != 0 {
+				b.WriteRune(r)
+			}
+		case c < 0x20 && c != '\n' && c != '\t':
+			// control codes carry no text in simple fonts
+		default:
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package documents
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExtractPDF(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"simple.pdf", "Radar signal processing\nBroad Agency Announcement\n\nProposals due June 1"},
+		{"tounicode.pdf", "Hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile("testdata/" + tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format := Detect(data, "", tt.file); format != FormatPDF {
+				t.Fatalf("Detect = %q, want %q", format, FormatPDF)
+			}
+			got, err := Extract(data, FormatPDF)
+			if err != nil {
+				t.Fatalf("Extract: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Extract = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// minimalPDF is a one-page PDF whose content stream declares the given /Length
+func minimalPDF(length string) []byte {
+	content := "BT /F1 12 Tf (Hello) Tj ET"
+	if length == "" {
+		length = fmt.Sprint(len(content))
+	}
+	return []byte("%PDF-1.4\n" +
+		"1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n" +
+		"2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj\n" +
+		"3 0 obj << /Type /Page /Parent 2 0 R /Contents 4 0 R >> endobj\n" +
+		"4 0 obj << /Length " + length + " >>\nstream\n" + content + "\nendstream\nendobj\n" +
+		"%%EOF\n")
+}
+
+func TestExtractPDFStreamLength(t *testing.T) {
+	// A /Length that is wrong, negative or too large to fit in an int must
+	// fall back to scanning for endstream
+	for _, length := range []string{"", "3", "-5", "1000000", "1e300", "9223372036854775807"} {
+		t.Run(length, func(t *testing.T) {
+			got, err := Extract(minimalPDF(length), FormatPDF)
+			if err != nil {
+				t.Fatalf("Extract: %v", err)
+			}
+			if got != "Hello" {
+				t.Errorf("Extract = %q, want %q", got, "Hello")
+			}
+		})
+	}
+}
+
+func TestExtractPDFErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"no pages", "%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n", "no pages"},
+		{"encrypted", "%PDF-1.4\ntrailer << /Encrypt 5 0 R >>\n", "encrypted"},
+		{"truncated", "%PDF-1.4\n1 0 obj << /Type /Pa", "no pages"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Extract([]byte(tt.data), FormatPDF)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Extract error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func FuzzExtractPDF(f *testing.F) {
+	for _, name := range []string{"simple.pdf", "tounicode.pdf"} {
+		data, err := os.ReadFile("testdata/" + name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add(minimalPDF("9223372036854775807"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Anything may fail, but nothing may panic
+		Extract(data, FormatPDF)
+	})
+}
@@ -0,0 +1,65 @@
+package documents
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Store keeps files under Root, named by the SHA-256 of their content, so the
+// same attachment linked from several solicitations is stored once
+type Store struct {
+	Root string
+}
+
+func NewStore(root string) *Store {
+	return &Store{Root: root}
+}
+
+// Put copies r into the store, reading at most maxBytes. It returns the hex
+// digest, the path relative to Root and the number of bytes written.
+func (s *Store) Put(r io.Reader, ext string, maxBytes int64) (string, string, int64, error) {
+	if err := os.MkdirAll(s.Root, 0755); err != nil {
+		return "", "", 0, err
+	}
+	tmp, err := os.CreateTemp(s.Root, ".incoming-*")
+	if err != nil {
+		return "", "", 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return "", "", 0, err
+	}
+	if n > maxBytes {
+		return "", "", 0, fmt.Errorf("document exceeds %d bytes", maxBytes)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", "", 0, err
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	rel := filepath.Join(sum[:2], sum+ext)
+	dst := filepath.Join(s.Root, rel)
+	if _, err := os.Stat(dst); err == nil {
+		return sum, rel, n, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return "", "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return "", "", 0, err
+	}
+	return sum, rel, n, nil
+}
+
+// Open returns the stored file at rel
+func (s *Store) Open(rel string) (*os.File, error) {
+	return os.Open(filepath.Join(s.Root, rel))
+}
@@ -0,0 +1,297 @@
+package mail
+
+import (
+	"bd_bot/internal/repository"
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTP is an in-process SMTP server. Each message is answered with the
+// next reply in dataReplies, then "250 OK" once they run out.
+type fakeSMTP struct {
+	ln net.Listener
+
+	mu          sync.Mutex
+	dataReplies []string
+	received    []receivedMail
+}
+
+type receivedMail struct {
+	from, to, data string
+}
+
+func newFakeSMTP(t *testing.T, dataReplies ...string) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTP{ln: ln, dataReplies: dataReplies}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	tc := textproto.NewConn(conn)
+	tc.PrintfLine("220 fake ESMTP")
+
+	var msg receivedMail
+	for {
+		line, err := tc.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tc.PrintfLine("250 fake")
+		case "MAIL":
+			msg = receivedMail{from: strings.TrimPrefix(arg, "FROM:")}
+			tc.PrintfLine("250 OK")
+		case "RCPT":
+			msg.to = strings.TrimPrefix(arg, "TO:")
+			tc.PrintfLine("250 OK")
+		case "DATA":
+			tc.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(tc.DotReader())
+			if err != nil {
+				return
+			}
+			msg.data = string(data)
+			tc.PrintfLine("%s", s.accept(msg))
+		case "RSET", "NOOP":
+			tc.PrintfLine("250 OK")
+		case "QUIT":
+			tc.PrintfLine("221 Bye")
+			return
+		default:
+			tc.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// accept records msg unless the next queued reply rejects it, and returns the reply
+func (s *fakeSMTP) accept(msg receivedMail) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reply := "250 OK"
+	if len(s.dataReplies) > 0 {
+		reply, s.dataReplies = s.dataReplies[0], s.dataReplies[1:]
+	}
+	if strings.HasPrefix(reply, "2") {
+		s.received = append(s.received, msg)
+	}
+	return reply
+}
+
+func (s *fakeSMTP) messages() []receivedMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedMail(nil), s.received...)
+}
+
+func (s *fakeSMTP) sender() *SMTPSender {
+	addr := s.ln.Addr().(*net.TCPAddr)
+	return &SMTPSender{Host: "127.0.0.1", Port: addr.Port, TLSMode: "none", From: "joshua@example.com", Timeout: 5 * time.Second}
+}
+
+// memoryStore is an outboxStore that keeps messages in memory and treats a
+// message as due once now reaches its next attempt
+type memoryStore struct {
+	now      time.Time
+	messages []repository.OutboxMessage
+}
+
+func (m *memoryStore) Enqueue(ctx context.Context, to, subject, textBody, htmlBody string) (int, error) {
+	id := len(m.messages) + 1
+	m.messages = append(m.messages, repository.OutboxMessage{
+		ID: id, To: to, Subject: subject, TextBody: textBody, HTMLBody: htmlBody,
+		Status: "pending", NextAttemptAt: m.now,
+	})
+	return id, nil
+}
+
+func (m *memoryStore) ClaimDue(ctx context.Context, limit int) ([]repository.OutboxMessage, error) {
+	var due []repository.OutboxMessage
+	for i := range m.messages {
+		msg := &m.messages[i]
+		if msg.Status == "pending" && !msg.NextAttemptAt.After(m.now) && len(due) < limit {
+			msg.Status = "sending"
+			msg.Attempts++
+			due = append(due, *msg)
+		}
+	}
+	return due, nil
+}
+
+func (m *memoryStore) MarkSent(ctx context.Context, id int) error {
+	m.messages[id-1].Status = "sent"
+	m.messages[id-1].LastError = ""
+	return nil
+}
+
+func (m *memoryStore) MarkFailed(ctx context.Context, id int, errText string, retryAt *time.Time) error {
+	msg := &m.messages[id-1]
+	msg.LastError = errText
+	if retryAt == nil {
+		msg.Status = "failed"
+		return nil
+	}
+	msg.Status = "pending"
+	// Keep the backoff but measure it from the store's clock
+	msg.NextAttemptAt = m.now.Add(time.Until(*retryAt))
+	return nil
+}
+
+func TestOutboxDelivers(t *testing.T) {
+	server := newFakeSMTP(t)
+	store := &memoryStore{now: time.Now()}
+	outbox := &Outbox{repo: store, sender: server.sender()}
+	ctx := context.Background()
+
+	if err := outbox.Enqueue(ctx, Message{To: "jdoe@example.com", Subject: "Your digest", Text: "3 new matches", HTML: "<p>3 new matches</p>"}); err != nil {
+		t.Fatal(err)
+	}
+	sent, err := outbox.Flush(ctx)
+	if err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if sent != 1 {
+		t.Fatalf("Flush() sent %d, want 1", sent)
+	}
+	if got := store.messages[0].Status; got != "sent" {
+		t.Errorf("status = %q, want sent", got)
+	}
+
+	received := server.messages()
+	if len(received) != 1 {
+		t.Fatalf("server received %d messages, want 1", len(received))
+	}
+	m := received[0]
+	if m.from != "<joshua@example.com>" || m.to != "<jdoe@example.com>" {
+		t.Errorf("envelope from %s to %s", m.from, m.to)
+	}
+	header, err := textproto.NewReader(bufio.NewReader(strings.NewReader(m.data))).ReadMIMEHeader()
+	if err != nil {
+		t.Fatalf("invalid message header: %v", err)
+	}
+	if got := header.Get("Subject"); got != "Your digest" {
+		t.Errorf("Subject = %q", got)
+	}
+	if !strings.HasPrefix(header.Get("Content-Type"), "multipart/alternative") {
+		t.Errorf("Content-Type = %q, want multipart/alternative", header.Get("Content-Type"))
+	}
+	if !strings.Contains(m.data, "3 new matches") || !strings.Contains(m.data, "<p>3 new matches</p>") {
+		t.Errorf("message is missing a body part:\n%s", m.data)
+	}
+
+	// Nothing is left to deliver
+	if sent, _ := outbox.Flush(ctx); sent != 0 {
+		t.Errorf("second Flush() sent %d, want 0", sent)
+	}
+}
+
+func TestOutboxRetriesAfterTemporaryFailure(t *testing.T) {
+	server := newFakeSMTP(t, "451 4.3.0 Mailbox temporarily unavailable")
+	store := &memoryStore{now: time.Now()}
+	outbox := &Outbox{repo: store, sender: server.sender()}
+	ctx := context.Background()
+
+	if err := outbox.Send(ctx, "jdoe@example.com", "Reset your password", "Follow the link"); err != nil {
+		t.Fatal(err)
+	}
+
+	sent, err := outbox.Flush(ctx)
+	if err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if sent != 0 {
+		t.Fatalf("Flush() sent %d despite the 451, want 0", sent)
+	}
+	msg := store.messages[0]
+	if msg.Status != "pending" || msg.Attempts != 1 {
+		t.Fatalf("after failure: status %q, attempts %d; want pending, 1", msg.Status, msg.Attempts)
+	}
+	if !strings.Contains(msg.LastError, "451") {
+		t.Errorf("last error %q does not mention the 451 reply", msg.LastError)
+	}
+	wantRetry := store.now.Add(backoff(1))
+	if d := msg.NextAttemptAt.Sub(wantRetry); d < -time.Second || d > time.Second {
+		t.Errorf("next attempt at %v, want about %v", msg.NextAttemptAt, wantRetry)
+	}
+
+	// Not retried before the backoff has passed
+	if sent, _ := outbox.Flush(ctx); sent != 0 {
+		t.Fatalf("Flush() before the backoff sent %d, want 0", sent)
+	}
+
+	store.now = store.now.Add(backoff(1) + time.Second)
+	sent, err = outbox.Flush(ctx)
+	if err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if sent != 1 {
+		t.Fatalf("retry sent %d, want 1", sent)
+	}
+	if msg := store.messages[0]; msg.Status != "sent" || msg.Attempts != 2 {
+		t.Errorf("after retry: status %q, attempts %d; want sent, 2", msg.Status, msg.Attempts)
+	}
+	if n := len(server.messages()); n != 1 {
+		t.Errorf("server accepted %d messages, want 1", n)
+	}
+}
+
+func TestOutboxGivesUp(t *testing.T) {
+	server := newFakeSMTP(t, "554 5.7.1 Rejected")
+	store := &memoryStore{now: time.Now()}
+	outbox := &Outbox{repo: store, sender: server.sender()}
+	ctx := context.Background()
+
+	outbox.Send(ctx, "jdoe@example.com", "Hello", "Hi")
+	store.messages[0].Attempts = maxAttempts - 1
+	if _, err := outbox.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.messages[0].Status; got != "failed" {
+		t.Errorf("status after the last attempt = %q, want failed", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{9, 256 * time.Minute},
+		{10, maxBackoff},
+		{50, maxBackoff},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.attempts), func(t *testing.T) {
+			if got := backoff(tt.attempts); got != tt.want {
+				t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package mail
+
+import (
+	"bd_bot/internal/repository"
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	// maxAttempts is the number of delivery attempts before a message is marked failed
+	maxAttempts = 8
+	baseBackoff = time.Minute
+	maxBackoff  = 6 * time.Hour
+	batchSize   = 20
+)
+
+// outboxStore is the part of repository.MailRepository the outbox uses
+type outboxStore interface {
+	Enqueue(ctx context.Context, to, subject, textBody, htmlBody string) (int, error)
+	ClaimDue(ctx context.Context, limit int) ([]repository.OutboxMessage, error)
+	MarkSent(ctx context.Context, id int) error
+	MarkFailed(ctx context.Context, id int, errText string, retryAt *time.Time) error
+}
+
+// Outbox queues mail in the database and delivers it in the background with
+// retry and exponential backoff
+type Outbox struct {
+	repo   outboxStore
+	sender Sender
+}
+
+// NewOutbox creates an outbox. sender may be nil when no transport is
+// configured; messages are then queued but not delivered.
+func NewOutbox(repo *repository.MailRepository, sender Sender) *Outbox {
+	return &Outbox{repo: repo, sender: sender}
+}
+
+// Enqueue queues a rendered message for delivery
+func (o *Outbox) Enqueue(ctx context.Context, msg Message) error {
+	id, err := o.repo.Enqueue(ctx, msg.To, msg.Subject, msg.Text, msg.HTML)
+	if err != nil {
+		return err
+	}
+	slog.Info("Queued email", "id", id, "to", msg.To, "subject", msg.Subject)
+	return nil
+}
+
+// EnqueueTemplate renders the named template and queues the result
+func (o *Outbox) EnqueueTemplate(ctx context.Context, name, to string, data interface{}) error {
+	msg, err := Render(name, to, data)
+	if err != nil {
+		return err
+	}
+	return o.Enqueue(ctx, msg)
+}
+
+// Send queues a plain-text message (satisfies notify.Mailer)
+func (o *Outbox) Send(ctx context.Context, to, subject, body string) error {
+	return o.Enqueue(ctx, Message{To: to, Subject: subject, Text: body})
+}
+
+// Flush attempts delivery of every due message and returns the number sent
+func (o *Outbox) Flush(ctx context.Context) (int, error) {
+	if o.sender == nil {
+		return 0, nil
+	}
+
+	sent := 0
+	for {
+		messages, err := o.repo.ClaimDue(ctx, batchSize)
+		if err != nil {
+			return sent, err
+		}
+		if len(messages) == 0 {
+			return sent, nil
+		}
+
+		for _, m := range messages {
+			err := o.sender.Deliver(ctx, Message{To: m.To, Subject: m.Subject, Text: m.TextBody, HTML: m.HTMLBody})
+			if err == nil {
+				if err := o.repo.MarkSent(ctx, m.ID); err != nil {
+					slog.Error("Failed to mark email sent", "id", m.ID, "error", err)
+				}
+				sent++
+				continue
+			}
+
+			var retryAt *time.Time
+			if m.Attempts < maxAttempts {
+				t := time.Now().Add(backoff(m.Attempts))
+				retryAt = &t
+			}
+			slog.Warn("Email delivery failed", "id", m.ID, "to", m.To, "attempt", m.Attempts, "retry_at", retryAt, "error", err)
+			if err := o.repo.MarkFailed(ctx, m.ID, err.Error(), retryAt); err != nil {
+				slog.Error("Failed to record email failure", "id", m.ID, "error", err)
+			}
+		}
+	}
+}
+
+// Run delivers queued mail every interval until ctx is done
+func (o *Outbox) Run(ctx context.Context, interval time.Duration) {
+	if o.sender == nil {
+		slog.Warn("SMTP not configured; outgoing mail will stay queued in mail_outbox")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := o.Flush(ctx); err != nil {
+			slog.Error("Mail outbox flush failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// backoff returns the delay before the next attempt, doubling each time
+func backoff(attempts int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
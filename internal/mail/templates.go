@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*
+var templateFS embed.FS
+
+// Render builds a message from the named template pair. templates/<name>.txt
+// must define "subject" and "body"; templates/<name>.html is optional and
+// defines "body" for the HTML alternative.
+func Render(name, to string, data interface{}) (Message, error) {
+	msg := Message{To: to}
+
+	textTmpl, err := texttemplate.ParseFS(templateFS, "templates/"+name+".txt")
+	if err != nil {
+		return msg, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	var subject, text bytes.Buffer
+	if err := textTmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return msg, fmt.Errorf("failed to render subject for %s: %w", name, err)
+	}
+	if err := textTmpl.ExecuteTemplate(&text, "body", data); err != nil {
+		return msg, fmt.Errorf("failed to render text body for %s: %w", name, err)
+	}
+	msg.Subject = strings.TrimSpace(subject.String())
+	msg.Text = text.String()
+
+	if _, err := templateFS.Open("templates/" + name + ".html"); err == nil {
+		htmlTmpl, err := htmltemplate.ParseFS(templateFS, "templates/"+name+".html")
+		if err != nil {
+			return msg, fmt.Errorf("failed to parse HTML template %s: %w", name, err)
+		}
+		var html bytes.Buffer
+		if err := htmlTmpl.ExecuteTemplate(&html, "body", data); err != nil {
+			return msg, fmt.Errorf("failed to render HTML body for %s: %w", name, err)
+		}
+		msg.HTML = html.String()
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,381 @@
+package matching
+
+import (
+	"bd_bot/internal/ai"
+	"bd_bot/internal/prompt"
+	"bd_bot/internal/repository"
+	"bd_bot/internal/scraper"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EvalCase is a narrative and a solicitation with the verdict the matcher
+// should reach
+type EvalCase struct {
+	ID           string
+	Narrative    string
+	Solicitation scraper.Solicitation
+	Relevant     bool
+	// UserID is the user whose decision the case comes from, 0 for golden
+	// set cases
+	UserID int
+	// Examples are shown to the matcher as few-shot context, as a matching
+	// run shows a user's feedback
+	Examples []prompt.MatchExample
+}
+
+// evalFile is the YAML layout of a golden set. Narratives shared by several
+// cases can be written once with a YAML anchor (&name) and reused (*name).
+type evalFile struct {
+	Cases []struct {
+		ID           string `yaml:"id"`
+		Narrative    string `yaml:"narrative"`
+		Solicitation struct {
+			Title       string `yaml:"title"`
+			Agency      string `yaml:"agency"`
+			Description string `yaml:"description"`
+			Documents   string `yaml:"documents"`
+		} `yaml:"solicitation"`
+		Relevant *bool `yaml:"relevant"`
+	} `yaml:"cases"`
+}
+
+// LoadEvalSet reads a golden set from a YAML file
+func LoadEvalSet(path string) ([]EvalCase, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f evalFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	cases := make([]EvalCase, 0, len(f.Cases))
+	seen := make(map[string]bool)
+	for i, c := range f.Cases {
+		id := c.ID
+		if id == "" {
+			id = fmt.Sprintf("case-%d", i+1)
+		}
+		switch {
+		case seen[id]:
+			return nil, fmt.Errorf("%s: duplicate case id %q", path, id)
+		case c.Narrative == "":
+			return nil, fmt.Errorf("%s: case %s has no narrative", path, id)
+		case c.Solicitation.Title == "":
+			return nil, fmt.Errorf("%s: case %s has no solicitation title", path, id)
+		case c.Relevant == nil:
+			return nil, fmt.Errorf("%s: case %s does not say whether it is relevant", path, id)
+		}
+		seen[id] = true
+
+		cases = append(cases, EvalCase{
+			ID:        id,
+			Narrative: c.Narrative,
+			Solicitation: scraper.Solicitation{
+				SourceID:     id,
+				Title:        c.Solicitation.Title,
+				Agency:       c.Solicitation.Agency,
+				Description:  c.Solicitation.Description,
+				DocumentText: c.Solicitation.Documents,
+			},
+			Relevant: *c.Relevant,
+		})
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("%s: no cases", path)
+	}
+	return cases, nil
+}
+
+// EvalCasesFromClaims turns users' lead and archive decisions into cases,
+// scored against each user's current narrative
+func EvalCasesFromClaims(claims []repository.LabeledClaim) []EvalCase {
+	cases := make([]EvalCase, 0, len(claims))
+	for _, c := range claims {
+		cases = append(cases, EvalCase{
+			ID:           c.UserEmail + "/" + c.Solicitation.SourceID,
+			Narrative:    c.Narrative,
+			Solicitation: c.Solicitation,
+			Relevant:     c.Relevant,
+			UserID:       c.UserID,
+		})
+	}
+	return cases
+}
+
+// feedbackSource loads users' recent match ratings
+type feedbackSource interface {
+	RecentFeedback(ctx context.Context, userID, perRating int) ([]repository.FeedbackExample, error)
+}
+
+// AddFeedbackExamples gives each case from users' history the examples a
+// matching run would show that user when scoring its solicitation. Each
+// user's feedback is loaded once.
+func AddFeedbackExamples(ctx context.Context, feedback feedbackSource, cases []EvalCase) error {
+	byUser := make(map[int][]ratedExample)
+	for i := range cases {
+		c := &cases[i]
+		if c.UserID == 0 {
+			continue
+		}
+		rated, ok := byUser[c.UserID]
+		if !ok {
+			fb, err := feedback.RecentFeedback(ctx, c.UserID, feedbackExamplesPerRating+1)
+			if err != nil {
+				return fmt.Errorf("loading feedback of user %d: %w", c.UserID, err)
+			}
+			rated = ratedExamples(fb)
+			byUser[c.UserID] = rated
+		}
+		c.Examples = examplesFor(rated, c.Solicitation.ID)
+	}
+	return nil
+}
+
+// EvalResult is the matcher's verdict on one case
+type EvalResult struct {
+	ID         string  `json:"id"`
+	Relevant   bool    `json:"relevant"`
+	Score      int     `json:"score"`
+	Confidence float64 `json:"confidence"`
+	// Error is set, and the score meaningless, if the case could not be scored
+	Error string `json:"error,omitempty"`
+}
+
+// Evaluate scores every case with the matcher, within the same concurrency
+// and rate limits as a matching run. Results are in case order.
+func Evaluate(ctx context.Context, matcher *ai.Matcher, cases []EvalCase, limits Limits, progress func(Progress)) []EvalResult {
+	results := make([]EvalResult, len(cases))
+	lim := newLimiter(limits.RequestsPerMinute)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	p := Progress{User: "eval", Total: len(cases)}
+	start := time.Now()
+	for range max(limits.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				c := cases[i]
+				res := EvalResult{ID: c.ID, Relevant: c.Relevant}
+				result, err := withRetry(ctx, lim, func() (*ai.MatchResult, error) {
+					return matcher.Match(ctx, c.Narrative, c.Examples, c.Solicitation)
+				})
+				if err != nil {
+					res.Error = err.Error()
+				} else {
+					res.Score = result.Score
+					res.Confidence = result.Confidence
+				}
+				results[i] = res
+
+				mu.Lock()
+				p.Done++
+				if err != nil {
+					p.Failed++
+				}
+				p.Elapsed = time.Since(start)
+				if progress != nil {
+					progress(p)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range cases {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Cases never started because of cancellation
+	for i, c := range cases {
+		if results[i].ID == "" {
+			results[i] = EvalResult{ID: c.ID, Relevant: c.Relevant, Error: "not scored: " + ctx.Err().Error()}
+		}
+	}
+	return results
+}
+
+// EvalReport measures a set of results against their labels. A case counts
+// as predicted relevant when its score reaches the threshold. Failed cases
+// are left out of every measure.
+type EvalReport struct {
+	Threshold      int `json:"threshold"`
+	Cases          int `json:"cases"`
+	Failed         int `json:"failed"`
+	TruePositives  int `json:"true_positives"`
+	FalsePositives int `json:"false_positives"`
+	TrueNegatives  int `json:"true_negatives"`
+	FalseNegatives int `json:"false_negatives"`
+
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	// Brier is the mean squared difference between score/100 and the label
+	// (1 relevant, 0 not); 0 is perfect and lower is better calibrated
+	Brier       float64             `json:"brier"`
+	Calibration []CalibrationBucket `json:"calibration"`
+}
+
+// CalibrationBucket is how often cases scored in [Min, Max] were relevant.
+// For a calibrated matcher, Relevant is close to MeanScore/100.
+type CalibrationBucket struct {
+	Min       int     `json:"min"`
+	Max       int     `json:"max"`
+	Count     int     `json:"count"`
+	MeanScore float64 `json:"mean_score"`
+	Relevant  float64 `json:"relevant"`
+}
+
+// calibrationWidth is the score range of each calibration bucket
+const calibrationWidth = 20
+
+// Report measures results against their labels at the threshold
+func Report(results []EvalResult, threshold int) EvalReport {
+	r := EvalReport{Threshold: threshold, Cases: len(results)}
+	for lo := 0; lo < 100; lo += calibrationWidth {
+		hi := lo + calibrationWidth - 1
+		if hi >= 99 {
+			hi = 100
+		}
+		r.Calibration = append(r.Calibration, CalibrationBucket{Min: lo, Max: hi})
+	}
+
+	var sqErr float64
+	scored := 0
+	for _, res := range results {
+		if res.Error != "" {
+			r.Failed++
+			continue
+		}
+		scored++
+		predicted := res.Score >= threshold
+		switch {
+		case predicted && res.Relevant:
+			r.TruePositives++
+		case predicted:
+			r.FalsePositives++
+		case res.Relevant:
+			r.FalseNegatives++
+		default:
+			r.TrueNegatives++
+		}
+
+		label := 0.0
+		if res.Relevant {
+			label = 1
+		}
+		sqErr += math.Pow(float64(res.Score)/100-label, 2)
+
+		b := &r.Calibration[min(res.Score/calibrationWidth, len(r.Calibration)-1)]
+		b.Count++
+		b.MeanScore += float64(res.Score)
+		b.Relevant += label
+	}
+
+	for i := range r.Calibration {
+		if b := &r.Calibration[i]; b.Count > 0 {
+			b.MeanScore /= float64(b.Count)
+			b.Relevant /= float64(b.Count)
+		}
+	}
+	if scored > 0 {
+		r.Brier = sqErr / float64(scored)
+	}
+	r.Precision = ratio(r.TruePositives, r.TruePositives+r.FalsePositives)
+	r.Recall = ratio(r.TruePositives, r.TruePositives+r.FalseNegatives)
+	if r.Precision+r.Recall > 0 {
+		r.F1 = 2 * r.Precision * r.Recall / (r.Precision + r.Recall)
+	}
+	return r
+}
+
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+// Eval verdict changes between two configurations
+const (
+	EvalFixed  = "fixed"  // the second configuration gets the case right, the first did not
+	EvalBroken = "broken" // the reverse
+)
+
+// EvalDiff compares two configurations' results for one case
+type EvalDiff struct {
+	ID       string `json:"id"`
+	Relevant bool   `json:"relevant"`
+	ScoreA   int    `json:"score_a"`
+	ScoreB   int    `json:"score_b"`
+	ErrorA   string `json:"error_a,omitempty"`
+	ErrorB   string `json:"error_b,omitempty"`
+	// Change is EvalFixed or EvalBroken when the verdict at the threshold
+	// flipped, otherwise empty
+	Change string `json:"change,omitempty"`
+}
+
+// Delta is how much the score moved from A to B
+func (d EvalDiff) Delta() int {
+	return d.ScoreB - d.ScoreA
+}
+
+// CompareEval pairs up two result sets from the same cases, flipped verdicts
+// first, then by how far the score moved
+func CompareEval(a, b []EvalResult, threshold int) []EvalDiff {
+	byID := make(map[string]EvalResult, len(b))
+	for _, res := range b {
+		byID[res.ID] = res
+	}
+
+	var diffs []EvalDiff
+	for _, ra := range a {
+		rb, ok := byID[ra.ID]
+		if !ok {
+			continue
+		}
+		d := EvalDiff{ID: ra.ID, Relevant: ra.Relevant, ScoreA: ra.Score, ScoreB: rb.Score, ErrorA: ra.Error, ErrorB: rb.Error}
+		if ra.Error == "" && rb.Error == "" {
+			rightA := (ra.Score >= threshold) == ra.Relevant
+			rightB := (rb.Score >= threshold) == rb.Relevant
+			switch {
+			case rightB && !rightA:
+				d.Change = EvalFixed
+			case rightA && !rightB:
+				d.Change = EvalBroken
+			}
+		}
+		diffs = append(diffs, d)
+	}
+
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if (diffs[i].Change != "") != (diffs[j].Change != "") {
+			return diffs[i].Change != ""
+		}
+		return abs(diffs[i].Delta()) > abs(diffs[j].Delta())
+	})
+	return diffs
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
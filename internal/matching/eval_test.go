@@ -0,0 +1,285 @@
+package matching
+
+import (
+	"bd_bot/internal/ai"
+	"bd_bot/internal/repository"
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []EvalResult
+		want    EvalReport
+		// wantBuckets is the case count of each calibration bucket
+		wantBuckets []int
+	}{
+		{
+			name: "mixed",
+			results: []EvalResult{
+				{ID: "tp", Relevant: true, Score: 90},
+				{ID: "fp", Relevant: false, Score: 80},
+				{ID: "tn", Relevant: false, Score: 10},
+				{ID: "fn", Relevant: true, Score: 50},
+			},
+			want: EvalReport{
+				Threshold: 75, Cases: 4,
+				TruePositives: 1, FalsePositives: 1, TrueNegatives: 1, FalseNegatives: 1,
+				Precision: 0.5, Recall: 0.5, F1: 0.5,
+				// (0.01 + 0.64 + 0.01 + 0.25) / 4
+				Brier: 0.2275,
+			},
+			wantBuckets: []int{1, 0, 1, 0, 2},
+		},
+		{
+			name: "no predicted positives",
+			results: []EvalResult{
+				{ID: "a", Relevant: true, Score: 40},
+				{ID: "b", Relevant: false, Score: 20},
+			},
+			want: EvalReport{
+				Threshold: 75, Cases: 2,
+				TrueNegatives: 1, FalseNegatives: 1,
+				// (0.36 + 0.04) / 2
+				Brier: 0.2,
+			},
+			wantBuckets: []int{0, 1, 1, 0, 0},
+		},
+		{
+			name: "all failed",
+			results: []EvalResult{
+				{ID: "a", Relevant: true, Error: "timeout"},
+				{ID: "b", Relevant: false, Error: "invalid match result"},
+			},
+			want:        EvalReport{Threshold: 75, Cases: 2, Failed: 2},
+			wantBuckets: []int{0, 0, 0, 0, 0},
+		},
+		{
+			name: "failed cases are left out",
+			results: []EvalResult{
+				{ID: "a", Relevant: true, Score: 100},
+				{ID: "b", Relevant: false, Score: 0, Error: "timeout"},
+			},
+			want: EvalReport{
+				Threshold: 75, Cases: 2, Failed: 1,
+				TruePositives: 1,
+				Precision:     1, Recall: 1, F1: 1,
+			},
+			wantBuckets: []int{0, 0, 0, 0, 1},
+		},
+		{
+			name: "bucket edges",
+			results: []EvalResult{
+				{ID: "0", Relevant: false, Score: 0},
+				{ID: "19", Relevant: false, Score: 19},
+				{ID: "20", Relevant: false, Score: 20},
+				{ID: "79", Relevant: true, Score: 79},
+				{ID: "80", Relevant: true, Score: 80},
+				{ID: "100", Relevant: true, Score: 100},
+			},
+			want: EvalReport{
+				Threshold: 75, Cases: 6,
+				TruePositives: 3, TrueNegatives: 3,
+				Precision: 1, Recall: 1, F1: 1,
+				// (0 + 0.0361 + 0.04 + 0.0441 + 0.04 + 0) / 6
+				Brier: 0.1602 / 6,
+			},
+			wantBuckets: []int{2, 1, 0, 1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Report(tt.results, 75)
+			counts := [...]struct {
+				name      string
+				got, want int
+			}{
+				{"cases", got.Cases, tt.want.Cases},
+				{"failed", got.Failed, tt.want.Failed},
+				{"true positives", got.TruePositives, tt.want.TruePositives},
+				{"false positives", got.FalsePositives, tt.want.FalsePositives},
+				{"true negatives", got.TrueNegatives, tt.want.TrueNegatives},
+				{"false negatives", got.FalseNegatives, tt.want.FalseNegatives},
+			}
+			for _, c := range counts {
+				if c.got != c.want {
+					t.Errorf("%s = %d, want %d", c.name, c.got, c.want)
+				}
+			}
+			measures := [...]struct {
+				name      string
+				got, want float64
+			}{
+				{"precision", got.Precision, tt.want.Precision},
+				{"recall", got.Recall, tt.want.Recall},
+				{"F1", got.F1, tt.want.F1},
+				{"Brier", got.Brier, tt.want.Brier},
+			}
+			for _, m := range measures {
+				if math.Abs(m.got-m.want) > 1e-9 || math.IsNaN(m.got) {
+					t.Errorf("%s = %v, want %v", m.name, m.got, m.want)
+				}
+			}
+
+			if len(got.Calibration) != len(tt.wantBuckets) {
+				t.Fatalf("%d calibration buckets, want %d", len(got.Calibration), len(tt.wantBuckets))
+			}
+			for i, b := range got.Calibration {
+				if b.Count != tt.wantBuckets[i] {
+					t.Errorf("bucket %d-%d has %d cases, want %d", b.Min, b.Max, b.Count, tt.wantBuckets[i])
+				}
+				if b.Count == 0 && (b.MeanScore != 0 || b.Relevant != 0) {
+					t.Errorf("empty bucket %d-%d = %+v", b.Min, b.Max, b)
+				}
+			}
+		})
+	}
+}
+
+func TestReportCalibration(t *testing.T) {
+	r := Report([]EvalResult{
+		{ID: "a", Relevant: true, Score: 100},
+		{ID: "b", Relevant: false, Score: 85},
+		{ID: "c", Relevant: true, Score: 90},
+		{ID: "d", Relevant: true, Score: 90},
+	}, 75)
+
+	first, last := r.Calibration[0], r.Calibration[len(r.Calibration)-1]
+	if first.Min != 0 || last.Min != 80 || last.Max != 100 {
+		t.Fatalf("buckets run %d-%d to %d-%d, want 0-19 to 80-100", first.Min, first.Max, last.Min, last.Max)
+	}
+	if last.Count != 4 || last.MeanScore != 91.25 || last.Relevant != 0.75 {
+		t.Errorf("80-100 bucket = %+v, want 4 cases, mean score 91.25, 75%% relevant", last)
+	}
+}
+
+func TestCompareEval(t *testing.T) {
+	a := []EvalResult{
+		{ID: "steady", Relevant: true, Score: 90},
+		{ID: "fixed", Relevant: true, Score: 60},
+		{ID: "broken", Relevant: false, Score: 30},
+		{ID: "moved", Relevant: false, Score: 10},
+		{ID: "failed", Relevant: true, Error: "timeout"},
+		{ID: "only-a", Relevant: true, Score: 50},
+	}
+	b := []EvalResult{
+		{ID: "steady", Relevant: true, Score: 92},
+		{ID: "fixed", Relevant: true, Score: 80},
+		{ID: "broken", Relevant: false, Score: 95},
+		{ID: "moved", Relevant: false, Score: 60},
+		{ID: "failed", Relevant: true, Score: 95},
+		{ID: "only-b", Relevant: true, Score: 50},
+	}
+
+	diffs := CompareEval(a, b, 75)
+	want := []struct {
+		id     string
+		change string
+		delta  int
+	}{
+		// Flipped verdicts first, then by how far the score moved
+		{"broken", EvalBroken, 65},
+		{"fixed", EvalFixed, 20},
+		// A failed case has no verdict to flip
+		{"failed", "", 95},
+		{"moved", "", 50},
+		{"steady", "", 2},
+	}
+
+	if len(diffs) != len(want) {
+		t.Fatalf("%d diffs, want %d: %+v", len(diffs), len(want), diffs)
+	}
+	for i, w := range want {
+		d := diffs[i]
+		if d.ID != w.id || d.Change != w.change || d.Delta() != w.delta {
+			t.Errorf("diff %d = %s %q %+d, want %s %q %+d", i, d.ID, d.Change, d.Delta(), w.id, w.change, w.delta)
+		}
+	}
+	for _, d := range diffs {
+		if d.ID == "failed" && d.ErrorA != "timeout" {
+			t.Errorf("failed case lost its error: %+v", d)
+		}
+	}
+}
+
+func TestCompareEvalBothWrong(t *testing.T) {
+	a := []EvalResult{{ID: "x", Relevant: true, Score: 10}}
+	b := []EvalResult{{ID: "x", Relevant: true, Score: 70}}
+	if diffs := CompareEval(a, b, 75); len(diffs) != 1 || diffs[0].Change != "" {
+		t.Errorf("CompareEval() = %+v, want one unchanged verdict", diffs)
+	}
+}
+
+// userFeedback is a feedbackSource with each user's ratings
+type userFeedback struct {
+	ratings map[int][]repository.FeedbackExample
+	loads   map[int]int
+	err     error
+}
+
+func (f *userFeedback) RecentFeedback(ctx context.Context, userID, perRating int) ([]repository.FeedbackExample, error) {
+	f.loads[userID]++
+	return f.ratings[userID], f.err
+}
+
+func TestEvaluateWithFeedbackExamples(t *testing.T) {
+	sols := testSolicitations()
+	claims := []repository.LabeledClaim{
+		{UserID: 7, UserEmail: "jdoe@example.com", Narrative: testUser.Narrative, Solicitation: sols[0], Relevant: true},
+		{UserID: 7, UserEmail: "jdoe@example.com", Narrative: testUser.Narrative, Solicitation: sols[1], Relevant: false},
+		{UserID: 8, UserEmail: "asmith@example.com", Narrative: "We clean offices", Solicitation: sols[1], Relevant: true},
+	}
+	feedback := &userFeedback{
+		ratings: map[int][]repository.FeedbackExample{
+			7: {
+				{SolicitationID: 1, Title: "Autonomous swarms", Agency: "DARPA", Description: "UAS swarm autonomy research", Score: 88, Rating: repository.RatingUp},
+				{SolicitationID: 99, Title: "Counter-UAS radar", Agency: "Army", Description: "Radar for small drones", Score: 40, Rating: repository.RatingUp},
+			},
+		},
+		loads: make(map[int]int),
+	}
+
+	cases := EvalCasesFromClaims(claims)
+	if err := AddFeedbackExamples(context.Background(), feedback, cases); err != nil {
+		t.Fatal(err)
+	}
+	if feedback.loads[7] != 1 || feedback.loads[8] != 1 {
+		t.Errorf("feedback loaded %v times per user, want once each", feedback.loads)
+	}
+	// A case never shows its own solicitation's rating
+	if got := len(cases[0].Examples); got != 1 || cases[0].Examples[0].Title != "Counter-UAS radar" {
+		t.Errorf("case %s examples = %+v, want only Counter-UAS radar", cases[0].ID, cases[0].Examples)
+	}
+	if got := len(cases[1].Examples); got != 2 {
+		t.Errorf("case %s has %d examples, want 2", cases[1].ID, got)
+	}
+	if len(cases[2].Examples) != 0 {
+		t.Errorf("user 8 has no feedback but case %s has examples %+v", cases[2].ID, cases[2].Examples)
+	}
+
+	provider := &promptRecorder{}
+	results := Evaluate(context.Background(), ai.NewMatcher(provider, nil), cases[:1], Limits{Concurrency: 1}, nil)
+	if results[0].Error != "" {
+		t.Fatalf("case failed: %s", results[0].Error)
+	}
+	if len(provider.prompts) != 1 || !strings.Contains(provider.prompts[0], "Counter-UAS radar") {
+		t.Errorf("prompt is missing the case's examples:\n%s", provider.prompts)
+	}
+}
+
+func TestAddFeedbackExamplesError(t *testing.T) {
+	cases := []EvalCase{{ID: "golden"}, {ID: "history", UserID: 7}}
+	feedback := &userFeedback{loads: make(map[int]int), err: errors.New("connection refused")}
+	if err := AddFeedbackExamples(context.Background(), feedback, cases); err == nil {
+		t.Error("AddFeedbackExamples() error = nil, want the load error")
+	}
+	// Golden set cases have no user to load feedback for
+	if feedback.loads[0] != 0 {
+		t.Error("feedback was loaded for a golden set case")
+	}
+}
@@ -0,0 +1,287 @@
+package matching
+
+import (
+	"bd_bot/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// jobQueueSize bounds how many web-requested jobs may wait to run
+const jobQueueSize = 100
+
+// progressSaveInterval throttles how often a running job's counters are written
+const progressSaveInterval = 2 * time.Second
+
+var (
+	// ErrJobActive is returned when the user already has a queued or running job
+	ErrJobActive = errors.New("a matching job is already queued or running")
+	// ErrNoNarrative is returned when the user has nothing to match against
+	ErrNoNarrative = errors.New("add a capability narrative to your profile first")
+	// ErrQueueFull is returned when too many jobs are waiting
+	ErrQueueFull = errors.New("too many matching jobs are queued, try again later")
+)
+
+// Job event types
+const (
+	EventProgress = "progress"
+	EventMatch    = "match"
+	EventDone     = "done"
+)
+
+// JobEvent is sent to subscribers of a running job
+type JobEvent struct {
+	Type  string              `json:"type"`
+	Job   repository.MatchJob `json:"job"`
+	Match *ScoreEvent         `json:"match,omitempty"`
+}
+
+// liveJob is the in-memory state of a queued or running job
+type liveJob struct {
+	job  repository.MatchJob
+	subs map[chan JobEvent]struct{}
+}
+
+// JobManager runs matching jobs for individual users in the background, one
+// at a time, and fans their progress out to subscribers
+type JobManager struct {
+	runner *Runner
+	users  *repository.UserRepository
+	jobs   *repository.MatchJobRepository
+	queue  chan int
+
+	enqueueMu sync.Mutex
+	mu        sync.Mutex // guards live
+	live      map[int]*liveJob
+}
+
+func NewJobManager(runner *Runner, users *repository.UserRepository, jobs *repository.MatchJobRepository) *JobManager {
+	return &JobManager{
+		runner: runner,
+		users:  users,
+		jobs:   jobs,
+		queue:  make(chan int, jobQueueSize),
+		live:   make(map[int]*liveJob),
+	}
+}
+
+// Start processes queued jobs until ctx is cancelled. Jobs left over from a
+// previous process are marked as failed first.
+func (m *JobManager) Start(ctx context.Context) {
+	if n, err := m.jobs.FailInterrupted(ctx); err != nil {
+		slog.Error("Failed to clean up interrupted matching jobs", "error", err)
+	} else if n > 0 {
+		slog.Warn("Marked interrupted matching jobs as failed", "count", n)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-m.queue:
+			m.run(ctx, id)
+		}
+	}
+}
+
+// Enqueue queues a matching job for the user. If one is already queued or
+// running, it is returned along with ErrJobActive.
+func (m *JobManager) Enqueue(ctx context.Context, userID int) (*repository.MatchJob, error) {
+	user, err := m.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Narrative == "" {
+		return nil, ErrNoNarrative
+	}
+
+	// Serialize enqueues so that a double click can't start two jobs
+	m.enqueueMu.Lock()
+	defer m.enqueueMu.Unlock()
+
+	if active, err := m.jobs.Active(ctx, userID); err == nil {
+		if live, err := m.Get(ctx, active.ID); err == nil {
+			active = live
+		}
+		return active, ErrJobActive
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	job, err := m.jobs.Create(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.live[job.ID] = &liveJob{job: *job, subs: make(map[chan JobEvent]struct{})}
+	m.mu.Unlock()
+
+	select {
+	case m.queue <- job.ID:
+		return job, nil
+	default:
+		m.mu.Lock()
+		delete(m.live, job.ID)
+		m.mu.Unlock()
+
+		job.Status = repository.JobFailed
+		job.Error = ErrQueueFull.Error()
+		if err := m.jobs.Finish(ctx, job); err != nil {
+			slog.Error("Failed to record rejected matching job", "job_id", job.ID, "error", err)
+		}
+		return nil, ErrQueueFull
+	}
+}
+
+// Get returns the current state of a job, including live progress estimates
+// while it runs
+func (m *JobManager) Get(ctx context.Context, id int) (*repository.MatchJob, error) {
+	m.mu.Lock()
+	if lj, ok := m.live[id]; ok {
+		snapshot := lj.job
+		m.mu.Unlock()
+		return &snapshot, nil
+	}
+	m.mu.Unlock()
+	return m.jobs.Get(ctx, id)
+}
+
+// Subscribe returns a channel of events for a queued or running job and a
+// function to unsubscribe. The channel is closed after the done event. ok is
+// false if the job is not live, in which case Get has its final state.
+func (m *JobManager) Subscribe(id int) (events <-chan JobEvent, unsubscribe func(), ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lj, ok := m.live[id]
+	if !ok {
+		return nil, nil, false
+	}
+	ch := make(chan JobEvent, 64)
+	lj.subs[ch] = struct{}{}
+	ch <- JobEvent{Type: EventProgress, Job: lj.job}
+
+	unsubscribe = func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if _, ok := lj.subs[ch]; ok {
+			delete(lj.subs, ch)
+			close(ch)
+		}
+	}
+	return ch, unsubscribe, true
+}
+
+// publish updates the live job and sends an event to its subscribers. Slow
+// subscribers miss progress events rather than holding up the job; the done
+// event is always delivered. Must be called with m.mu held.
+func (m *JobManager) publish(lj *liveJob, event JobEvent) {
+	event.Job = lj.job
+	for ch := range lj.subs {
+		if event.Type == EventDone {
+			select {
+			case ch <- event:
+			default:
+				// Make room: the final state matters more than a stale update
+				<-ch
+				ch <- event
+			}
+			delete(lj.subs, ch)
+			close(ch)
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+func (m *JobManager) run(ctx context.Context, id int) {
+	m.mu.Lock()
+	lj, ok := m.live[id]
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	job := lj.job
+	finish := func(err error) {
+		m.mu.Lock()
+		if err != nil {
+			lj.job.Status = repository.JobFailed
+			lj.job.Error = err.Error()
+		} else {
+			lj.job.Status = repository.JobSucceeded
+		}
+		lj.job.PerMinute, lj.job.ETASeconds = 0, 0
+		final := lj.job
+		m.mu.Unlock()
+
+		// Record the outcome even if the server is shutting down
+		saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		if err := m.jobs.Finish(saveCtx, &final); err != nil {
+			slog.Error("Failed to record matching job", "job_id", id, "error", err)
+		}
+
+		m.mu.Lock()
+		lj.job = final
+		m.publish(lj, JobEvent{Type: EventDone})
+		delete(m.live, id)
+		m.mu.Unlock()
+	}
+
+	if err := m.jobs.Start(ctx, &job); err != nil {
+		finish(fmt.Errorf("failed to start: %w", err))
+		return
+	}
+	m.mu.Lock()
+	lj.job.Status, lj.job.StartedAt = job.Status, job.StartedAt
+	m.publish(lj, JobEvent{Type: EventProgress})
+	m.mu.Unlock()
+
+	user, err := m.users.FindByID(ctx, job.UserID)
+	if err != nil {
+		finish(err)
+		return
+	}
+
+	var lastSave time.Time
+	opts := Options{
+		Progress: func(p Progress) {
+			m.mu.Lock()
+			lj.job.Total, lj.job.Done, lj.job.Failed = p.Total, p.Done, p.Failed
+			lj.job.PerMinute = p.PerMinute()
+			lj.job.ETASeconds = int(p.ETA().Seconds())
+			m.publish(lj, JobEvent{Type: EventProgress})
+			snapshot := lj.job
+			m.mu.Unlock()
+
+			if time.Since(lastSave) >= progressSaveInterval {
+				lastSave = time.Now()
+				if err := m.jobs.UpdateProgress(ctx, &snapshot); err != nil {
+					slog.Error("Failed to save matching job progress", "job_id", id, "error", err)
+				}
+			}
+		},
+		Scored: func(e ScoreEvent) {
+			m.mu.Lock()
+			if e.AboveThreshold {
+				lj.job.AboveThreshold++
+			}
+			m.publish(lj, JobEvent{Type: EventMatch, Match: &e})
+			m.mu.Unlock()
+		},
+	}
+
+	slog.Info("Matching job started", "job_id", id, "user", user.Email)
+	stats, err := m.runner.RunForUser(ctx, user, opts)
+	slog.Info("Matching job finished", "job_id", id, "user", user.Email, "scored", stats.Scored, "failed", stats.Failed, "error", err)
+	finish(err)
+}
@@ -0,0 +1,132 @@
+package matching
+
+import (
+	"bd_bot/internal/ai"
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Retry policy for temporary LLM failures (429 and 5xx)
+const (
+	maxAttempts    = 5
+	initialBackoff = 2 * time.Second
+	maxBackoff     = time.Minute
+)
+
+// Limits bounds how hard a runner drives the LLM endpoint
+type Limits struct {
+	// Concurrency is the number of requests in flight; values below 1 mean 1
+	Concurrency int
+	// RequestsPerMinute caps the request rate across all workers, including
+	// retries; 0 means unlimited
+	RequestsPerMinute int
+}
+
+// Progress is reported after each solicitation finishes scoring
+type Progress struct {
+	// User is the email or organization name being matched
+	User    string
+	Done    int
+	Total   int
+	Failed  int
+	Elapsed time.Duration
+}
+
+// PerMinute is the observed throughput
+func (p Progress) PerMinute() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Done) / p.Elapsed.Minutes()
+}
+
+// ETA estimates the time remaining at the observed throughput
+func (p Progress) ETA() time.Duration {
+	if p.Done == 0 {
+		return 0
+	}
+	return time.Duration(float64(p.Elapsed) / float64(p.Done) * float64(p.Total-p.Done))
+}
+
+// limiter spaces requests evenly to stay within a per-minute budget
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newLimiter(perMinute int) *limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &limiter{interval: time.Minute / time.Duration(perMinute)}
+}
+
+// Wait blocks until the caller may send a request. A nil limiter never blocks.
+func (l *limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// withRetry calls fn until it succeeds, fails permanently, or maxAttempts is
+// reached, backing off exponentially (with jitter, or as the server asks)
+// between temporary failures
+func withRetry[T any](ctx context.Context, lim *limiter, fn func() (T, error)) (T, error) {
+	var zero T
+	backoff := initialBackoff
+	for attempt := 1; ; attempt++ {
+		if err := lim.Wait(ctx); err != nil {
+			return zero, err
+		}
+		result, err := fn()
+		if err == nil {
+			return result, nil
+		}
+
+		var statusErr *ai.StatusError
+		if !errors.As(err, &statusErr) || !statusErr.Temporary() || attempt == maxAttempts || ctx.Err() != nil {
+			return zero, err
+		}
+
+		delay := backoff/2 + rand.N(backoff/2+1)
+		if statusErr.RetryAfter > delay {
+			delay = min(statusErr.RetryAfter, maxBackoff)
+		}
+		backoff = min(backoff*2, maxBackoff)
+		slog.Warn("LLM request failed, retrying", "attempt", attempt, "delay", delay.Round(time.Millisecond), "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return zero, ctx.Err()
+		}
+	}
+}
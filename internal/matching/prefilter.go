@@ -0,0 +1,149 @@
+package matching
+
+import (
+	"bd_bot/internal/ai"
+	"bd_bot/internal/repository"
+	"bd_bot/internal/scraper"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// embeddedDocumentExcerpt is how much attachment text contributes to a
+// solicitation's embedding
+const embeddedDocumentExcerpt = 2000
+
+// Prefilter ranks solicitations by embedding similarity to a narrative so
+// that only the closest ones are sent to the LLM. Vectors are cached in the
+// embeddings table and recomputed when the embedded text changes.
+type Prefilter struct {
+	embedder *ai.Embedder
+	repo     *repository.EmbeddingRepository
+}
+
+func NewPrefilter(embedder *ai.Embedder, repo *repository.EmbeddingRepository) *Prefilter {
+	return &Prefilter{embedder: embedder, repo: repo}
+}
+
+// TopK returns the k solicitations most similar to the subject's narrative,
+// most similar first. Solicitations are returned unchanged if there are k or fewer.
+func (p *Prefilter) TopK(ctx context.Context, subject Subject, sols []scraper.Solicitation, k int) ([]scraper.Solicitation, error) {
+	if k <= 0 || len(sols) <= k {
+		return sols, nil
+	}
+
+	narrative, err := p.narrativeVector(ctx, subject)
+	if err != nil {
+		return nil, fmt.Errorf("narrative embedding: %w", err)
+	}
+	vectors, err := p.solicitationVectors(ctx, sols)
+	if err != nil {
+		return nil, fmt.Errorf("solicitation embeddings: %w", err)
+	}
+
+	type ranked struct {
+		sol        scraper.Solicitation
+		similarity float64
+	}
+	ranking := make([]ranked, len(sols))
+	for i, sol := range sols {
+		ranking[i] = ranked{sol: sol, similarity: ai.Cosine(narrative, vectors[sol.ID])}
+	}
+	sort.SliceStable(ranking, func(i, j int) bool { return ranking[i].similarity > ranking[j].similarity })
+
+	top := make([]scraper.Solicitation, k)
+	for i := range top {
+		top[i] = ranking[i].sol
+	}
+	return top, nil
+}
+
+func (p *Prefilter) narrativeVector(ctx context.Context, subject Subject) ([]float32, error) {
+	hash := contentHash(subject.Narrative)
+	stored, err := p.repo.Get(ctx, subject.embeddingType(), subject.ID, p.embedder.Model)
+	if err == nil && stored.ContentHash == hash {
+		return stored.Vector, nil
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	vectors, err := p.embedder.Embed(ctx, []string{subject.Narrative})
+	if err != nil {
+		return nil, err
+	}
+	e := repository.Embedding{EntityID: subject.ID, ContentHash: hash, Vector: vectors[0]}
+	if err := p.repo.Save(ctx, subject.embeddingType(), p.embedder.Model, e); err != nil {
+		return nil, err
+	}
+	return e.Vector, nil
+}
+
+// solicitationVectors returns a vector per solicitation ID, embedding only
+// those that are new or whose text changed since they were last embedded
+func (p *Prefilter) solicitationVectors(ctx context.Context, sols []scraper.Solicitation) (map[int][]float32, error) {
+	stored, err := p.repo.List(ctx, repository.EmbeddingSolicitation, p.embedder.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := make(map[int][]float32, len(sols))
+	var stale []repository.Embedding
+	var texts []string
+	for _, sol := range sols {
+		text := solicitationText(sol)
+		hash := contentHash(text)
+		if e, ok := stored[sol.ID]; ok && e.ContentHash == hash {
+			vectors[sol.ID] = e.Vector
+			continue
+		}
+		stale = append(stale, repository.Embedding{EntityID: sol.ID, ContentHash: hash})
+		texts = append(texts, text)
+	}
+	if len(stale) == 0 {
+		return vectors, nil
+	}
+
+	slog.Info("Embedding solicitations", "count", len(stale), "model", p.embedder.Model)
+	embedded, err := p.embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	for i, e := range stale {
+		e.Vector = embedded[i]
+		if err := p.repo.Save(ctx, repository.EmbeddingSolicitation, p.embedder.Model, e); err != nil {
+			return nil, err
+		}
+		vectors[e.EntityID] = e.Vector
+	}
+	return vectors, nil
+}
+
+func solicitationText(sol scraper.Solicitation) string {
+	var b strings.Builder
+	b.WriteString(sol.Title)
+	b.WriteString("\n")
+	b.WriteString(sol.Agency)
+	b.WriteString("\n")
+	b.WriteString(sol.Description)
+	if sol.DocumentText != "" {
+		excerpt := []rune(sol.DocumentText)
+		if len(excerpt) > embeddedDocumentExcerpt {
+			excerpt = excerpt[:embeddedDocumentExcerpt]
+		}
+		b.WriteString("\n")
+		b.WriteString(string(excerpt))
+	}
+	return b.String()
+}
+
+func contentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
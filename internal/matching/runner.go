@@ -0,0 +1,343 @@
+package matching
+
+import (
+	"bd_bot/internal/ai"
+	"bd_bot/internal/prompt"
+	"bd_bot/internal/repository"
+	"bd_bot/internal/scraper"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// solicitationLister is the part of repository.SolicitationRepository a run reads
+type solicitationLister interface {
+	List(ctx context.Context) ([]scraper.Solicitation, error)
+}
+
+// matchStore is the part of repository.MatchRepository a run reads and writes
+type matchStore interface {
+	GetScoredHashes(ctx context.Context, userID int) (map[int]repository.ScoredHashes, error)
+	GetOrganizationScoredHashes(ctx context.Context, orgID int) (map[int]repository.ScoredHashes, error)
+	Upsert(ctx context.Context, userID, solicitationID, score int, explanation string, details json.RawMessage, hashes repository.ScoredHashes) error
+	UpsertOrganization(ctx context.Context, orgID, solicitationID, score int, explanation string, details json.RawMessage, hashes repository.ScoredHashes) error
+	RecentFeedback(ctx context.Context, userID, perRating int) ([]repository.FeedbackExample, error)
+}
+
+// Runner scores solicitations against user narratives and stores the results
+type Runner struct {
+	userRepo  *repository.UserRepository
+	orgRepo   *repository.OrganizationRepository
+	solRepo   solicitationLister
+	matchRepo matchStore
+	matcher   *ai.Matcher
+	prefilter *Prefilter
+	limits    Limits
+	limiter   *limiter
+}
+
+// Stats summarizes a matching run
+type Stats struct {
+	Scored int `json:"scored"`
+	Saved  int `json:"saved"`
+	Failed int `json:"failed"`
+	// AboveThreshold counts saved scores that reach the user's threshold
+	AboveThreshold int `json:"above_threshold"`
+	// Filtered counts solicitations the embedding pre-filter kept from the LLM
+	Filtered int `json:"filtered"`
+	// Unchanged counts pairs skipped because neither side changed since they were scored
+	Unchanged int `json:"unchanged"`
+}
+
+func (s *Stats) add(o Stats) {
+	s.Scored += o.Scored
+	s.Saved += o.Saved
+	s.Failed += o.Failed
+	s.AboveThreshold += o.AboveThreshold
+	s.Filtered += o.Filtered
+	s.Unchanged += o.Unchanged
+}
+
+// Options narrows a matching run
+type Options struct {
+	// Since skips solicitations that have not changed since this time, unless
+	// the user's narrative changed. Zero considers every solicitation.
+	Since time.Time
+	// Full re-scores every pair even when neither side changed
+	Full bool
+
+	// Progress, if set, is called once scoring starts and after each
+	// solicitation finishes
+	Progress func(Progress)
+	// Scored, if set, is called after each score is saved
+	Scored func(ScoreEvent)
+}
+
+// ScoreEvent describes one saved score
+type ScoreEvent struct {
+	SolicitationID int    `json:"solicitation_id"`
+	SourceID       string `json:"source_id"`
+	Title          string `json:"title"`
+	Score          int    `json:"score"`
+	AboveThreshold bool   `json:"above_threshold"`
+}
+
+// NewRunner creates a runner. prefilter may be nil to score every solicitation.
+// The request rate limit is shared by every run of the runner.
+func NewRunner(userRepo *repository.UserRepository, orgRepo *repository.OrganizationRepository, solRepo *repository.SolicitationRepository, matchRepo *repository.MatchRepository, matcher *ai.Matcher, prefilter *Prefilter, limits Limits) *Runner {
+	return &Runner{
+		userRepo:  userRepo,
+		orgRepo:   orgRepo,
+		solRepo:   solRepo,
+		matchRepo: matchRepo,
+		matcher:   matcher,
+		prefilter: prefilter,
+		limits:    limits,
+		limiter:   newLimiter(limits.RequestsPerMinute),
+	}
+}
+
+// RunForUser scores solicitations against the user's narrative into their inbox
+func (r *Runner) RunForUser(ctx context.Context, user *repository.User, opts Options) (Stats, error) {
+	return r.Run(ctx, UserSubject(user), opts)
+}
+
+// RunForOrganization scores solicitations against the organization's
+// narrative into its shared inbox
+func (r *Runner) RunForOrganization(ctx context.Context, org *repository.Organization, opts Options) (Stats, error) {
+	return r.Run(ctx, OrganizationSubject(org), opts)
+}
+
+// Run scores solicitations against the subject's narrative. With a
+// prefilter, only the subject's TopK most similar solicitations are scored.
+// Unless opts.Full is set, pairs whose narrative, solicitation content and
+// match prompt version are unchanged since they were last scored are skipped. A user's recent match
+// ratings of other solicitations are shown to the matcher as examples.
+func (r *Runner) Run(ctx context.Context, subject Subject, opts Options) (Stats, error) {
+	var stats Stats
+
+	if subject.Narrative == "" {
+		slog.Warn("No narrative defined", "kind", subject.Kind, "id", subject.ID)
+		return stats, nil
+	}
+
+	sols, err := r.solRepo.List(ctx)
+	if err != nil {
+		return stats, err
+	}
+
+	if r.prefilter != nil && subject.TopK > 0 && len(sols) > subject.TopK {
+		top, err := r.prefilter.TopK(ctx, subject, sols, subject.TopK)
+		if err != nil {
+			// Slower, but still correct
+			slog.Error("Embedding pre-filter failed, scoring every solicitation", subject.Kind, subject.Name, "error", err)
+		} else {
+			stats.Filtered = len(sols) - len(top)
+			sols = top
+		}
+	}
+
+	narrativeHash := contentHash(subject.Narrative)
+	if !opts.Full {
+		promptVersion := -1
+		if p, err := r.matcher.Prompts.Current(ctx, prompt.Match); err != nil {
+			slog.Warn("Failed to load the match prompt, not checking scores for prompt changes", "error", err)
+		} else {
+			promptVersion = p.Version
+		}
+		changed, err := r.changedSince(ctx, subject, narrativeHash, promptVersion, sols, opts.Since)
+		if err != nil {
+			return stats, err
+		}
+		stats.Unchanged = len(sols) - len(changed)
+		sols = changed
+	}
+
+	rated := r.feedbackExamples(ctx, subject)
+
+	slog.Info("Starting Matching", subject.Kind, subject.Name, "solicitations", len(sols), "filtered", stats.Filtered, "unchanged", stats.Unchanged, "rated", len(rated))
+
+	type outcome struct {
+		sol    scraper.Solicitation
+		result *ai.MatchResult
+		err    error
+	}
+
+	jobs := make(chan scraper.Solicitation)
+	outcomes := make(chan outcome)
+	var wg sync.WaitGroup
+	for range max(r.limits.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sol := range jobs {
+				examples := examplesFor(rated, sol.ID)
+				result, err := withRetry(ctx, r.limiter, func() (*ai.MatchResult, error) {
+					return r.matcher.Match(ctx, subject.Narrative, examples, sol)
+				})
+				outcomes <- outcome{sol: sol, result: result, err: err}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, sol := range sols {
+			select {
+			case jobs <- sol:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	// Results are saved from this goroutine only
+	progress := Progress{User: subject.Name, Total: len(sols)}
+	start := time.Now()
+	opts.reportProgress(progress)
+	for o := range outcomes {
+		if ctx.Err() != nil {
+			// Drain; requests cut short by cancellation are not failures
+			continue
+		}
+		progress.Done++
+		progress.Elapsed = time.Since(start)
+
+		if o.err != nil {
+			slog.Error("Match failed", "sol_id", o.sol.ID, "error", o.err)
+			stats.Failed++
+			progress.Failed++
+			opts.reportProgress(progress)
+			continue
+		}
+		stats.Scored++
+		opts.reportProgress(progress)
+
+		slog.Debug("Match Result", "sol", o.sol.Title, "score", o.result.Score)
+
+		// Every score is stored; the threshold is applied when reading
+		hashes := repository.ScoredHashes{NarrativeHash: narrativeHash, ContentHash: matchContentHash(o.sol), PromptVersion: o.result.PromptVersion}
+		details, _ := json.Marshal(o.result.Details())
+		if err := r.saveScore(ctx, subject, o.sol.ID, o.result.Score, o.result.Explanation, details, hashes); err != nil {
+			slog.Error("Failed to save match", "error", err)
+			continue
+		}
+		stats.Saved++
+		above := o.result.Score >= subject.Threshold
+		if above {
+			stats.AboveThreshold++
+		}
+		if opts.Scored != nil {
+			opts.Scored(ScoreEvent{SolicitationID: o.sol.ID, SourceID: o.sol.SourceID, Title: o.sol.Title, Score: o.result.Score, AboveThreshold: above})
+		}
+	}
+
+	return stats, ctx.Err()
+}
+
+func (o Options) reportProgress(p Progress) {
+	if o.Progress != nil {
+		o.Progress(p)
+	}
+}
+
+// changedSince drops solicitations the subject already has a score for that was
+// computed from the same narrative, content and match prompt version. With a
+// non-zero since, it also drops solicitations with a hashed score from the
+// same prompt version that were not updated since then, unless the narrative
+// changed. A negative promptVersion means it is unknown and not compared.
+func (r *Runner) changedSince(ctx context.Context, subject Subject, narrativeHash string, promptVersion int, sols []scraper.Solicitation, since time.Time) ([]scraper.Solicitation, error) {
+	scored, err := r.scoredHashes(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scores from before hashes were tracked don't say which narrative they
+	// came from, so a subject with only those is treated as changed
+	narrativeChanged := true
+	for _, h := range scored {
+		if h.NarrativeHash == "" {
+			continue
+		}
+		if h.NarrativeHash != narrativeHash {
+			narrativeChanged = true
+			break
+		}
+		narrativeChanged = false
+	}
+
+	var changed []scraper.Solicitation
+	for _, sol := range sols {
+		h, ok := scored[sol.ID]
+		// A score from another prompt version counts as missing
+		ok = ok && h.NarrativeHash != "" && (promptVersion < 0 || h.PromptVersion == promptVersion)
+		if ok && !since.IsZero() && !narrativeChanged && sol.UpdatedAt.Before(since) {
+			continue
+		}
+		if ok && h.NarrativeHash == narrativeHash && h.ContentHash == matchContentHash(sol) {
+			continue
+		}
+		changed = append(changed, sol)
+	}
+	return changed, nil
+}
+
+// RunForAllUsers runs matching for every user that has a narrative
+func (r *Runner) RunForAllUsers(ctx context.Context, opts Options) (Stats, error) {
+	var total Stats
+
+	users, err := r.userRepo.List(ctx)
+	if err != nil {
+		return total, err
+	}
+
+	for i := range users {
+		user, err := r.userRepo.FindByID(ctx, users[i].ID)
+		if err != nil {
+			slog.Error("Failed to load user for matching", "id", users[i].ID, "error", err)
+			continue
+		}
+		if user.Narrative == "" {
+			continue
+		}
+
+		stats, err := r.RunForUser(ctx, user, opts)
+		total.add(stats)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// RunForAllOrganizations runs matching for every organization that has a narrative
+func (r *Runner) RunForAllOrganizations(ctx context.Context, opts Options) (Stats, error) {
+	var total Stats
+
+	orgs, err := r.orgRepo.ListWithNarrative(ctx)
+	if err != nil {
+		return total, err
+	}
+
+	for i := range orgs {
+		stats, err := r.RunForOrganization(ctx, &orgs[i], opts)
+		total.add(stats)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// matchContentHash covers every solicitation field the matcher reads
+func matchContentHash(sol scraper.Solicitation) string {
+	return contentHash(strings.Join([]string{sol.Title, sol.Agency, sol.Description, sol.DocumentText}, "\x00"))
+}
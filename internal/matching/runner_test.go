@@ -0,0 +1,300 @@
+package matching
+
+import (
+	"bd_bot/internal/ai"
+	"bd_bot/internal/prompt"
+	"bd_bot/internal/repository"
+	"bd_bot/internal/scraper"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type fixedSolicitations []scraper.Solicitation
+
+func (f fixedSolicitations) List(ctx context.Context) ([]scraper.Solicitation, error) {
+	return f, nil
+}
+
+type storedScore struct {
+	score  int
+	hashes repository.ScoredHashes
+}
+
+// memoryMatches is a matchStore for one user and one organization
+type memoryMatches struct {
+	user, org map[int]storedScore
+	feedback  []repository.FeedbackExample
+}
+
+func newMemoryMatches() *memoryMatches {
+	return &memoryMatches{user: make(map[int]storedScore), org: make(map[int]storedScore)}
+}
+
+func hashesOf(scores map[int]storedScore) map[int]repository.ScoredHashes {
+	out := make(map[int]repository.ScoredHashes)
+	for id, s := range scores {
+		out[id] = s.hashes
+	}
+	return out
+}
+
+func (m *memoryMatches) GetScoredHashes(ctx context.Context, userID int) (map[int]repository.ScoredHashes, error) {
+	return hashesOf(m.user), nil
+}
+
+func (m *memoryMatches) GetOrganizationScoredHashes(ctx context.Context, orgID int) (map[int]repository.ScoredHashes, error) {
+	return hashesOf(m.org), nil
+}
+
+func (m *memoryMatches) Upsert(ctx context.Context, userID, solicitationID, score int, explanation string, details json.RawMessage, hashes repository.ScoredHashes) error {
+	m.user[solicitationID] = storedScore{score: score, hashes: hashes}
+	return nil
+}
+
+func (m *memoryMatches) UpsertOrganization(ctx context.Context, orgID, solicitationID, score int, explanation string, details json.RawMessage, hashes repository.ScoredHashes) error {
+	m.org[solicitationID] = storedScore{score: score, hashes: hashes}
+	return nil
+}
+
+func (m *memoryMatches) RecentFeedback(ctx context.Context, userID, perRating int) ([]repository.FeedbackExample, error) {
+	return m.feedback, nil
+}
+
+// promptRecorder answers like FakeProvider and keeps the prompts it is sent
+type promptRecorder struct {
+	ai.FakeProvider
+
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (p *promptRecorder) Complete(ctx context.Context, req ai.ChatRequest) (string, error) {
+	p.mu.Lock()
+	p.prompts = append(p.prompts, req.Messages[0].Content)
+	p.mu.Unlock()
+	return p.FakeProvider.Complete(ctx, req)
+}
+
+func testSolicitations() fixedSolicitations {
+	return fixedSolicitations{
+		{ID: 1, SourceID: "A-1", Title: "Autonomous swarms", Agency: "DARPA", Description: "UAS swarm autonomy research"},
+		{ID: 2, SourceID: "A-2", Title: "Janitorial services", Agency: "GSA", Description: "Office cleaning"},
+		{ID: 3, SourceID: "A-3", Title: "Maritime sensing", Agency: "ONR", Description: "Undersea sensor networks"},
+	}
+}
+
+func newTestRunner(provider ai.LLMProvider, sols fixedSolicitations, matches *memoryMatches) *Runner {
+	return &Runner{
+		solRepo:   sols,
+		matchRepo: matches,
+		matcher:   ai.NewMatcher(provider, nil),
+		limits:    Limits{Concurrency: 2},
+	}
+}
+
+var testUser = Subject{Kind: SubjectUser, ID: 7, Name: "jdoe@example.com", Narrative: "We build autonomy for unmanned systems", Threshold: 75}
+
+func TestRunScoresEverySolicitation(t *testing.T) {
+	matches := newMemoryMatches()
+	runner := newTestRunner(&ai.FakeProvider{}, testSolicitations(), matches)
+
+	var events []ScoreEvent
+	stats, err := runner.Run(context.Background(), testUser, Options{Scored: func(e ScoreEvent) { events = append(events, e) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Scored != 3 || stats.Saved != 3 || stats.Failed != 0 || stats.Unchanged != 0 {
+		t.Fatalf("stats = %+v, want 3 scored and saved", stats)
+	}
+	if len(matches.user) != 3 || len(events) != 3 {
+		t.Fatalf("%d scores saved and %d events, want 3", len(matches.user), len(events))
+	}
+
+	above := 0
+	for _, e := range events {
+		s := matches.user[e.SolicitationID]
+		// Scores below the threshold are stored as they are
+		if s.score != e.Score || s.score < 0 || s.score > 100 {
+			t.Errorf("solicitation %d: stored %d, reported %d", e.SolicitationID, s.score, e.Score)
+		}
+		if e.AboveThreshold != (e.Score >= testUser.Threshold) {
+			t.Errorf("solicitation %d: above threshold = %v for score %d", e.SolicitationID, e.AboveThreshold, e.Score)
+		}
+		if e.AboveThreshold {
+			above++
+		}
+		if s.hashes.NarrativeHash == "" || s.hashes.ContentHash == "" {
+			t.Errorf("solicitation %d saved without hashes", e.SolicitationID)
+		}
+	}
+	if stats.AboveThreshold != above {
+		t.Errorf("AboveThreshold = %d, want %d", stats.AboveThreshold, above)
+	}
+}
+
+func TestRunSkipsUnchanged(t *testing.T) {
+	sols := testSolicitations()
+	matches := newMemoryMatches()
+	runner := newTestRunner(&ai.FakeProvider{}, sols, matches)
+	ctx := context.Background()
+
+	if _, err := runner.Run(ctx, testUser, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, _ := runner.Run(ctx, testUser, Options{})
+	if stats.Scored != 0 || stats.Unchanged != 3 {
+		t.Errorf("rerun: %+v, want all 3 unchanged", stats)
+	}
+
+	stats, _ = runner.Run(ctx, testUser, Options{Full: true})
+	if stats.Scored != 3 {
+		t.Errorf("full rerun scored %d, want 3", stats.Scored)
+	}
+
+	sols[1].Description = "Office cleaning and waste removal"
+	stats, _ = runner.Run(ctx, testUser, Options{})
+	if stats.Scored != 1 || stats.Unchanged != 2 {
+		t.Errorf("after a solicitation changed: %+v, want 1 scored", stats)
+	}
+
+	edited := testUser
+	edited.Narrative += " and sensors"
+	stats, _ = runner.Run(ctx, edited, Options{})
+	if stats.Scored != 3 {
+		t.Errorf("after the narrative changed scored %d, want 3", stats.Scored)
+	}
+
+	// Organizations keep their own scores
+	org := Subject{Kind: SubjectOrganization, ID: 1, Name: "Acme", Narrative: testUser.Narrative, Threshold: 60}
+	stats, _ = runner.Run(ctx, org, Options{})
+	if stats.Scored != 3 || len(matches.org) != 3 {
+		t.Errorf("organization: %+v with %d stored, want 3 scored", stats, len(matches.org))
+	}
+}
+
+func TestRunStoresScoresBelowThreshold(t *testing.T) {
+	matches := newMemoryMatches()
+	provider := &ai.FakeProvider{Replies: []string{`{"score": 40, "explanation": "Weak fit", "confidence": 0.8,
+		"criteria": [], "matched_keywords": [], "disqualifiers": []}`}}
+	runner := newTestRunner(provider, testSolicitations(), matches)
+
+	stats, err := runner.Run(context.Background(), testUser, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Saved != 3 || stats.AboveThreshold != 0 {
+		t.Fatalf("stats = %+v, want 3 saved and none above the threshold", stats)
+	}
+	// The real score is kept, not a placeholder, so that lowering the
+	// threshold shows these matches without re-scoring them
+	for id, s := range matches.user {
+		if s.score != 40 {
+			t.Errorf("solicitation %d stored with score %d, want 40", id, s.score)
+		}
+	}
+}
+
+func TestRunRescoresOtherPromptVersions(t *testing.T) {
+	matches := newMemoryMatches()
+	runner := newTestRunner(&ai.FakeProvider{}, testSolicitations(), matches)
+	ctx := context.Background()
+	if _, err := runner.Run(ctx, testUser, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	def, err := prompt.Default(prompt.Match)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := *def
+	edited.Version = 4
+	runner.matcher.Prompts = prompt.Pinned(&edited)
+
+	stats, _ := runner.Run(ctx, testUser, Options{})
+	if stats.Scored != 3 {
+		t.Fatalf("after a prompt change scored %d, want 3", stats.Scored)
+	}
+	for id, s := range matches.user {
+		if s.hashes.PromptVersion != 4 {
+			t.Errorf("solicitation %d saved with prompt version %d, want 4", id, s.hashes.PromptVersion)
+		}
+	}
+	if stats, _ := runner.Run(ctx, testUser, Options{}); stats.Scored != 0 {
+		t.Errorf("rerun with the same prompt scored %d, want 0", stats.Scored)
+	}
+}
+
+func TestRunCountsFailures(t *testing.T) {
+	matches := newMemoryMatches()
+	provider := &ai.FakeProvider{Replies: []string{`{"score": 90}`}}
+	runner := newTestRunner(provider, testSolicitations(), matches)
+
+	var last Progress
+	stats, err := runner.Run(context.Background(), testUser, Options{Progress: func(p Progress) { last = p }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Failed != 3 || stats.Saved != 0 || len(matches.user) != 0 {
+		t.Errorf("stats = %+v with %d stored, want 3 failed and nothing saved", stats, len(matches.user))
+	}
+	if last.Done != 3 || last.Failed != 3 || last.Total != 3 {
+		t.Errorf("last progress = %+v", last)
+	}
+
+	// Failed pairs have no score, so they are retried on the next run
+	provider.Replies = nil
+	if stats, _ := runner.Run(context.Background(), testUser, Options{}); stats.Scored != 3 {
+		t.Errorf("next run scored %d, want 3", stats.Scored)
+	}
+}
+
+func TestRunFeedbackExamples(t *testing.T) {
+	sols := testSolicitations()
+	matches := newMemoryMatches()
+	matches.feedback = []repository.FeedbackExample{
+		{SolicitationID: 1, Title: "Autonomous swarms", Agency: "DARPA", Description: "UAS swarm autonomy research", Score: 88, Rating: repository.RatingUp},
+		{SolicitationID: 99, Title: "Counter-UAS radar", Agency: "Army", Description: "Radar for small drones", Score: 40, Rating: repository.RatingUp},
+	}
+	provider := &promptRecorder{}
+	runner := newTestRunner(provider, sols, matches)
+
+	if _, err := runner.Run(context.Background(), testUser, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.prompts) != 3 {
+		t.Fatalf("%d prompts, want 3", len(provider.prompts))
+	}
+	for _, p := range provider.prompts {
+		if !strings.Contains(p, "Counter-UAS radar") {
+			t.Errorf("prompt is missing the rated example:\n%s", p)
+		}
+		// The solicitation's own rating is never shown while scoring it
+		if strings.Contains(p, "UAS swarm autonomy research") && strings.Count(p, "Autonomous swarms") > 1 {
+			t.Errorf("solicitation 1 was shown as its own example:\n%s", p)
+		}
+	}
+
+	// Organizations are scored without examples
+	provider.prompts = nil
+	org := Subject{Kind: SubjectOrganization, ID: 1, Name: "Acme", Narrative: testUser.Narrative}
+	if _, err := runner.Run(context.Background(), org, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range provider.prompts {
+		if strings.Contains(p, "Counter-UAS radar") {
+			t.Errorf("organization prompt includes a user's example:\n%s", p)
+		}
+	}
+}
+
+func TestRunWithoutNarrative(t *testing.T) {
+	runner := newTestRunner(&ai.FakeProvider{}, testSolicitations(), newMemoryMatches())
+	stats, err := runner.Run(context.Background(), Subject{Kind: SubjectUser, ID: 1}, Options{})
+	if err != nil || stats != (Stats{}) {
+		t.Errorf("Run() = %+v, %v; want nothing done", stats, err)
+	}
+}
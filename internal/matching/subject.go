@@ -0,0 +1,135 @@
+package matching
+
+import (
+	"bd_bot/internal/prompt"
+	"bd_bot/internal/repository"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+)
+
+// Subject kinds
+const (
+	SubjectUser         = "user"
+	SubjectOrganization = "organization"
+)
+
+// Subject is a narrative that solicitations are scored against, either a
+// user's or an organization's, along with its matching settings
+type Subject struct {
+	Kind      string
+	ID        int
+	Name      string
+	Narrative string
+	Threshold int
+	TopK      int
+}
+
+func UserSubject(u *repository.User) Subject {
+	return Subject{Kind: SubjectUser, ID: u.ID, Name: u.Email, Narrative: u.Narrative, Threshold: u.MatchThreshold, TopK: u.MatchTopK}
+}
+
+func OrganizationSubject(o *repository.Organization) Subject {
+	return Subject{Kind: SubjectOrganization, ID: o.ID, Name: o.Name, Narrative: o.Narrative, Threshold: o.MatchThreshold, TopK: o.MatchTopK}
+}
+
+// embeddingType is where the subject's narrative vector is cached
+func (s Subject) embeddingType() string {
+	if s.Kind == SubjectOrganization {
+		return repository.EmbeddingOrganizationNarrative
+	}
+	return repository.EmbeddingNarrative
+}
+
+// scoredHashes loads the hashes of the subject's stored scores
+func (r *Runner) scoredHashes(ctx context.Context, s Subject) (map[int]repository.ScoredHashes, error) {
+	if s.Kind == SubjectOrganization {
+		return r.matchRepo.GetOrganizationScoredHashes(ctx, s.ID)
+	}
+	return r.matchRepo.GetScoredHashes(ctx, s.ID)
+}
+
+// saveScore stores a score in the subject's inbox
+func (r *Runner) saveScore(ctx context.Context, s Subject, solicitationID, score int, explanation string, details json.RawMessage, hashes repository.ScoredHashes) error {
+	if s.Kind == SubjectOrganization {
+		return r.matchRepo.UpsertOrganization(ctx, s.ID, solicitationID, score, explanation, details, hashes)
+	}
+	return r.matchRepo.Upsert(ctx, s.ID, solicitationID, score, explanation, details, hashes)
+}
+
+// Few-shot context from feedback
+const (
+	// feedbackExamplesPerRating is how many recent thumbs-up, and as many
+	// thumbs-down, are shown to the matcher
+	feedbackExamplesPerRating = 3
+	// maxExampleSummary bounds the description excerpt of each example
+	maxExampleSummary = 200
+)
+
+// ratedExample is a few-shot example and the solicitation it is about
+type ratedExample struct {
+	solicitationID int
+	example        prompt.MatchExample
+}
+
+// feedbackExamples loads the subject's recent match ratings as few-shot
+// examples. One more of each rating than is shown is loaded, so that
+// examplesFor can leave out the solicitation being scored. Organizations have
+// none. Failing to load them is not fatal.
+func (r *Runner) feedbackExamples(ctx context.Context, s Subject) []ratedExample {
+	if s.Kind != SubjectUser {
+		return nil
+	}
+	rated, err := r.matchRepo.RecentFeedback(ctx, s.ID, feedbackExamplesPerRating+1)
+	if err != nil {
+		slog.Error("Failed to load match feedback, scoring without examples", "user", s.Name, "error", err)
+		return nil
+	}
+	return ratedExamples(rated)
+}
+
+// ratedExamples turns match ratings into few-shot examples
+func ratedExamples(rated []repository.FeedbackExample) []ratedExample {
+	examples := make([]ratedExample, 0, len(rated))
+	for _, f := range rated {
+		summary := []rune(strings.Join(strings.Fields(f.Description), " "))
+		if len(summary) > maxExampleSummary {
+			summary = append(summary[:maxExampleSummary], '…')
+		}
+		examples = append(examples, ratedExample{
+			solicitationID: f.SolicitationID,
+			example: prompt.MatchExample{
+				Title:    f.Title,
+				Agency:   f.Agency,
+				Summary:  string(summary),
+				Score:    f.Score,
+				Relevant: f.Rating == repository.RatingUp,
+			},
+		})
+	}
+	return examples
+}
+
+// examplesFor picks the examples shown when scoring one solicitation: up to
+// feedbackExamplesPerRating of each rating, newest first, never the
+// solicitation itself, whose rating would give the answer away
+func examplesFor(rated []ratedExample, solicitationID int) []prompt.MatchExample {
+	var examples []prompt.MatchExample
+	up, down := 0, 0
+	for _, r := range rated {
+		if r.solicitationID == solicitationID {
+			continue
+		}
+		count := &down
+		if r.example.Relevant {
+			count = &up
+		}
+		if *count >= feedbackExamplesPerRating {
+			continue
+		}
+		*count++
+		examples = append(examples, r.example)
+	}
+	return examples
+}
@@ -0,0 +1,207 @@
+package notify
+
+import (
+	"bd_bot/internal/mail"
+	"bd_bot/internal/repository"
+	"context"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// deadlineWindow is how far ahead claimed due dates are included in a digest
+	deadlineWindow = 7 * 24 * time.Hour
+	// digestSlack lets a digest go out slightly early so a daily run that
+	// drifts by a few minutes is not pushed back a whole day
+	digestSlack = time.Hour
+)
+
+// DigestMatch is a new inbox match as shown in a digest
+type DigestMatch struct {
+	Title   string
+	Agency  string
+	DueDate string
+	Score   int
+	Link    string
+}
+
+// DigestDeadline is an approaching deadline as shown in a digest
+type DigestDeadline struct {
+	Title     string
+	DueDate   string
+	ClaimType string
+	Link      string
+}
+
+// DigestComment is a new comment as shown in a digest
+type DigestComment struct {
+	Title   string
+	Author  string
+	Content string
+	Link    string
+}
+
+// Digest is the data passed to the digest email template
+type Digest struct {
+	Name        string
+	Period      string
+	Threshold   int
+	Matches     []DigestMatch
+	Deadlines   []DigestDeadline
+	Comments    []DigestComment
+	InboxLink   string
+	ProfileLink string
+}
+
+func (d *Digest) empty() bool {
+	return len(d.Matches) == 0 && len(d.Deadlines) == 0 && len(d.Comments) == 0
+}
+
+// Digester emails each user a summary of their inbox at the frequency chosen in their profile
+type Digester struct {
+	userRepo  *repository.UserRepository
+	matchRepo *repository.MatchRepository
+	solRepo   *repository.SolicitationRepository
+	outbox    *mail.Outbox
+	publicURL string
+}
+
+func NewDigester(userRepo *repository.UserRepository, matchRepo *repository.MatchRepository, solRepo *repository.SolicitationRepository, outbox *mail.Outbox, publicURL string) *Digester {
+	return &Digester{
+		userRepo:  userRepo,
+		matchRepo: matchRepo,
+		solRepo:   solRepo,
+		outbox:    outbox,
+		publicURL: strings.TrimRight(publicURL, "/"),
+	}
+}
+
+// SendDue queues a digest for every user whose daily or weekly digest is due
+// and returns the number queued. Users with nothing to report are skipped and
+// stay due, so the next digest covers the whole gap.
+func (d *Digester) SendDue(ctx context.Context, now time.Time) (int, error) {
+	users, err := d.userRepo.ListDigestRecipients(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+
+		period := digestPeriod(user.DigestFrequency)
+		if period == 0 {
+			continue
+		}
+		since := now.Add(-period)
+		if user.DigestSentAt != nil {
+			if now.Sub(*user.DigestSentAt) < period-digestSlack {
+				continue
+			}
+			since = *user.DigestSentAt
+		}
+
+		digest, err := d.build(ctx, &user, since, now)
+		if err != nil {
+			slog.Error("Failed to build digest", "user_id", user.ID, "error", err)
+			continue
+		}
+		if digest.empty() {
+			continue
+		}
+
+		if err := d.outbox.EnqueueTemplate(ctx, "digest", user.Email, digest); err != nil {
+			slog.Error("Failed to queue digest", "user_id", user.ID, "error", err)
+			continue
+		}
+		if err := d.userRepo.MarkDigestSent(ctx, user.ID, now); err != nil {
+			slog.Error("Failed to record digest", "user_id", user.ID, "error", err)
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// build collects new matches at or above the user's threshold, claimed
+// solicitations due within deadlineWindow and new comments on claimed items
+func (d *Digester) build(ctx context.Context, user *repository.User, since, now time.Time) (*Digest, error) {
+	digest := &Digest{
+		Name:        user.FullName,
+		Period:      user.DigestFrequency,
+		Threshold:   user.MatchThreshold,
+		InboxLink:   d.publicURL + "/inbox",
+		ProfileLink: d.publicURL + "/profile",
+	}
+
+	inbox, err := d.matchRepo.GetUserInbox(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range inbox {
+		// New means it reached the threshold since the last digest, whether it
+		// was first scored, re-scored higher or let in by a lower threshold
+		if m.AboveThresholdAt == nil || !m.AboveThresholdAt.After(since) {
+			continue
+		}
+		digest.Matches = append(digest.Matches, DigestMatch{
+			Title:   m.Solicitation.Title,
+			Agency:  m.Solicitation.Agency,
+			DueDate: formatDueDate(m.Solicitation.DueDate),
+			Score:   m.Score,
+			Link:    d.link(m.Solicitation.SourceID),
+		})
+	}
+
+	deadlines, err := d.solRepo.ClaimedDueBefore(ctx, user.ID, now.Add(deadlineWindow))
+	if err != nil {
+		return nil, err
+	}
+	for _, dl := range deadlines {
+		digest.Deadlines = append(digest.Deadlines, DigestDeadline{
+			Title:     dl.Title,
+			DueDate:   formatDueDate(dl.DueDate),
+			ClaimType: dl.ClaimType,
+			Link:      d.link(dl.SourceID),
+		})
+	}
+
+	comments, err := d.solRepo.CommentsOnClaimedSince(ctx, user.ID, since)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range comments {
+		digest.Comments = append(digest.Comments, DigestComment{
+			Title:   c.Title,
+			Author:  c.UserFullName,
+			Content: c.Content,
+			Link:    d.link(c.SourceID),
+		})
+	}
+
+	return digest, nil
+}
+
+func (d *Digester) link(sourceID string) string {
+	return d.publicURL + "/solicitation/" + url.PathEscape(sourceID)
+}
+
+func digestPeriod(frequency string) time.Duration {
+	switch frequency {
+	case "daily":
+		return 24 * time.Hour
+	case "weekly":
+		return 7 * 24 * time.Hour
+	}
+	return 0
+}
+
+func formatDueDate(t time.Time) string {
+	if t.IsZero() {
+		return "N/A"
+	}
+	return t.Format("Jan 2, 2006")
+}
@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"bd_bot/internal/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Mailer delivers a plain-text email
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// LogMailer is a Mailer that only logs messages. It is used when no outbound
+// mail transport is configured.
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, to, subject, body string) error {
+	slog.Info("Email delivery not configured; skipping", "to", to, "subject", subject)
+	return nil
+}
+
+// notifyFields are the solicitation changes claimants are told about
+var notifyFields = []string{"due_date", "description", "documents"}
+
+// Notifier tells leads and interested parties when a claimed solicitation changes
+type Notifier struct {
+	solRepo   *repository.SolicitationRepository
+	notifRepo *repository.NotificationRepository
+	mailer    Mailer
+}
+
+func NewNotifier(solRepo *repository.SolicitationRepository, notifRepo *repository.NotificationRepository, mailer Mailer) *Notifier {
+	if mailer == nil {
+		mailer = LogMailer{}
+	}
+	return &Notifier{solRepo: solRepo, notifRepo: notifRepo, mailer: mailer}
+}
+
+// NotifyChangesSince creates a notification and sends an email to every
+// claimant of a solicitation revised at or after since. It returns the
+// number of notifications created.
+func (n *Notifier) NotifyChangesSince(ctx context.Context, since time.Time) (int, error) {
+	revisions, err := n.solRepo.RevisionsSince(ctx, since)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, rev := range revisions {
+		changes := relevantChanges(rev.Changes)
+		if len(changes) == 0 {
+			continue
+		}
+
+		detail, err := n.solRepo.GetByID(ctx, rev.SourceID)
+		if err != nil {
+			slog.Error("Failed to load claims for changed solicitation", "source_id", rev.SourceID, "error", err)
+			continue
+		}
+
+		title, body := describeChanges(rev.Title, changes)
+		data, _ := json.Marshal(map[string]interface{}{"revision_id": rev.ID, "changes": changes})
+
+		for _, claim := range detail.Claims {
+			// Archiving creates a claim row too; those users have opted out
+			if claim.Archived {
+				continue
+			}
+
+			solID := rev.SolicitationID
+			notification := &repository.Notification{
+				UserID:         claim.UserID,
+				SolicitationID: &solID,
+				Kind:           "solicitation_changed",
+				Title:          title,
+				Body:           body,
+				Data:           data,
+			}
+			if err := n.notifRepo.Create(ctx, notification); err != nil {
+				slog.Error("Failed to create notification", "user_id", claim.UserID, "error", err)
+				continue
+			}
+			sent++
+
+			emailBody := fmt.Sprintf("Hi %s,\n\nA solicitation you are tracking as %s has changed.\n\n%s\n\n%s\n", claim.User.FullName, claim.ClaimType, body, detail.URL)
+			if err := n.mailer.Send(ctx, claim.User.Email, "[JOSHUA] "+title, emailBody); err != nil {
+				slog.Error("Failed to email claimant", "user_id", claim.UserID, "error", err)
+			}
+		}
+	}
+
+	return sent, nil
+}
+
+func relevantChanges(changes map[string]repository.FieldChange) map[string]repository.FieldChange {
+	relevant := make(map[string]repository.FieldChange)
+	for _, field := range notifyFields {
+		if c, ok := changes[field]; ok {
+			relevant[field] = c
+		}
+	}
+	return relevant
+}
+
+// describeChanges builds a one-line title and a human readable summary
+func describeChanges(solTitle string, changes map[string]repository.FieldChange) (string, string) {
+	title := fmt.Sprintf("%s was amended", solTitle)
+	if _, ok := changes["due_date"]; ok {
+		title = fmt.Sprintf("Deadline moved: %s", solTitle)
+	}
+
+	fields := make([]string, 0, len(changes))
+	for field := range changes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var lines []string
+	for _, field := range fields {
+		c := changes[field]
+		switch field {
+		case "due_date":
+			lines = append(lines, fmt.Sprintf("- Due date changed from %s to %s", formatDate(c.Old), formatDate(c.New)))
+		case "description":
+			lines = append(lines, "- Description was updated")
+		case "documents":
+			lines = append(lines, "- Attached documents changed")
+		}
+	}
+	return title, strings.Join(lines, "\n")
+}
+
+func formatDate(v interface{}) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format("Jan 2, 2006 15:04")
+	case string:
+		if parsed, err := time.Parse(time.RFC3339, t); err == nil {
+			return parsed.Format("Jan 2, 2006 15:04")
+		}
+		return t
+	}
+	return "none"
+}
@@ -0,0 +1,299 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testClientID = "bd-bot"
+
+// testIssuer serves discovery and a JWKS with one RSA and one EC signing key
+type testIssuer struct {
+	srv    *httptest.Server
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss := &testIssuer{rsaKey: rsaKey, ecKey: ecKey}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 iss.srv.URL,
+			"authorization_endpoint": iss.srv.URL + "/authorize",
+			"token_endpoint":         iss.srv.URL + "/token",
+			"jwks_uri":               iss.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		b64 := base64.RawURLEncoding.EncodeToString
+		writeJSON(w, map[string]interface{}{"keys": []map[string]string{
+			{
+				"kty": "RSA", "kid": "rsa-1", "use": "sig",
+				"n": b64(rsaKey.N.Bytes()),
+				"e": b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+			},
+			{
+				"kty": "EC", "kid": "ec-1", "use": "sig", "crv": "P-256",
+				"x": b64(ecKey.X.FillBytes(make([]byte, 32))),
+				"y": b64(ecKey.Y.FillBytes(make([]byte, 32))),
+			},
+		}})
+	})
+	iss.srv = httptest.NewServer(mux)
+	t.Cleanup(iss.srv.Close)
+	return iss
+}
+
+func (iss *testIssuer) provider() *Provider {
+	return NewProvider(Config{IssuerURL: iss.srv.URL, ClientID: testClientID, RedirectURL: "http://app.test/callback"})
+}
+
+// claims returns valid ID token claims for nonce
+func (iss *testIssuer) claims(nonce string) map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss":   iss.srv.URL,
+		"aud":   testClientID,
+		"sub":   "user-1",
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": nonce,
+	}
+}
+
+// sign builds a token with the given header, signed with the key kid names.
+// alg only goes into the header, so it can disagree with the key.
+func (iss *testIssuer) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch kid {
+	case "rsa-1":
+		s, err := rsa.SignPKCS1v15(rand.Reader, iss.rsaKey, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = s
+	case "ec-1":
+		r, s, err := ecdsa.Sign(rand.Reader, iss.ecKey, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestVerify(t *testing.T) {
+	iss := newTestIssuer(t)
+	const nonce = "n-0S6_WzA2Mj"
+
+	with := func(change func(c map[string]interface{})) map[string]interface{} {
+		c := iss.claims(nonce)
+		change(c)
+		return c
+	}
+	tamper := func(token string) string {
+		parts := strings.Split(token, ".")
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		sig[0] ^= 0xff
+		return parts[0] + "." + parts[1] + "." + base64.RawURLEncoding.EncodeToString(sig)
+	}
+	swapPayload := func(token string, claims map[string]interface{}) string {
+		parts := strings.Split(token, ".")
+		payload, _ := json.Marshal(claims)
+		return parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"rs256", iss.sign(t, "RS256", "rsa-1", iss.claims(nonce)), ""},
+		{"es256", iss.sign(t, "ES256", "ec-1", iss.claims(nonce)), ""},
+		{"audience list", iss.sign(t, "RS256", "rsa-1", with(func(c map[string]interface{}) {
+			c["aud"] = []string{"other", testClientID}
+		})), ""},
+		{"expired within leeway", iss.sign(t, "RS256", "rsa-1", with(func(c map[string]interface{}) {
+			c["exp"] = time.Now().Add(-30 * time.Second).Unix()
+		})), ""},
+
+		{"bad rsa signature", tamper(iss.sign(t, "RS256", "rsa-1", iss.claims(nonce))), "invalid id_token signature"},
+		{"bad ec signature", tamper(iss.sign(t, "ES256", "ec-1", iss.claims(nonce))), "invalid id_token signature"},
+		{"payload swapped", swapPayload(iss.sign(t, "RS256", "rsa-1", iss.claims(nonce)), with(func(c map[string]interface{}) {
+			c["sub"] = "admin"
+		})), "invalid id_token signature"},
+		{"wrong audience", iss.sign(t, "RS256", "rsa-1", with(func(c map[string]interface{}) {
+			c["aud"] = "someone-else"
+		})), "audience"},
+		{"no audience", iss.sign(t, "RS256", "rsa-1", with(func(c map[string]interface{}) {
+			delete(c, "aud")
+		})), "audience"},
+		{"expired", iss.sign(t, "RS256", "rsa-1", with(func(c map[string]interface{}) {
+			c["exp"] = time.Now().Add(-2 * time.Minute).Unix()
+		})), "expired"},
+		{"no expiry", iss.sign(t, "RS256", "rsa-1", with(func(c map[string]interface{}) {
+			delete(c, "exp")
+		})), "expired"},
+		{"nonce mismatch", iss.sign(t, "RS256", "rsa-1", with(func(c map[string]interface{}) {
+			c["nonce"] = "replayed"
+		})), "nonce mismatch"},
+		{"no nonce", iss.sign(t, "RS256", "rsa-1", with(func(c map[string]interface{}) {
+			delete(c, "nonce")
+		})), "nonce mismatch"},
+		{"wrong issuer", iss.sign(t, "RS256", "rsa-1", with(func(c map[string]interface{}) {
+			c["iss"] = "https://evil.example.com"
+		})), "issuer"},
+		{"no subject", iss.sign(t, "RS256", "rsa-1", with(func(c map[string]interface{}) {
+			delete(c, "sub")
+		})), "no subject"},
+		{"es alg with rsa key", iss.sign(t, "ES256", "rsa-1", iss.claims(nonce)), "does not match algorithm"},
+		{"rs alg with ec key", iss.sign(t, "RS256", "ec-1", iss.claims(nonce)), "does not match algorithm"},
+		{"alg none", iss.sign(t, "none", "rsa-1", iss.claims(nonce)), "unsupported id_token algorithm"},
+		{"alg hs256", iss.sign(t, "HS256", "rsa-1", iss.claims(nonce)), "unsupported id_token algorithm"},
+		{"unknown key", iss.sign(t, "RS256", "rsa-2", iss.claims(nonce)), "unknown signing key"},
+		{"malformed", "not-a-token", "malformed"},
+	}
+
+	p := iss.provider()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := p.Verify(context.Background(), tt.token, nonce)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+				if claims.String("sub") != "user-1" {
+					t.Errorf("sub = %q, want user-1", claims.String("sub"))
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Verify() succeeded, want error containing %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Verify() error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestMockIssuerFlow runs the whole authorization code flow against MockIssuer
+func TestMockIssuerFlow(t *testing.T) {
+	var handler http.Handler
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	mock, err := NewMockIssuer(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler = mock.Handler()
+
+	// authorize signs in as the given user and returns the code from the redirect
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	authorize := func(t *testing.T, p *Provider, req *AuthRequest) string {
+		t.Helper()
+		authURL, err := p.AuthCodeURL(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, _ := url.Parse(authURL)
+		form := u.Query()
+		form.Set("sub", "jdoe")
+		form.Set("email", "jdoe@example.com")
+		form.Set("email_verified", "true")
+		form.Set("name", "Jane Doe")
+		resp, err := noRedirect.PostForm(srv.URL+"/authorize", form)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusFound {
+			t.Fatalf("authorize returned %d", resp.StatusCode)
+		}
+		loc, _ := url.Parse(resp.Header.Get("Location"))
+		if got := loc.Query().Get("state"); got != req.State {
+			t.Fatalf("state = %q, want %q", got, req.State)
+		}
+		return loc.Query().Get("code")
+	}
+
+	p := NewProvider(Config{IssuerURL: srv.URL, ClientID: testClientID, RedirectURL: "http://app.test/callback"})
+
+	t.Run("success", func(t *testing.T) {
+		req, err := NewAuthRequest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		claims, err := p.Exchange(context.Background(), authorize(t, p, req), req)
+		if err != nil {
+			t.Fatalf("Exchange() error = %v", err)
+		}
+		if claims.String("sub") != "jdoe" || claims.String("email") != "jdoe@example.com" || !claims.Bool("email_verified") {
+			t.Errorf("unexpected claims %v", claims)
+		}
+	})
+
+	t.Run("code reused", func(t *testing.T) {
+		req, _ := NewAuthRequest()
+		code := authorize(t, p, req)
+		if _, err := p.Exchange(context.Background(), code, req); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Exchange(context.Background(), code, req); err == nil {
+			t.Fatal("second Exchange() with the same code succeeded")
+		}
+	})
+
+	t.Run("wrong verifier", func(t *testing.T) {
+		req, _ := NewAuthRequest()
+		code := authorize(t, p, req)
+		other := *req
+		other.Verifier = "not-the-verifier"
+		if _, err := p.Exchange(context.Background(), code, &other); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+			t.Fatalf("Exchange() error = %v, want invalid_grant", err)
+		}
+	})
+
+	t.Run("wrong nonce", func(t *testing.T) {
+		req, _ := NewAuthRequest()
+		code := authorize(t, p, req)
+		other := *req
+		other.Nonce = "other-login"
+		if _, err := p.Exchange(context.Background(), code, &other); err == nil || !strings.Contains(err.Error(), "nonce mismatch") {
+			t.Fatalf("Exchange() error = %v, want a nonce mismatch", err)
+		}
+	})
+}
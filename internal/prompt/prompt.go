@@ -0,0 +1,125 @@
+// Package prompt holds the LLM prompts as versioned text/template templates.
+// Each prompt has a built-in default, version 0, that applies until an
+// admin saves a version of their own.
+package prompt
+
+import (
+	"bd_bot/internal/repository"
+	"embed"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Prompt names
+const (
+	Match = "match" // scores a solicitation against a narrative
+	Chat  = "chat"  // system prompt of the chat assistant
+)
+
+// MatchData is what the match prompt is rendered with
+type MatchData struct {
+	Narrative   string
+	Title       string
+	Agency      string
+	Description string
+	// Documents is an excerpt of the attachment text, empty if there is none
+	Documents string
+	// Examples are solicitations the user rated, most recent first
+	Examples []MatchExample
+}
+
+// MatchExample is a solicitation the user rated, shown to the model as a
+// few-shot example
+type MatchExample struct {
+	Title   string
+	Agency  string
+	Summary string
+	// Score is what the matcher gave it when it was rated
+	Score    int
+	Relevant bool
+}
+
+// ChatData is what the chat system prompt is rendered with
+type ChatData struct {
+	UserName     string
+	UserEmail    string
+	Role         string
+	Organization string
+	// Context describes what the user is looking at
+	Context string
+}
+
+// samples are used to check that a template renders before it is saved
+var samples = map[string]interface{}{
+	Match: MatchData{Narrative: "narrative", Title: "title", Agency: "agency", Description: "description", Documents: "documents",
+		Examples: []MatchExample{{Title: "title", Agency: "agency", Summary: "summary", Score: 50, Relevant: true}}},
+	Chat: ChatData{UserName: "name", UserEmail: "email", Role: "role", Organization: "organization", Context: "context"},
+}
+
+//go:embed defaults/*.tmpl
+var defaultsFS embed.FS
+
+// Names lists every prompt, sorted
+func Names() []string {
+	names := make([]string, 0, len(samples))
+	for name := range samples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Valid reports whether name is a known prompt
+func Valid(name string) bool {
+	_, ok := samples[name]
+	return ok
+}
+
+// Default returns the built-in version of a prompt
+func Default(name string) (*repository.PromptTemplate, error) {
+	if !Valid(name) {
+		return nil, fmt.Errorf("unknown prompt %q (use %s)", name, strings.Join(Names(), " or "))
+	}
+	body, err := defaultsFS.ReadFile("defaults/" + name + ".tmpl")
+	if err != nil {
+		return nil, err
+	}
+	return &repository.PromptTemplate{Name: name, Version: 0, Body: string(body), AuthorName: "built-in"}, nil
+}
+
+// Check parses a template body and renders it with sample data, so that
+// syntax errors and unknown fields are caught before it is saved
+func Check(name, body string) error {
+	sample, ok := samples[name]
+	if !ok {
+		return fmt.Errorf("unknown prompt %q (use %s)", name, strings.Join(Names(), " or "))
+	}
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("prompt is empty")
+	}
+	tmpl, err := parse(name, body)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(io.Discard, sample)
+}
+
+// Render executes a prompt template with data
+func Render(p *repository.PromptTemplate, data interface{}) (string, error) {
+	tmpl, err := parse(p.Name, p.Body)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("rendering prompt %s v%d: %w", p.Name, p.Version, err)
+	}
+	return sb.String(), nil
+}
+
+func parse(name, body string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Parse(body)
+}
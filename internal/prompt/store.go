@@ -0,0 +1,139 @@
+package prompt
+
+import (
+	"bd_bot/internal/repository"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// cacheTTL bounds how long a saved prompt takes to reach other processes
+const cacheTTL = 30 * time.Second
+
+// Store serves the current version of each prompt from the database, falling
+// back to the built-in default. A nil Store, or one without a repository,
+// serves the defaults only.
+type Store struct {
+	repo *repository.PromptRepository
+	// pinned templates are served instead of the current version
+	pinned map[string]*repository.PromptTemplate
+
+	mu    sync.Mutex
+	cache map[string]cachedPrompt
+}
+
+type cachedPrompt struct {
+	prompt   *repository.PromptTemplate
+	loadedAt time.Time
+}
+
+func NewStore(repo *repository.PromptRepository) *Store {
+	return &Store{repo: repo, cache: make(map[string]cachedPrompt)}
+}
+
+// Pinned returns a store that always serves the given templates, and the
+// defaults for other prompts. It lets a template be tried, e.g. by an
+// evaluation, without saving it.
+func Pinned(templates ...*repository.PromptTemplate) *Store {
+	s := NewStore(nil)
+	s.pinned = make(map[string]*repository.PromptTemplate)
+	for _, p := range templates {
+		s.pinned[p.Name] = p
+	}
+	return s
+}
+
+// Current returns the version of a prompt in use: the newest saved one, or
+// the default if none was saved
+func (s *Store) Current(ctx context.Context, name string) (*repository.PromptTemplate, error) {
+	if s != nil && s.pinned[name] != nil {
+		return s.pinned[name], nil
+	}
+	if s == nil || s.repo == nil {
+		return Default(name)
+	}
+
+	s.mu.Lock()
+	cached, ok := s.cache[name]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < cacheTTL {
+		return cached.prompt, nil
+	}
+
+	p, err := s.repo.Latest(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		if p, err = Default(name); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	s.cache[name] = cachedPrompt{prompt: p, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return p, nil
+}
+
+// Version returns one version of a prompt, or nil if it does not exist.
+// Version 0 is the default.
+func (s *Store) Version(ctx context.Context, name string, version int) (*repository.PromptTemplate, error) {
+	if version == 0 {
+		return Default(name)
+	}
+	if s == nil || s.repo == nil {
+		return nil, nil
+	}
+	return s.repo.GetVersion(ctx, name, version)
+}
+
+// Versions returns the history of a prompt, newest first, ending with the default
+func (s *Store) Versions(ctx context.Context, name string) ([]repository.PromptTemplate, error) {
+	def, err := Default(name)
+	if err != nil {
+		return nil, err
+	}
+	var versions []repository.PromptTemplate
+	if s != nil && s.repo != nil {
+		if versions, err = s.repo.ListVersions(ctx, name); err != nil {
+			return nil, err
+		}
+	}
+	return append(versions, *def), nil
+}
+
+// Save checks body and stores it as the next version of the prompt, which
+// takes effect immediately in this process
+func (s *Store) Save(ctx context.Context, name, body string, authorID int) (*repository.PromptTemplate, error) {
+	if err := Check(name, body); err != nil {
+		return nil, err
+	}
+	p, err := s.repo.Create(ctx, name, body, authorID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[name] = cachedPrompt{prompt: p, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return p, nil
+}
+
+// Render renders the current version of a prompt and reports which version
+// it was. If the database cannot be read, the default is used.
+func (s *Store) Render(ctx context.Context, name string, data interface{}) (string, int, error) {
+	p, err := s.Current(ctx, name)
+	if err != nil {
+		slog.Warn("Failed to load prompt, using the default", "prompt", name, "error", err)
+		if p, err = Default(name); err != nil {
+			return "", 0, err
+		}
+	}
+	text, err := Render(p, data)
+	if err != nil {
+		return "", 0, err
+	}
+	return text, p.Version, nil
+}
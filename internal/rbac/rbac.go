@@ -0,0 +1,86 @@
+package rbac
+
+import "sort"
+
+// Roles
+const (
+	Admin      = "admin"
+	Developer  = "developer"
+	BDManager  = "bd_manager"
+	Researcher = "researcher"
+	Viewer     = "viewer"
+)
+
+// DefaultRole is given to newly provisioned users
+const DefaultRole = Researcher
+
+// Roles lists every valid role, most privileged first
+var Roles = []string{Admin, Developer, BDManager, Researcher, Viewer}
+
+// Permissions
+const (
+	EngageSolicitations = "solicitations:engage" // claim, comment, archive, share
+	ReadRequirements    = "requirements:read"
+	WriteRequirements   = "requirements:write"
+	ReadTasks           = "tasks:read"
+	WriteTasks          = "tasks:write"
+	ReadIRAD            = "irad:read"
+	ProposeIRAD         = "irad:propose" // create projects
+	ManageIRAD          = "irad:manage"  // create SCOs
+	ReviewIRAD          = "irad:review"
+	ManageOrganization  = "organization:manage" // edit the organization narrative and match settings
+	ManageSystem        = "system:admin"        // scraper status, user administration
+)
+
+var rolePermissions = map[string][]string{
+	Admin: {
+		EngageSolicitations, ReadRequirements, WriteRequirements, ReadTasks, WriteTasks,
+		ReadIRAD, ProposeIRAD, ManageIRAD, ReviewIRAD, ManageOrganization, ManageSystem,
+	},
+	Developer: {
+		EngageSolicitations, ReadRequirements, WriteRequirements, ReadTasks, WriteTasks, ReadIRAD,
+	},
+	BDManager: {
+		EngageSolicitations, ReadIRAD, ProposeIRAD, ManageIRAD, ReviewIRAD, ManageOrganization,
+	},
+	Researcher: {
+		EngageSolicitations, ReadIRAD, ProposeIRAD,
+	},
+	Viewer: {
+		ReadIRAD,
+	},
+}
+
+// ValidRole reports whether role is one of Roles
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Permissions returns the sorted permission set of a role. Unknown roles have none.
+func Permissions(role string) []string {
+	perms := append([]string{}, rolePermissions[role]...)
+	sort.Strings(perms)
+	return perms
+}
+
+// Has reports whether role grants perm
+func Has(role, perm string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RolesWith returns every role that grants perm
+func RolesWith(perm string) []string {
+	var roles []string
+	for _, role := range Roles {
+		if Has(role, perm) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// Token scopes. A read token may only make GET requests.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// tokenPrefix marks JOSHUA personal access tokens so they are easy to spot in scripts and secret scanners
+const tokenPrefix = "jsh_"
+
+// APIToken is a personal access token. The secret is only returned by Create.
+type APIToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	UserEmail  string     `json:"user_email,omitempty"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HasScope reports whether the token was granted scope. Write implies read.
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope || (s == ScopeWrite && scope == ScopeRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidTokenScope reports whether scope is a known token scope
+func ValidTokenScope(scope string) bool {
+	return scope == ScopeRead || scope == ScopeWrite
+}
+
+type APITokenRepository struct {
+	db *sql.DB
+}
+
+func NewAPITokenRepository(db *sql.DB) *APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+// Create issues a new token. expiresAt may be nil for a token that never expires.
+func (r *APITokenRepository) Create(ctx context.Context, userID int, name string, scopes []string, expiresAt *time.Time) (string, *APIToken, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	secret := tokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	scopeData, err := json.Marshal(scopes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	token := &APIToken{UserID: userID, Name: name, Prefix: secret[:len(tokenPrefix)+6], Scopes: scopes, ExpiresAt: expiresAt}
+	query := `
+		INSERT INTO api_tokens (user_id, name, token_hash, token_prefix, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		RETURNING id, created_at
+	`
+	if err := r.db.QueryRowContext(ctx, query, userID, name, HashToken(secret), token.Prefix, scopeData, expiresAt).Scan(&token.ID, &token.CreatedAt); err != nil {
+		return "", nil, err
+	}
+	return secret, token, nil
+}
+
+// Validate returns the active token matching secret, or sql.ErrNoRows if it
+// is unknown, expired or revoked
+func (r *APITokenRepository) Validate(ctx context.Context, secret string) (*APIToken, error) {
+	query := `
+		SELECT id, user_id, name, token_prefix, scopes, last_used_at, expires_at, created_at
+		FROM api_tokens
+		WHERE token_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+	`
+	var t APIToken
+	var scopes []byte
+	var lastUsed, expires sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, HashToken(secret)).Scan(&t.ID, &t.UserID, &t.Name, &t.Prefix, &scopes, &lastUsed, &expires, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal(scopes, &t.Scopes)
+	t.LastUsedAt = nullTimePtr(lastUsed)
+	t.ExpiresAt = nullTimePtr(expires)
+	return &t, nil
+}
+
+// Touch records that the token was used, at most once a minute
+func (r *APITokenRepository) Touch(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE api_tokens SET last_used_at = NOW() WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`, id)
+	return err
+}
+
+// Revoke disables a token. A userID of 0 allows revoking any user's token.
+func (r *APITokenRepository) Revoke(ctx context.Context, id, userID int) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE api_tokens SET revoked_at = NOW() WHERE id = $1 AND ($2 = 0 OR user_id = $2) AND revoked_at IS NULL`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// List returns unrevoked tokens, newest first. A userID of 0 lists every user's tokens.
+func (r *APITokenRepository) List(ctx context.Context, userID int) ([]APIToken, error) {
+	query := `
+		SELECT t.id, t.user_id, u.email, t.name, t.token_prefix, t.scopes, t.last_used_at, t.expires_at, t.created_at
+		FROM api_tokens t
+		JOIN users u ON t.user_id = u.id
+		WHERE t.revoked_at IS NULL AND ($1 = 0 OR t.user_id = $1)
+		ORDER BY t.created_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []APIToken{}
+	for rows.Next() {
+		var t APIToken
+		var scopes []byte
+		var lastUsed, expires sql.NullTime
+		if err := rows.Scan(&t.ID, &t.UserID, &t.UserEmail, &t.Name, &t.Prefix, &scopes, &lastUsed, &expires, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		json.Unmarshal(scopes, &t.Scopes)
+		t.LastUsedAt = nullTimePtr(lastUsed)
+		t.ExpiresAt = nullTimePtr(expires)
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time
+	return &v
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// DigestDeadline is a solicitation the user has claimed that is due soon
+type DigestDeadline struct {
+	SolicitationID int       `json:"solicitation_id"`
+	SourceID       string    `json:"source_id"`
+	Title          string    `json:"title"`
+	Agency         string    `json:"agency"`
+	DueDate        time.Time `json:"due_date"`
+	ClaimType      string    `json:"claim_type"`
+}
+
+// DigestComment is a comment left by someone else on a solicitation the user has claimed
+type DigestComment struct {
+	SourceID     string    `json:"source_id"`
+	Title        string    `json:"title"`
+	UserFullName string    `json:"user_full_name"`
+	Content      string    `json:"content"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ClaimedDueBefore returns the user's active claims with a due date between now and before
+func (r *SolicitationRepository) ClaimedDueBefore(ctx context.Context, userID int, before time.Time) ([]DigestDeadline, error) {
+	query := `
+		SELECT s.id, s.source_id, s.title, COALESCE(s.agency, ''), s.due_date, c.claim_type
+		FROM claims c
+		JOIN solicitations s ON c.solicitation_id = s.id
+		WHERE c.user_id = $1
+			AND (c.archived IS NULL OR c.archived = FALSE)
+			AND s.due_date >= NOW() AND s.due_date < $2
+		ORDER BY s.due_date ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deadlines []DigestDeadline
+	for rows.Next() {
+		var d DigestDeadline
+		if err := rows.Scan(&d.SolicitationID, &d.SourceID, &d.Title, &d.Agency, &d.DueDate, &d.ClaimType); err != nil {
+			return nil, err
+		}
+		deadlines = append(deadlines, d)
+	}
+	return deadlines, rows.Err()
+}
+
+// CommentsOnClaimedSince returns comments by other users posted after since on
+// solicitations the user has an active claim on, oldest first
+func (r *SolicitationRepository) CommentsOnClaimedSince(ctx context.Context, userID int, since time.Time) ([]DigestComment, error) {
+	query := `
+		SELECT s.source_id, s.title, u.full_name, sc.content, sc.created_at
+		FROM solicitation_comments sc
+		JOIN claims c ON c.solicitation_id = sc.solicitation_id AND c.user_id = $1
+		JOIN solicitations s ON sc.solicitation_id = s.id
+		JOIN users u ON sc.user_id = u.id
+		WHERE sc.user_id <> $1
+			AND (c.archived IS NULL OR c.archived = FALSE)
+			AND sc.created_at > $2
+		ORDER BY sc.created_at ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []DigestComment
+	for rows.Next() {
+		var c DigestComment
+		if err := rows.Scan(&c.SourceID, &c.Title, &c.UserFullName, &c.Content, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// maxDocumentAttempts is how many times a failing download is retried
+const maxDocumentAttempts = 3
+
+// maxIndexedDocumentText caps solicitations.document_text so the combined
+// tsvector stays well under Postgres' 1MB limit
+const maxIndexedDocumentText = 400000
+
+// SolicitationDocument is an attachment that has been downloaded (or failed to)
+type SolicitationDocument struct {
+	ID             int        `json:"id"`
+	SolicitationID int        `json:"solicitation_id"`
+	URL            string     `json:"url"`
+	Title          string     `json:"title"`
+	Format         string     `json:"format"`
+	ContentType    string     `json:"content_type,omitempty"`
+	SHA256         string     `json:"sha256,omitempty"`
+	StoragePath    string     `json:"-"`
+	SizeBytes      int64      `json:"size_bytes"`
+	Text           string     `json:"-"`
+	TextLength     int        `json:"text_length"`
+	Status         string     `json:"status"`
+	Error          string     `json:"error,omitempty"`
+	Attempts       int        `json:"attempts"`
+	FetchedAt      *time.Time `json:"fetched_at,omitempty"`
+}
+
+// PendingDocument is an attachment listed on a solicitation that still needs fetching
+type PendingDocument struct {
+	SolicitationID int
+	SourceID       string
+	URL            string
+	Title          string
+}
+
+type DocumentRepository struct {
+	db *sql.DB
+}
+
+func NewDocumentRepository(db *sql.DB) *DocumentRepository {
+	return &DocumentRepository{db: db}
+}
+
+// Pending returns attachments that were never fetched, or failed fewer than
+// maxDocumentAttempts times, newest solicitations first. A solicitationID of
+// 0 considers every solicitation.
+func (r *DocumentRepository) Pending(ctx context.Context, solicitationID, limit int) ([]PendingDocument, error) {
+	query := `
+		SELECT s.id, s.source_id, d->>'url', COALESCE(d->>'title', '')
+		FROM solicitations s
+		CROSS JOIN LATERAL jsonb_array_elements(COALESCE(s.documents, '[]'::jsonb)) d
+		LEFT JOIN solicitation_documents sd ON sd.solicitation_id = s.id AND sd.url = d->>'url'
+		WHERE COALESCE(d->>'url', '') <> ''
+		  AND ($1 = 0 OR s.id = $1)
+		  AND (sd.id IS NULL OR (sd.status = 'failed' AND sd.attempts < $2))
+		ORDER BY s.created_at DESC, s.id
+		LIMIT $3
+	`
+	rows, err := r.db.QueryContext(ctx, query, solicitationID, maxDocumentAttempts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pending []PendingDocument
+	for rows.Next() {
+		var p PendingDocument
+		if err := rows.Scan(&p.SolicitationID, &p.SourceID, &p.URL, &p.Title); err != nil {
+			return nil, err
+		}
+		pending = append(pending, p)
+	}
+	return pending, rows.Err()
+}
+
+// Save records the outcome of a fetch, counting repeated attempts
+func (r *DocumentRepository) Save(ctx context.Context, doc *SolicitationDocument) error {
+	query := `
+		INSERT INTO solicitation_documents
+			(solicitation_id, url, title, format, content_type, sha256, storage_path, size_bytes, text, status, error, attempts, fetched_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10, NULLIF($11, ''), 1, NOW())
+		ON CONFLICT (solicitation_id, url) DO UPDATE SET
+			title = EXCLUDED.title,
+			format = EXCLUDED.format,
+			content_type = EXCLUDED.content_type,
+			sha256 = EXCLUDED.sha256,
+			storage_path = EXCLUDED.storage_path,
+			size_bytes = EXCLUDED.size_bytes,
+			text = EXCLUDED.text,
+			status = EXCLUDED.status,
+			error = EXCLUDED.error,
+			attempts = solicitation_documents.attempts + 1,
+			fetched_at = NOW()
+		RETURNING id, attempts
+	`
+	return r.db.QueryRowContext(ctx, query,
+		doc.SolicitationID, doc.URL, doc.Title, doc.Format, doc.ContentType, doc.SHA256, doc.StoragePath,
+		doc.SizeBytes, doc.Text, doc.Status, doc.Error,
+	).Scan(&doc.ID, &doc.Attempts)
+}
+
+// RefreshText copies the extracted text of a solicitation's documents into
+// solicitations.document_text, which re-indexes it for search. updated_at is
+// bumped when the text changes so incremental matching picks it up.
+func (r *DocumentRepository) RefreshText(ctx context.Context, solicitationID int) error {
+	query := `
+		WITH agg AS (
+			SELECT LEFT(STRING_AGG(text, E'\n\n' ORDER BY id), $2) AS text
+			FROM solicitation_documents
+			WHERE solicitation_id = $1 AND status = 'fetched' AND text <> ''
+		)
+		UPDATE solicitations s SET document_text = agg.text, updated_at = NOW()
+		FROM agg
+		WHERE s.id = $1 AND s.document_text IS DISTINCT FROM agg.text
+	`
+	_, err := r.db.ExecContext(ctx, query, solicitationID, maxIndexedDocumentText)
+	return err
+}
+
+// ListForSolicitation returns the fetched and failed documents of a solicitation
+func (r *DocumentRepository) ListForSolicitation(ctx context.Context, solicitationID int) ([]SolicitationDocument, error) {
+	query := `
+		SELECT id, solicitation_id, url, COALESCE(title, ''), COALESCE(format, ''), COALESCE(content_type, ''),
+			COALESCE(sha256, ''), COALESCE(storage_path, ''), COALESCE(size_bytes, 0), LENGTH(COALESCE(text, '')),
+			status, COALESCE(error, ''), attempts, fetched_at
+		FROM solicitation_documents
+		WHERE solicitation_id = $1
+		ORDER BY id
+	`
+	rows, err := r.db.QueryContext(ctx, query, solicitationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	docs := []SolicitationDocument{}
+	for rows.Next() {
+		var d SolicitationDocument
+		var fetchedAt sql.NullTime
+		if err := rows.Scan(&d.ID, &d.SolicitationID, &d.URL, &d.Title, &d.Format, &d.ContentType,
+			&d.SHA256, &d.StoragePath, &d.SizeBytes, &d.TextLength, &d.Status, &d.Error, &d.Attempts, &fetchedAt); err != nil {
+			return nil, err
+		}
+		d.FetchedAt = nullTimePtr(fetchedAt)
+		docs = append(docs, d)
+	}
+	return docs, rows.Err()
+}
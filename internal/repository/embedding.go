@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// Embedding entity types
+const (
+	EmbeddingSolicitation          = "solicitation"
+	EmbeddingNarrative             = "narrative"
+	EmbeddingOrganizationNarrative = "organization_narrative"
+)
+
+// Embedding is a stored vector and the hash of the text it was computed from
+type Embedding struct {
+	EntityID    int
+	ContentHash string
+	Vector      []float32
+}
+
+type EmbeddingRepository struct {
+	db *sql.DB
+}
+
+func NewEmbeddingRepository(db *sql.DB) *EmbeddingRepository {
+	return &EmbeddingRepository{db: db}
+}
+
+// List returns the stored vectors of one entity type for a model, keyed by entity ID
+func (r *EmbeddingRepository) List(ctx context.Context, entityType, model string) (map[int]Embedding, error) {
+	query := `SELECT entity_id, content_hash, vector FROM embeddings WHERE entity_type = $1 AND model = $2`
+	rows, err := r.db.QueryContext(ctx, query, entityType, model)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[int]Embedding)
+	for rows.Next() {
+		var e Embedding
+		if err := rows.Scan(&e.EntityID, &e.ContentHash, pq.Array(&e.Vector)); err != nil {
+			return nil, err
+		}
+		out[e.EntityID] = e
+	}
+	return out, rows.Err()
+}
+
+// Get returns one stored vector, or sql.ErrNoRows
+func (r *EmbeddingRepository) Get(ctx context.Context, entityType string, entityID int, model string) (*Embedding, error) {
+	query := `SELECT entity_id, content_hash, vector FROM embeddings WHERE entity_type = $1 AND entity_id = $2 AND model = $3`
+	var e Embedding
+	err := r.db.QueryRowContext(ctx, query, entityType, entityID, model).Scan(&e.EntityID, &e.ContentHash, pq.Array(&e.Vector))
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Save stores or replaces a vector
+func (r *EmbeddingRepository) Save(ctx context.Context, entityType, model string, e Embedding) error {
+	query := `
+		INSERT INTO embeddings (entity_type, entity_id, model, content_hash, vector, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (entity_type, entity_id, model) DO UPDATE SET
+			content_hash = EXCLUDED.content_hash,
+			vector = EXCLUDED.vector,
+			updated_at = NOW()
+	`
+	_, err := r.db.ExecContext(ctx, query, entityType, e.EntityID, model, e.ContentHash, pq.Array(e.Vector))
+	return err
+}
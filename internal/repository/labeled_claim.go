@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"bd_bot/internal/scraper"
+	"context"
+)
+
+// LabeledClaim is a user's own verdict on a solicitation: relevant if they
+// took the lead on it, irrelevant if they archived it
+type LabeledClaim struct {
+	UserID       int
+	UserEmail    string
+	Narrative    string
+	Solicitation scraper.Solicitation
+	Relevant     bool
+}
+
+// ListLabeledClaims returns the lead and archive decisions of users who have
+// a narrative, newest first. A userID of 0 includes every user and a limit of
+// 0 returns them all. Archiving wins over a lead claim on the same solicitation.
+func (r *SolicitationRepository) ListLabeledClaims(ctx context.Context, userID, limit int) ([]LabeledClaim, error) {
+	query := `
+		SELECT u.id, u.email, u.narrative,
+			s.id, s.source_id, s.title, COALESCE(s.description, ''), COALESCE(s.agency, ''), COALESCE(s.document_text, ''),
+			NOT COALESCE(c.archived, FALSE)
+		FROM claims c
+		JOIN users u ON u.id = c.user_id
+		JOIN solicitations s ON s.id = c.solicitation_id
+		WHERE (c.archived = TRUE OR c.claim_type = 'lead')
+		  AND COALESCE(u.narrative, '') <> ''
+		  AND ($1 = 0 OR c.user_id = $1)
+		ORDER BY c.created_at DESC
+		LIMIT NULLIF($2::int, 0)
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claims []LabeledClaim
+	for rows.Next() {
+		var c LabeledClaim
+		sol := &c.Solicitation
+		if err := rows.Scan(&c.UserID, &c.UserEmail, &c.Narrative, &sol.ID, &sol.SourceID, &sol.Title, &sol.Description, &sol.Agency, &sol.DocumentText, &c.Relevant); err != nil {
+			return nil, err
+		}
+		claims = append(claims, c)
+	}
+	return claims, rows.Err()
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type OutboxMessage struct {
+	ID            int        `json:"id"`
+	To            string     `json:"to"`
+	Subject       string     `json:"subject"`
+	TextBody      string     `json:"text_body"`
+	HTMLBody      string     `json:"html_body,omitempty"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type MailRepository struct {
+	db *sql.DB
+}
+
+func NewMailRepository(db *sql.DB) *MailRepository {
+	return &MailRepository{db: db}
+}
+
+// Enqueue adds a message to the outbox for immediate delivery
+func (r *MailRepository) Enqueue(ctx context.Context, to, subject, textBody, htmlBody string) (int, error) {
+	var html interface{}
+	if htmlBody != "" {
+		html = htmlBody
+	}
+	var id int
+	query := `
+		INSERT INTO mail_outbox (to_address, subject, text_body, html_body, status, next_attempt_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 'pending', NOW(), NOW(), NOW())
+		RETURNING id
+	`
+	err := r.db.QueryRowContext(ctx, query, to, subject, textBody, html).Scan(&id)
+	return id, err
+}
+
+// ClaimDue marks up to limit due messages as sending and returns them.
+// Messages stuck in sending (e.g. after a crash) are retried after 15 minutes.
+func (r *MailRepository) ClaimDue(ctx context.Context, limit int) ([]OutboxMessage, error) {
+	query := `
+		UPDATE mail_outbox SET status = 'sending', attempts = attempts + 1, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM mail_outbox
+			WHERE (status = 'pending' AND next_attempt_at <= NOW())
+			   OR (status = 'sending' AND updated_at < NOW() - INTERVAL '15 minutes')
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, to_address, subject, text_body, html_body, status, attempts, last_error, next_attempt_at, created_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []OutboxMessage
+	for rows.Next() {
+		m, err := scanOutboxMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+func (r *MailRepository) MarkSent(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE mail_outbox SET status = 'sent', sent_at = NOW(), last_error = NULL, updated_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+// MarkFailed records a delivery error. If retryAt is nil the message is given up on.
+func (r *MailRepository) MarkFailed(ctx context.Context, id int, errText string, retryAt *time.Time) error {
+	if retryAt == nil {
+		_, err := r.db.ExecContext(ctx, `UPDATE mail_outbox SET status = 'failed', last_error = $1, updated_at = NOW() WHERE id = $2`, errText, id)
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `UPDATE mail_outbox SET status = 'pending', last_error = $1, next_attempt_at = $2, updated_at = NOW() WHERE id = $3`, errText, *retryAt, id)
+	return err
+}
+
+// List returns the most recent outbox messages, optionally filtered by status
+func (r *MailRepository) List(ctx context.Context, status string, limit int) ([]OutboxMessage, error) {
+	query := `
+		SELECT id, to_address, subject, text_body, html_body, status, attempts, last_error, next_attempt_at, created_at
+		FROM mail_outbox
+	`
+	var rows *sql.Rows
+	var err error
+	if status != "" {
+		query += ` WHERE status = $1 ORDER BY created_at DESC LIMIT $2`
+		rows, err = r.db.QueryContext(ctx, query, status, limit)
+	} else {
+		query += ` ORDER BY created_at DESC LIMIT $1`
+		rows, err = r.db.QueryContext(ctx, query, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []OutboxMessage
+	for rows.Next() {
+		m, err := scanOutboxMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+func scanOutboxMessage(rows *sql.Rows) (OutboxMessage, error) {
+	var m OutboxMessage
+	var html sql.NullString
+	var lastError sql.NullString
+	err := rows.Scan(&m.ID, &m.To, &m.Subject, &m.TextBody, &html, &m.Status, &m.Attempts, &lastError, &m.NextAttemptAt, &m.CreatedAt)
+	m.HTMLBody = html.String
+	m.LastError = lastError.String
+	return m, err
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"time"
 )
 
 type Match struct {
@@ -19,7 +20,20 @@ type MatchedSolicitation struct {
 	MatchID        int             `json:"match_id"`
 	Score          int             `json:"score"`
 	Explanation    string          `json:"explanation"`
+	// Details is the structured explanation, absent for matches scored before it was recorded
+	Details        json.RawMessage `json:"details,omitempty"`
+	// PromptVersion is the match prompt version the score came from, absent
+	// for matches scored before it was recorded
+	PromptVersion  *int            `json:"prompt_version,omitempty"`
+	// Feedback is the user's rating of the match ("up" or "down"), set in personal inboxes
+	Feedback       string          `json:"feedback,omitempty"`
+	// AboveThresholdAt is when the score last reached the user's threshold,
+	// set in personal inboxes
+	AboveThresholdAt *time.Time    `json:"above_threshold_at,omitempty"`
+	// PersonalScore is the viewer's own score, set in organization inboxes
+	PersonalScore  *int            `json:"personal_score,omitempty"`
 	Solicitation   scraper.Solicitation `json:"solicitation"`
+	MatchedAt      time.Time       `json:"matched_at"`
 }
 
 type MatchRepository struct {
@@ -30,17 +44,23 @@ func NewMatchRepository(db *sql.DB) *MatchRepository {
 	return &MatchRepository{db: db}
 }
 
+// GetUserInbox returns the user's matches that reach their current threshold,
+// best first. Archived solicitations are left out.
 func (r *MatchRepository) GetUserInbox(ctx context.Context, userID int) ([]MatchedSolicitation, error) {
 	query := `
 		SELECT 
-			m.id, m.score, m.explanation,
+			m.id, m.score, m.explanation, m.details, m.prompt_version, m.created_at,
 			s.id, s.source_id, s.title, s.description, s.agency, s.due_date, s.url, s.raw_data, s.documents,
 			(SELECT u.full_name FROM claims c JOIN users u ON c.user_id = u.id WHERE c.solicitation_id = s.id AND c.claim_type = 'lead' LIMIT 1),
-			(SELECT STRING_AGG(u.full_name, ', ') FROM claims c JOIN users u ON c.user_id = u.id WHERE c.solicitation_id = s.id AND c.claim_type = 'interested')
+			(SELECT STRING_AGG(u.full_name, ', ') FROM claims c JOIN users u ON c.user_id = u.id WHERE c.solicitation_id = s.id AND c.claim_type = 'interested'),
+			f.rating, m.above_threshold_at
 		FROM matches m
+		JOIN users u ON u.id = m.user_id
 		JOIN solicitations s ON m.solicitation_id = s.id
 		LEFT JOIN claims c ON c.solicitation_id = s.id AND c.user_id = m.user_id
-		WHERE m.user_id = $1 AND m.score > 0 AND (c.archived IS NULL OR c.archived = FALSE)
+		LEFT JOIN match_feedback f ON f.user_id = m.user_id AND f.solicitation_id = m.solicitation_id
+		WHERE m.user_id = $1 AND m.score >= COALESCE(u.match_threshold, 75)
+		  AND (c.archived IS NULL OR c.archived = FALSE)
 		ORDER BY m.score DESC
 	`
 	rows, err := r.db.QueryContext(ctx, query, userID)
@@ -52,56 +72,134 @@ func (r *MatchRepository) GetUserInbox(ctx context.Context, userID int) ([]Match
 	var results []MatchedSolicitation
 	for rows.Next() {
 		var ms MatchedSolicitation
-		var rawData []byte
-		var docsData []byte
-		var dueDate sql.NullTime
-		var leadName sql.NullString
-		var interestedParties sql.NullString
-
-		if err := rows.Scan(
-			&ms.MatchID, &ms.Score, &ms.Explanation,
-			&ms.Solicitation.ID, &ms.Solicitation.SourceID, &ms.Solicitation.Title, 
-			&ms.Solicitation.Description, &ms.Solicitation.Agency, &dueDate, 
-			&ms.Solicitation.URL, &rawData, &docsData, &leadName, &interestedParties,
-		); err != nil {
+		var rating sql.NullString
+		var aboveAt sql.NullTime
+		if err := scanMatchedSolicitation(rows, &ms, &rating, &aboveAt); err != nil {
 			return nil, err
 		}
-
-		if dueDate.Valid {
-			ms.Solicitation.DueDate = dueDate.Time
-		}
-		if leadName.Valid {
-			name := leadName.String
-			ms.Solicitation.LeadName = &name
-		}
-		if interestedParties.Valid {
-			names := interestedParties.String
-			ms.Solicitation.InterestedParties = &names
-		}
-		
-		json.Unmarshal(rawData, &ms.Solicitation.RawData)
-		if len(docsData) > 0 {
-			json.Unmarshal(docsData, &ms.Solicitation.Documents)
+		ms.Feedback = rating.String
+		if aboveAt.Valid {
+			t := aboveAt.Time
+			ms.AboveThresholdAt = &t
 		}
-
 		results = append(results, ms)
 	}
 	return results, nil
 }
 
-func (r *MatchRepository) Upsert(ctx context.Context, userID, solicitationID, score int, explanation string) error {
+// scanMatchedSolicitation reads an inbox row: the match, the solicitation, its
+// lead and interested parties, then any extra columns into extra
+func scanMatchedSolicitation(rows *sql.Rows, ms *MatchedSolicitation, extra ...interface{}) error {
+	var rawData []byte
+	var docsData []byte
+	var dueDate sql.NullTime
+	var leadName sql.NullString
+	var interestedParties sql.NullString
+	var details []byte
+	var promptVersion sql.NullInt64
+
+	dest := []interface{}{
+		&ms.MatchID, &ms.Score, &ms.Explanation, &details, &promptVersion, &ms.MatchedAt,
+		&ms.Solicitation.ID, &ms.Solicitation.SourceID, &ms.Solicitation.Title,
+		&ms.Solicitation.Description, &ms.Solicitation.Agency, &dueDate,
+		&ms.Solicitation.URL, &rawData, &docsData, &leadName, &interestedParties,
+	}
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+
+	if dueDate.Valid {
+		ms.Solicitation.DueDate = dueDate.Time
+	}
+	if len(details) > 0 {
+		ms.Details = details
+	}
+	if promptVersion.Valid {
+		v := int(promptVersion.Int64)
+		ms.PromptVersion = &v
+	}
+	if leadName.Valid {
+		name := leadName.String
+		ms.Solicitation.LeadName = &name
+	}
+	if interestedParties.Valid {
+		names := interestedParties.String
+		ms.Solicitation.InterestedParties = &names
+	}
+
+	json.Unmarshal(rawData, &ms.Solicitation.RawData)
+	if len(docsData) > 0 {
+		json.Unmarshal(docsData, &ms.Solicitation.Documents)
+	}
+	return nil
+}
+
+// ScoredHashes identifies the narrative, solicitation content and match prompt
+// a match was scored from
+type ScoredHashes struct {
+	NarrativeHash string
+	ContentHash   string
+	PromptVersion int
+}
+
+// Upsert stores a score, its structured details (JSON, may be nil) and the
+// hashes of the inputs it was computed from. above_threshold_at is set when
+// the score reaches the user's threshold from below, and cleared when it drops
+// under it.
+func (r *MatchRepository) Upsert(ctx context.Context, userID, solicitationID, score int, explanation string, details json.RawMessage, hashes ScoredHashes) error {
 	query := `
-		INSERT INTO matches (user_id, solicitation_id, score, explanation, updated_at)
-		VALUES ($1, $2, $3, $4, NOW())
+		INSERT INTO matches (user_id, solicitation_id, score, explanation, details, narrative_hash, content_hash, prompt_version, above_threshold_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8,
+			CASE WHEN $3::int >= (SELECT COALESCE(match_threshold, 75) FROM users WHERE id = $1) THEN NOW() END,
+			NOW())
 		ON CONFLICT (user_id, solicitation_id) DO UPDATE SET
+			above_threshold_at = CASE
+				WHEN EXCLUDED.above_threshold_at IS NULL THEN NULL
+				ELSE COALESCE(matches.above_threshold_at, EXCLUDED.above_threshold_at)
+			END,
 			score = EXCLUDED.score,
 			explanation = EXCLUDED.explanation,
+			details = EXCLUDED.details,
+			narrative_hash = EXCLUDED.narrative_hash,
+			content_hash = EXCLUDED.content_hash,
+			prompt_version = EXCLUDED.prompt_version,
 			updated_at = NOW();
 	`
-	_, err := r.db.ExecContext(ctx, query, userID, solicitationID, score, explanation)
+	var detailsArg interface{}
+	if len(details) > 0 {
+		detailsArg = []byte(details)
+	}
+	_, err := r.db.ExecContext(ctx, query, userID, solicitationID, score, explanation, detailsArg, hashes.NarrativeHash, hashes.ContentHash, hashes.PromptVersion)
 	return err
 }
 
+// GetScoredHashes returns, per solicitation ID, the hashes the user's existing
+// matches were scored from. Hashes are empty for matches scored before they
+// were tracked.
+func (r *MatchRepository) GetScoredHashes(ctx context.Context, userID int) (map[int]ScoredHashes, error) {
+	query := `
+		SELECT solicitation_id, COALESCE(narrative_hash, ''), COALESCE(content_hash, ''), COALESCE(prompt_version, 0)
+		FROM matches
+		WHERE user_id = $1
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make(map[int]ScoredHashes)
+	for rows.Next() {
+		var id int
+		var h ScoredHashes
+		if err := rows.Scan(&id, &h.NarrativeHash, &h.ContentHash, &h.PromptVersion); err != nil {
+			return nil, err
+		}
+		hashes[id] = h
+	}
+	return hashes, rows.Err()
+}
+
 // GetMatchesForUser retrieves all matches for a user
 func (r *MatchRepository) GetMatchesForUser(ctx context.Context, userID int) (map[string]Match, error) {
 	query := `
@@ -128,6 +226,68 @@ func (r *MatchRepository) GetMatchesForUser(ctx context.Context, userID int) (ma
 	return matches, nil
 }
 
+// ScoreBucket counts a user's scores in [Min, Max]
+type ScoreBucket struct {
+	Min   int `json:"min"`
+	Max   int `json:"max"`
+	Count int `json:"count"`
+}
+
+// ScoreDistribution summarizes every stored score of a user, including those
+// below the threshold
+type ScoreDistribution struct {
+	Threshold      int           `json:"threshold"`
+	Total          int           `json:"total"`
+	AboveThreshold int           `json:"above_threshold"`
+	Buckets        []ScoreBucket `json:"buckets"`
+}
+
+// GetScoreDistribution buckets the user's scores in steps of 10 (the last
+// bucket is 90-100) and counts how many reach their threshold
+func (r *MatchRepository) GetScoreDistribution(ctx context.Context, userID int) (*ScoreDistribution, error) {
+	dist := &ScoreDistribution{}
+	for i := 0; i < 10; i++ {
+		bucket := ScoreBucket{Min: i * 10, Max: i*10 + 9}
+		if i == 9 {
+			bucket.Max = 100
+		}
+		dist.Buckets = append(dist.Buckets, bucket)
+	}
+
+	if err := r.db.QueryRowContext(ctx, `SELECT COALESCE(match_threshold, 75) FROM users WHERE id = $1`, userID).Scan(&dist.Threshold); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT LEAST(GREATEST(score, 0) / 10, 9) AS bucket, COUNT(*)
+		FROM matches
+		WHERE user_id = $1
+		GROUP BY bucket
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bucket, count int
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, err
+		}
+		dist.Buckets[bucket].Count = count
+		dist.Total += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM matches WHERE user_id = $1 AND score >= $2`, userID, dist.Threshold).Scan(&dist.AboveThreshold)
+	if err != nil {
+		return nil, err
+	}
+	return dist, nil
+}
+
 func (r *MatchRepository) ClearMatches(ctx context.Context, userID int) error {
 	query := `DELETE FROM matches WHERE user_id = $1`
 	_, err := r.db.ExecContext(ctx, query, userID)
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Match feedback ratings
+const (
+	RatingUp   = "up"
+	RatingDown = "down"
+)
+
+// SetFeedback records the user's rating of the solicitation of one of their
+// own matches, along with the score the match has now. The rating belongs to
+// the solicitation, so it survives the match being cleared. It returns
+// sql.ErrNoRows if the match is not the user's.
+func (r *MatchRepository) SetFeedback(ctx context.Context, userID, matchID int, rating string) error {
+	query := `
+		INSERT INTO match_feedback (user_id, solicitation_id, rating, score, created_at, updated_at)
+		SELECT m.user_id, m.solicitation_id, $3::text, m.score, NOW(), NOW()
+		FROM matches m
+		WHERE m.id = $1 AND m.user_id = $2
+		ON CONFLICT (user_id, solicitation_id) DO UPDATE SET
+			rating = EXCLUDED.rating,
+			score = EXCLUDED.score,
+			updated_at = NOW()
+	`
+	res, err := r.db.ExecContext(ctx, query, matchID, userID, rating)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ClearFeedback removes the user's rating of a match's solicitation
+func (r *MatchRepository) ClearFeedback(ctx context.Context, userID, matchID int) error {
+	query := `
+		DELETE FROM match_feedback f
+		USING matches m
+		WHERE m.id = $1 AND m.user_id = $2
+		  AND f.user_id = m.user_id AND f.solicitation_id = m.solicitation_id
+	`
+	_, err := r.db.ExecContext(ctx, query, matchID, userID)
+	return err
+}
+
+// FeedbackExample is a solicitation the user rated, with the score they rated
+type FeedbackExample struct {
+	SolicitationID int
+	Title          string
+	Agency         string
+	Description    string
+	Score          int
+	Rating         string
+}
+
+// RecentFeedback returns up to perRating of the user's most recent thumbs-up
+// and as many thumbs-down, newest first
+func (r *MatchRepository) RecentFeedback(ctx context.Context, userID, perRating int) ([]FeedbackExample, error) {
+	query := `
+		SELECT solicitation_id, title, agency, description, score, rating
+		FROM (
+			SELECT s.id AS solicitation_id, s.title AS title, COALESCE(s.agency, '') AS agency, COALESCE(s.description, '') AS description,
+				f.score AS score, f.rating AS rating, f.updated_at AS updated_at,
+				ROW_NUMBER() OVER (PARTITION BY f.rating ORDER BY f.updated_at DESC) AS n
+			FROM match_feedback f
+			JOIN solicitations s ON s.id = f.solicitation_id
+			WHERE f.user_id = $1
+		) rated
+		WHERE n <= $2
+		ORDER BY updated_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, perRating)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var examples []FeedbackExample
+	for rows.Next() {
+		var e FeedbackExample
+		if err := rows.Scan(&e.SolicitationID, &e.Title, &e.Agency, &e.Description, &e.Score, &e.Rating); err != nil {
+			return nil, err
+		}
+		examples = append(examples, e)
+	}
+	return examples, rows.Err()
+}
+
+// FeedbackReport shows how well scores agree with users' ratings, using the
+// score each match had when it was rated
+type FeedbackReport struct {
+	MinScore int `json:"min_score"`
+	Up       int `json:"up"`
+	Down     int `json:"down"`
+	// HighScored counts ratings of matches scored at least MinScore, and
+	// HighScoredDown those of them that were thumbs-down
+	HighScored     int     `json:"high_scored"`
+	HighScoredDown int     `json:"high_scored_down"`
+	HighScoredRate float64 `json:"high_scored_down_rate"`
+	// Buckets are in steps of 10; the last is 90-100
+	Buckets []FeedbackBucket `json:"buckets"`
+	// Misses are the most recent thumbs-down matches scored at least MinScore
+	Misses []FeedbackMiss `json:"misses"`
+}
+
+type FeedbackBucket struct {
+	Min  int `json:"min"`
+	Max  int `json:"max"`
+	Up   int `json:"up"`
+	Down int `json:"down"`
+}
+
+// FeedbackMiss is a high-scoring match the user rated thumbs-down
+type FeedbackMiss struct {
+	SolicitationID int       `json:"solicitation_id"`
+	UserEmail      string    `json:"user_email"`
+	SourceID       string    `json:"source_id"`
+	Title          string    `json:"title"`
+	Score          int       `json:"score"`
+	Explanation    string    `json:"explanation"`
+	RatedAt        time.Time `json:"rated_at"`
+}
+
+// GetFeedbackReport summarizes ratings of one user's matches, or everyone's
+// if userID is 0, listing up to limit recent misses
+func (r *MatchRepository) GetFeedbackReport(ctx context.Context, userID, minScore, limit int) (*FeedbackReport, error) {
+	report := &FeedbackReport{MinScore: minScore, Misses: []FeedbackMiss{}}
+	for i := 0; i < 10; i++ {
+		bucket := FeedbackBucket{Min: i * 10, Max: i*10 + 9}
+		if i == 9 {
+			bucket.Max = 100
+		}
+		report.Buckets = append(report.Buckets, bucket)
+	}
+
+	query := `
+		SELECT LEAST(GREATEST(score, 0) / 10, 9) AS bucket, rating, COUNT(*)
+		FROM match_feedback
+		WHERE ($1 = 0 OR user_id = $1)
+		GROUP BY bucket, rating
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bucket, count int
+		var rating string
+		if err := rows.Scan(&bucket, &rating, &count); err != nil {
+			return nil, err
+		}
+		if rating == RatingUp {
+			report.Buckets[bucket].Up += count
+			report.Up += count
+		} else {
+			report.Buckets[bucket].Down += count
+			report.Down += count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE rating = 'down')
+		FROM match_feedback
+		WHERE ($1 = 0 OR user_id = $1) AND score >= $2
+	`
+	if err := r.db.QueryRowContext(ctx, query, userID, minScore).Scan(&report.HighScored, &report.HighScoredDown); err != nil {
+		return nil, err
+	}
+	if report.HighScored > 0 {
+		report.HighScoredRate = float64(report.HighScoredDown) / float64(report.HighScored)
+	}
+
+	query = `
+		SELECT f.solicitation_id, u.email, s.source_id, s.title, f.score, COALESCE(m.explanation, ''), f.updated_at
+		FROM match_feedback f
+		JOIN users u ON u.id = f.user_id
+		JOIN solicitations s ON s.id = f.solicitation_id
+		LEFT JOIN matches m ON m.user_id = f.user_id AND m.solicitation_id = f.solicitation_id
+		WHERE ($1 = 0 OR f.user_id = $1) AND f.rating = 'down' AND f.score >= $2
+		ORDER BY f.updated_at DESC
+		LIMIT $3
+	`
+	missRows, err := r.db.QueryContext(ctx, query, userID, minScore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer missRows.Close()
+	for missRows.Next() {
+		var m FeedbackMiss
+		if err := missRows.Scan(&m.SolicitationID, &m.UserEmail, &m.SourceID, &m.Title, &m.Score, &m.Explanation, &m.RatedAt); err != nil {
+			return nil, err
+		}
+		report.Misses = append(report.Misses, m)
+	}
+	return report, missRows.Err()
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Match job statuses
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// MatchJob is a background matching run requested from the web UI
+type MatchJob struct {
+	ID             int        `json:"id"`
+	UserID         int        `json:"user_id"`
+	Status         string     `json:"status"`
+	Total          int        `json:"total"`
+	Done           int        `json:"done"`
+	Failed         int        `json:"failed"`
+	AboveThreshold int        `json:"above_threshold"`
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+
+	// Live estimates, only set while the job is running
+	PerMinute  float64 `json:"per_minute,omitempty"`
+	ETASeconds int     `json:"eta_seconds,omitempty"`
+}
+
+// Finished reports whether the job has stopped
+func (j *MatchJob) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed
+}
+
+type MatchJobRepository struct {
+	db *sql.DB
+}
+
+func NewMatchJobRepository(db *sql.DB) *MatchJobRepository {
+	return &MatchJobRepository{db: db}
+}
+
+const matchJobColumns = `id, user_id, status, total, done, failed, above_threshold, COALESCE(error, ''), created_at, started_at, finished_at`
+
+func scanMatchJob(row interface{ Scan(...any) error }) (*MatchJob, error) {
+	var j MatchJob
+	var startedAt, finishedAt sql.NullTime
+	if err := row.Scan(&j.ID, &j.UserID, &j.Status, &j.Total, &j.Done, &j.Failed, &j.AboveThreshold, &j.Error, &j.CreatedAt, &startedAt, &finishedAt); err != nil {
+		return nil, err
+	}
+	j.StartedAt = nullTimePtr(startedAt)
+	j.FinishedAt = nullTimePtr(finishedAt)
+	return &j, nil
+}
+
+// Create queues a job for a user
+func (r *MatchJobRepository) Create(ctx context.Context, userID int) (*MatchJob, error) {
+	query := `INSERT INTO match_jobs (user_id, status) VALUES ($1, $2) RETURNING ` + matchJobColumns
+	return scanMatchJob(r.db.QueryRowContext(ctx, query, userID, JobQueued))
+}
+
+// Get returns a job by ID, or sql.ErrNoRows
+func (r *MatchJobRepository) Get(ctx context.Context, id int) (*MatchJob, error) {
+	query := `SELECT ` + matchJobColumns + ` FROM match_jobs WHERE id = $1`
+	return scanMatchJob(r.db.QueryRowContext(ctx, query, id))
+}
+
+// Active returns the user's queued or running job, or sql.ErrNoRows
+func (r *MatchJobRepository) Active(ctx context.Context, userID int) (*MatchJob, error) {
+	query := `SELECT ` + matchJobColumns + ` FROM match_jobs WHERE user_id = $1 AND status IN ($2, $3) ORDER BY id DESC LIMIT 1`
+	return scanMatchJob(r.db.QueryRowContext(ctx, query, userID, JobQueued, JobRunning))
+}
+
+// Start marks a job as running
+func (r *MatchJobRepository) Start(ctx context.Context, job *MatchJob) error {
+	query := `UPDATE match_jobs SET status = $1, started_at = NOW() WHERE id = $2 RETURNING started_at`
+	var startedAt time.Time
+	if err := r.db.QueryRowContext(ctx, query, JobRunning, job.ID).Scan(&startedAt); err != nil {
+		return err
+	}
+	job.Status = JobRunning
+	job.StartedAt = &startedAt
+	return nil
+}
+
+// UpdateProgress stores the counters of a running job
+func (r *MatchJobRepository) UpdateProgress(ctx context.Context, job *MatchJob) error {
+	query := `UPDATE match_jobs SET total = $1, done = $2, failed = $3, above_threshold = $4 WHERE id = $5`
+	_, err := r.db.ExecContext(ctx, query, job.Total, job.Done, job.Failed, job.AboveThreshold, job.ID)
+	return err
+}
+
+// Finish stores the final counters and status of a job
+func (r *MatchJobRepository) Finish(ctx context.Context, job *MatchJob) error {
+	query := `
+		UPDATE match_jobs
+		SET status = $1, total = $2, done = $3, failed = $4, above_threshold = $5, error = NULLIF($6, ''), finished_at = NOW()
+		WHERE id = $7
+		RETURNING finished_at
+	`
+	var finishedAt time.Time
+	if err := r.db.QueryRowContext(ctx, query, job.Status, job.Total, job.Done, job.Failed, job.AboveThreshold, job.Error, job.ID).Scan(&finishedAt); err != nil {
+		return err
+	}
+	job.FinishedAt = &finishedAt
+	return nil
+}
+
+// FailInterrupted marks jobs left queued or running by a previous server
+// process as failed
+func (r *MatchJobRepository) FailInterrupted(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE match_jobs SET status = $1, error = 'interrupted by a server restart', finished_at = NOW()
+		WHERE status IN ($2, $3)
+	`
+	res, err := r.db.ExecContext(ctx, query, JobFailed, JobQueued, JobRunning)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type Notification struct {
+	ID             int             `json:"id"`
+	UserID         int             `json:"user_id"`
+	SolicitationID *int            `json:"solicitation_id,omitempty"`
+	SourceID       string          `json:"source_id,omitempty"`
+	Kind           string          `json:"kind"`
+	Title          string          `json:"title"`
+	Body           string          `json:"body"`
+	Data           json.RawMessage `json:"data,omitempty"`
+	ReadAt         *time.Time      `json:"read_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+func (r *NotificationRepository) Create(ctx context.Context, n *Notification) error {
+	var data interface{}
+	if len(n.Data) > 0 {
+		data = []byte(n.Data)
+	}
+	query := `
+		INSERT INTO notifications (user_id, solicitation_id, kind, title, body, data, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		RETURNING id, created_at
+	`
+	return r.db.QueryRowContext(ctx, query, n.UserID, n.SolicitationID, n.Kind, n.Title, n.Body, data).Scan(&n.ID, &n.CreatedAt)
+}
+
+// ListForUser returns a user's most recent notifications
+func (r *NotificationRepository) ListForUser(ctx context.Context, userID int, unreadOnly bool, limit int) ([]Notification, error) {
+	query := `
+		SELECT n.id, n.user_id, n.solicitation_id, s.source_id, n.kind, n.title, n.body, n.data, n.read_at, n.created_at
+		FROM notifications n
+		LEFT JOIN solicitations s ON n.solicitation_id = s.id
+		WHERE n.user_id = $1
+	`
+	if unreadOnly {
+		query += ` AND n.read_at IS NULL`
+	}
+	query += ` ORDER BY n.created_at DESC LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []Notification{}
+	for rows.Next() {
+		var n Notification
+		var solID sql.NullInt64
+		var sourceID sql.NullString
+		var body sql.NullString
+		var data []byte
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.UserID, &solID, &sourceID, &n.Kind, &n.Title, &body, &data, &readAt, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		if solID.Valid {
+			id := int(solID.Int64)
+			n.SolicitationID = &id
+		}
+		n.SourceID = sourceID.String
+		n.Body = body.String
+		if len(data) > 0 {
+			n.Data = json.RawMessage(data)
+		}
+		if readAt.Valid {
+			t := readAt.Time
+			n.ReadAt = &t
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+// MarkRead marks one of the user's notifications as read
+func (r *NotificationRepository) MarkRead(ctx context.Context, id, userID int) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE notifications SET read_at = NOW() WHERE id = $1 AND user_id = $2 AND read_at IS NULL`, id, userID)
+	if err != nil {
+		return err
+	}
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("notification not found: %d", id)
+	}
+	return nil
+}
+
+// MarkAllRead marks every unread notification for the user as read
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL`, userID)
+	return err
+}
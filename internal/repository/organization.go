@@ -4,8 +4,30 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 )
 
+// Organization is a group of users that shares a narrative and an inbox.
+// Users belong to it by organization_name.
+type Organization struct {
+	ID             int       `json:"id"`
+	Name           string    `json:"name"`
+	Narrative      string    `json:"narrative"`
+	MatchThreshold int       `json:"match_threshold"`
+	MatchTopK      int       `json:"match_top_k"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// OrganizationNarrativeVersion is one saved revision of an organization's narrative
+type OrganizationNarrativeVersion struct {
+	ID             int       `json:"id"`
+	OrganizationID int       `json:"organization_id"`
+	Content        string    `json:"content"`
+	AuthorID       *int      `json:"author_id,omitempty"`
+	AuthorName     string    `json:"author_name,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
 type OrganizationWithCount struct {
 	ID        int
 	Name      string
@@ -102,3 +124,121 @@ func (r *OrganizationRepository) Delete(ctx context.Context, name string) error
 	}
 	return nil
 }
+
+const organizationColumns = `id, name, COALESCE(narrative, ''), match_threshold, COALESCE(match_top_k, 0), COALESCE(updated_at, NOW())`
+
+func scanOrganization(row interface{ Scan(...interface{}) error }) (*Organization, error) {
+	var o Organization
+	if err := row.Scan(&o.ID, &o.Name, &o.Narrative, &o.MatchThreshold, &o.MatchTopK, &o.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *OrganizationRepository) FindByID(ctx context.Context, id int) (*Organization, error) {
+	return scanOrganization(r.db.QueryRowContext(ctx, "SELECT "+organizationColumns+" FROM organizations WHERE id = $1", id))
+}
+
+func (r *OrganizationRepository) FindByName(ctx context.Context, name string) (*Organization, error) {
+	return scanOrganization(r.db.QueryRowContext(ctx, "SELECT "+organizationColumns+" FROM organizations WHERE name = $1", name))
+}
+
+// ListWithNarrative returns every organization that has a narrative to match against
+func (r *OrganizationRepository) ListWithNarrative(ctx context.Context) ([]Organization, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+organizationColumns+" FROM organizations WHERE narrative IS NOT NULL AND narrative <> '' ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orgs []Organization
+	for rows.Next() {
+		o, err := scanOrganization(rows)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, *o)
+	}
+	return orgs, rows.Err()
+}
+
+// UpdateNarrative replaces the organization's narrative and records it as a
+// new version by authorID
+func (r *OrganizationRepository) UpdateNarrative(ctx context.Context, orgID, authorID int, narrative string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE organizations SET narrative = $1, updated_at = NOW() WHERE id = $2", narrative, orgID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO organization_narrative_versions (organization_id, content, author_id) VALUES ($1, $2, $3)", orgID, narrative, authorID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateMatchSettings sets the organization's inbox threshold and pre-filter size
+func (r *OrganizationRepository) UpdateMatchSettings(ctx context.Context, orgID, matchThreshold, matchTopK int) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE organizations SET match_threshold = $1, match_top_k = NULLIF($2, 0), updated_at = NOW() WHERE id = $3", matchThreshold, matchTopK, orgID)
+	return err
+}
+
+// ListNarrativeVersions returns the organization's narrative history, newest first
+func (r *OrganizationRepository) ListNarrativeVersions(ctx context.Context, orgID int) ([]OrganizationNarrativeVersion, error) {
+	query := `
+		SELECT v.id, v.organization_id, COALESCE(v.content, ''), v.author_id, COALESCE(u.full_name, ''), v.created_at
+		FROM organization_narrative_versions v
+		LEFT JOIN users u ON u.id = v.author_id
+		WHERE v.organization_id = $1
+		ORDER BY v.id DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []OrganizationNarrativeVersion
+	for rows.Next() {
+		var v OrganizationNarrativeVersion
+		var authorID sql.NullInt64
+		if err := rows.Scan(&v.ID, &v.OrganizationID, &v.Content, &authorID, &v.AuthorName, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		if authorID.Valid {
+			id := int(authorID.Int64)
+			v.AuthorID = &id
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+func (r *OrganizationRepository) GetNarrativeVersion(ctx context.Context, id, orgID int) (*OrganizationNarrativeVersion, error) {
+	query := `
+		SELECT v.id, v.organization_id, COALESCE(v.content, ''), v.author_id, COALESCE(u.full_name, ''), v.created_at
+		FROM organization_narrative_versions v
+		LEFT JOIN users u ON u.id = v.author_id
+		WHERE v.id = $1 AND v.organization_id = $2
+	`
+	var v OrganizationNarrativeVersion
+	var authorID sql.NullInt64
+	err := r.db.QueryRowContext(ctx, query, id, orgID).Scan(&v.ID, &v.OrganizationID, &v.Content, &authorID, &v.AuthorName, &v.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if authorID.Valid {
+		aid := int(authorID.Int64)
+		v.AuthorID = &aid
+	}
+	return &v, nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+// UpsertOrganization stores an organization's score, like Upsert does for a user
+func (r *MatchRepository) UpsertOrganization(ctx context.Context, orgID, solicitationID, score int, explanation string, details json.RawMessage, hashes ScoredHashes) error {
+	query := `
+		INSERT INTO organization_matches (organization_id, solicitation_id, score, explanation, details, narrative_hash, content_hash, prompt_version, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		ON CONFLICT (organization_id, solicitation_id) DO UPDATE SET
+			score = EXCLUDED.score,
+			explanation = EXCLUDED.explanation,
+			details = EXCLUDED.details,
+			narrative_hash = EXCLUDED.narrative_hash,
+			content_hash = EXCLUDED.content_hash,
+			prompt_version = EXCLUDED.prompt_version,
+			updated_at = NOW();
+	`
+	var detailsArg interface{}
+	if len(details) > 0 {
+		detailsArg = []byte(details)
+	}
+	_, err := r.db.ExecContext(ctx, query, orgID, solicitationID, score, explanation, detailsArg, hashes.NarrativeHash, hashes.ContentHash, hashes.PromptVersion)
+	return err
+}
+
+// GetOrganizationScoredHashes returns, per solicitation ID, the hashes the
+// organization's existing matches were scored from
+func (r *MatchRepository) GetOrganizationScoredHashes(ctx context.Context, orgID int) (map[int]ScoredHashes, error) {
+	query := `
+		SELECT solicitation_id, COALESCE(narrative_hash, ''), COALESCE(content_hash, ''), COALESCE(prompt_version, 0)
+		FROM organization_matches
+		WHERE organization_id = $1
+	`
+	rows, err := r.db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make(map[int]ScoredHashes)
+	for rows.Next() {
+		var id int
+		var h ScoredHashes
+		if err := rows.Scan(&id, &h.NarrativeHash, &h.ContentHash, &h.PromptVersion); err != nil {
+			return nil, err
+		}
+		hashes[id] = h
+	}
+	return hashes, rows.Err()
+}
+
+// GetOrganizationInbox returns the organization's matches that reach its
+// threshold, best first, as seen by one of its members: solicitations the
+// viewer archived are left out and their personal score is included.
+func (r *MatchRepository) GetOrganizationInbox(ctx context.Context, orgID, viewerID int) ([]MatchedSolicitation, error) {
+	query := `
+		SELECT
+			m.id, m.score, m.explanation, m.details, m.prompt_version, m.created_at,
+			s.id, s.source_id, s.title, s.description, s.agency, s.due_date, s.url, s.raw_data, s.documents,
+			(SELECT u.full_name FROM claims c JOIN users u ON c.user_id = u.id WHERE c.solicitation_id = s.id AND c.claim_type = 'lead' LIMIT 1),
+			(SELECT STRING_AGG(u.full_name, ', ') FROM claims c JOIN users u ON c.user_id = u.id WHERE c.solicitation_id = s.id AND c.claim_type = 'interested'),
+			pm.score
+		FROM organization_matches m
+		JOIN organizations o ON o.id = m.organization_id
+		JOIN solicitations s ON m.solicitation_id = s.id
+		LEFT JOIN matches pm ON pm.solicitation_id = s.id AND pm.user_id = $2
+		WHERE m.organization_id = $1 AND m.score >= o.match_threshold
+		  AND NOT EXISTS (
+			SELECT 1 FROM claims c
+			WHERE c.solicitation_id = s.id AND c.user_id = $2 AND c.archived = TRUE
+		  )
+		ORDER BY m.score DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, orgID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []MatchedSolicitation
+	for rows.Next() {
+		var ms MatchedSolicitation
+		var personal sql.NullInt64
+		if err := scanMatchedSolicitation(rows, &ms, &personal); err != nil {
+			return nil, err
+		}
+		if personal.Valid {
+			score := int(personal.Int64)
+			ms.PersonalScore = &score
+		}
+		results = append(results, ms)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// PromptTemplate is one saved version of a named LLM prompt
+type PromptTemplate struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	Version    int       `json:"version"`
+	Body       string    `json:"body"`
+	AuthorID   *int      `json:"author_id,omitempty"`
+	AuthorName string    `json:"author_name,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type PromptRepository struct {
+	db *sql.DB
+}
+
+func NewPromptRepository(db *sql.DB) *PromptRepository {
+	return &PromptRepository{db: db}
+}
+
+const promptColumns = `p.id, p.name, p.version, p.body, p.author_id, COALESCE(u.full_name, ''), p.created_at`
+
+func scanPrompt(row interface{ Scan(...interface{}) error }) (*PromptTemplate, error) {
+	var p PromptTemplate
+	var authorID sql.NullInt64
+	if err := row.Scan(&p.ID, &p.Name, &p.Version, &p.Body, &authorID, &p.AuthorName, &p.CreatedAt); err != nil {
+		return nil, err
+	}
+	if authorID.Valid {
+		id := int(authorID.Int64)
+		p.AuthorID = &id
+	}
+	return &p, nil
+}
+
+// Latest returns the newest version of a prompt, or nil if it has none
+func (r *PromptRepository) Latest(ctx context.Context, name string) (*PromptTemplate, error) {
+	query := `
+		SELECT ` + promptColumns + `
+		FROM prompt_templates p
+		LEFT JOIN users u ON u.id = p.author_id
+		WHERE p.name = $1
+		ORDER BY p.version DESC
+		LIMIT 1
+	`
+	p, err := scanPrompt(r.db.QueryRowContext(ctx, query, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// GetVersion returns one version of a prompt, or nil if it does not exist
+func (r *PromptRepository) GetVersion(ctx context.Context, name string, version int) (*PromptTemplate, error) {
+	query := `
+		SELECT ` + promptColumns + `
+		FROM prompt_templates p
+		LEFT JOIN users u ON u.id = p.author_id
+		WHERE p.name = $1 AND p.version = $2
+	`
+	p, err := scanPrompt(r.db.QueryRowContext(ctx, query, name, version))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// ListVersions returns every saved version of a prompt, newest first
+func (r *PromptRepository) ListVersions(ctx context.Context, name string) ([]PromptTemplate, error) {
+	query := `
+		SELECT ` + promptColumns + `
+		FROM prompt_templates p
+		LEFT JOIN users u ON u.id = p.author_id
+		WHERE p.name = $1
+		ORDER BY p.version DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []PromptTemplate
+	for rows.Next() {
+		p, err := scanPrompt(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *p)
+	}
+	return versions, rows.Err()
+}
+
+// Create saves body as the next version of a prompt. An authorID of 0 records
+// no author.
+func (r *PromptRepository) Create(ctx context.Context, name, body string, authorID int) (*PromptTemplate, error) {
+	var author interface{}
+	if authorID != 0 {
+		author = authorID
+	}
+	query := `
+		INSERT INTO prompt_templates (name, version, body, author_id, created_at)
+		SELECT $1::text, COALESCE(MAX(version), 0) + 1, $2::text, $3::int, NOW()
+		FROM prompt_templates
+		WHERE name = $1
+		RETURNING id
+	`
+	var id int
+	if err := r.db.QueryRowContext(ctx, query, name, body, author).Scan(&id); err != nil {
+		return nil, err
+	}
+
+	p, err := scanPrompt(r.db.QueryRowContext(ctx, `
+		SELECT `+promptColumns+`
+		FROM prompt_templates p
+		LEFT JOIN users u ON u.id = p.author_id
+		WHERE p.id = $1
+	`, id))
+	return p, err
+}
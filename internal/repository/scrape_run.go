@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type ScrapeRun struct {
+	ID           int            `json:"id"`
+	Trigger      string         `json:"trigger"`
+	Status       string         `json:"status"`
+	ItemsFound   int            `json:"items_found"`
+	ItemsSaved   int            `json:"items_saved"`
+	SourceCounts map[string]int `json:"source_counts"`
+	Error        string         `json:"error,omitempty"`
+	StartedAt    time.Time      `json:"started_at"`
+	FinishedAt   *time.Time     `json:"finished_at,omitempty"`
+
+	Sources []ScrapeSourceResult `json:"sources,omitempty"`
+}
+
+// ScrapeSourceResult is the outcome of one scraper within a run
+type ScrapeSourceResult struct {
+	ID           int       `json:"id"`
+	RunID        int       `json:"run_id"`
+	Source       string    `json:"source"`
+	ItemsFound   int       `json:"items_found"`
+	ItemsNew     int       `json:"items_new"`
+	ItemsUpdated int       `json:"items_updated"`
+	DurationMS   int64     `json:"duration_ms"`
+	Error        string    `json:"error,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// SourceHealth compares a scraper's latest result with its recent history
+type SourceHealth struct {
+	Source      string    `json:"source"`
+	LastRunAt   time.Time `json:"last_run_at"`
+	LastFound   int       `json:"last_found"`
+	LastError   string    `json:"last_error,omitempty"`
+	TrailingAvg float64   `json:"trailing_avg"`
+	Alert       bool      `json:"alert"`
+}
+
+// healthWindow is the number of previous runs averaged for SourceHealth
+const healthWindow = 7
+
+type ScrapeRunRepository struct {
+	db *sql.DB
+}
+
+func NewScrapeRunRepository(db *sql.DB) *ScrapeRunRepository {
+	return &ScrapeRunRepository{db: db}
+}
+
+// Start records the beginning of a scrape run
+func (r *ScrapeRunRepository) Start(ctx context.Context, trigger string) (*ScrapeRun, error) {
+	run := &ScrapeRun{
+		Trigger:      trigger,
+		Status:       "running",
+		SourceCounts: make(map[string]int),
+	}
+	query := `INSERT INTO scrape_runs (trigger, status, started_at) VALUES ($1, 'running', NOW()) RETURNING id, started_at`
+	if err := r.db.QueryRowContext(ctx, query, trigger).Scan(&run.ID, &run.StartedAt); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// Finish stores the outcome of a scrape run
+func (r *ScrapeRunRepository) Finish(ctx context.Context, run *ScrapeRun) error {
+	counts, err := json.Marshal(run.SourceCounts)
+	if err != nil {
+		return err
+	}
+
+	var errText interface{}
+	if run.Error != "" {
+		errText = run.Error
+	}
+
+	query := `
+		UPDATE scrape_runs
+		SET status = $1, items_found = $2, items_saved = $3, source_counts = $4, error = $5, finished_at = NOW()
+		WHERE id = $6
+	`
+	_, err = r.db.ExecContext(ctx, query, run.Status, run.ItemsFound, run.ItemsSaved, counts, errText, run.ID)
+	return err
+}
+
+// List returns the most recent scrape runs
+func (r *ScrapeRunRepository) List(ctx context.Context, limit int) ([]ScrapeRun, error) {
+	query := `
+		SELECT id, trigger, status, items_found, items_saved, source_counts, error, started_at, finished_at
+		FROM scrape_runs
+		ORDER BY started_at DESC LIMIT $1
+	`
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []ScrapeRun
+	for rows.Next() {
+		var run ScrapeRun
+		var counts []byte
+		var errText sql.NullString
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&run.ID, &run.Trigger, &run.Status, &run.ItemsFound, &run.ItemsSaved, &counts, &errText, &run.StartedAt, &finishedAt); err != nil {
+			return nil, err
+		}
+		if len(counts) > 0 {
+			json.Unmarshal(counts, &run.SourceCounts)
+		}
+		run.Error = errText.String
+		if finishedAt.Valid {
+			t := finishedAt.Time
+			run.FinishedAt = &t
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// AddSource records the outcome of a single scraper for a run
+func (r *ScrapeRunRepository) AddSource(ctx context.Context, res ScrapeSourceResult) error {
+	var errText interface{}
+	if res.Error != "" {
+		errText = res.Error
+	}
+
+	query := `
+		INSERT INTO scrape_run_sources (run_id, source, items_found, items_new, items_updated, duration_ms, error, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+	`
+	_, err := r.db.ExecContext(ctx, query, res.RunID, res.Source, res.ItemsFound, res.ItemsNew, res.ItemsUpdated, res.DurationMS, errText)
+	return err
+}
+
+// ListWithSources returns the most recent runs with their per-scraper results attached
+func (r *ScrapeRunRepository) ListWithSources(ctx context.Context, limit int) ([]ScrapeRun, error) {
+	runs, err := r.List(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, run_id, source, items_found, items_new, items_updated, duration_ms, error, created_at
+		FROM scrape_run_sources
+		WHERE run_id IN (SELECT id FROM scrape_runs ORDER BY started_at DESC LIMIT $1)
+		ORDER BY id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byRun := make(map[int][]ScrapeSourceResult)
+	for rows.Next() {
+		var res ScrapeSourceResult
+		var errText sql.NullString
+		if err := rows.Scan(&res.ID, &res.RunID, &res.Source, &res.ItemsFound, &res.ItemsNew, &res.ItemsUpdated, &res.DurationMS, &errText, &res.CreatedAt); err != nil {
+			return nil, err
+		}
+		res.Error = errText.String
+		byRun[res.RunID] = append(byRun[res.RunID], res)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range runs {
+		runs[i].Sources = byRun[runs[i].ID]
+	}
+	return runs, nil
+}
+
+// SourceHealth returns the latest result for each scraper alongside the average
+// item count of its previous runs. A source is flagged when it errored or when
+// it found nothing despite a non-zero trailing average.
+func (r *ScrapeRunRepository) SourceHealth(ctx context.Context) ([]SourceHealth, error) {
+	query := fmt.Sprintf(`
+		SELECT source, items_found, error, created_at, COALESCE(trailing_avg, 0)
+		FROM (
+			SELECT source, items_found, error, created_at,
+				AVG(items_found) OVER (PARTITION BY source ORDER BY created_at ROWS BETWEEN %d PRECEDING AND 1 PRECEDING) AS trailing_avg,
+				ROW_NUMBER() OVER (PARTITION BY source ORDER BY created_at DESC) AS rn
+			FROM scrape_run_sources
+		) latest
+		WHERE rn = 1
+		ORDER BY source
+	`, healthWindow)
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var health []SourceHealth
+	for rows.Next() {
+		var h SourceHealth
+		var errText sql.NullString
+		if err := rows.Scan(&h.Source, &h.LastFound, &errText, &h.LastRunAt, &h.TrailingAvg); err != nil {
+			return nil, err
+		}
+		h.LastError = errText.String
+		h.Alert = h.LastError != "" || (h.LastFound == 0 && h.TrailingAvg > 0)
+		health = append(health, h)
+	}
+	return health, rows.Err()
+}
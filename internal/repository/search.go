@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"bd_bot/internal/scraper"
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Due-date facet buckets, relative to now
+const (
+	DueExpired = "expired"
+	DueWeek    = "0-7"
+	DueTwoWeek = "8-14"
+	DueMonth   = "15-30"
+	DueLater   = "30+"
+	DueNone    = "none"
+)
+
+// dueBucketExpr assigns each solicitation to a due-date facet bucket
+const dueBucketExpr = `CASE
+	WHEN s.due_date IS NULL THEN 'none'
+	WHEN s.due_date < NOW() THEN 'expired'
+	WHEN s.due_date < NOW() + INTERVAL '7 days' THEN '0-7'
+	WHEN s.due_date < NOW() + INTERVAL '14 days' THEN '8-14'
+	WHEN s.due_date < NOW() + INTERVAL '30 days' THEN '15-30'
+	ELSE '30+' END`
+
+// ValidDueBucket reports whether b is one of the Due* bucket names
+func ValidDueBucket(b string) bool {
+	switch b {
+	case DueExpired, DueWeek, DueTwoWeek, DueMonth, DueLater, DueNone:
+		return true
+	}
+	return false
+}
+
+// SearchQuery is a full-text search. Query uses web search syntax: quoted
+// phrases, OR, and -negation; a trailing * on a word matches it as a prefix.
+type SearchQuery struct {
+	Query  string
+	Agency string // restrict hits to one agency
+	Due    string // restrict hits to one Due* bucket
+	Limit  int
+	Offset int
+}
+
+type SearchHit struct {
+	Solicitation scraper.Solicitation `json:"solicitation"`
+	Rank         float64              `json:"rank"`
+	// TitleHTML and SnippetHTML are HTML-escaped with matches wrapped in <mark>
+	TitleHTML   string `json:"title_html"`
+	SnippetHTML string `json:"snippet_html"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// SearchResults holds one page of hits. Facets count every match of the query,
+// ignoring the Agency and Due restrictions, so they can be used to refine it.
+type SearchResults struct {
+	Total  int                     `json:"total"`
+	Hits   []SearchHit             `json:"hits"`
+	Facets map[string][]FacetCount `json:"facets"`
+}
+
+// headline markers are control characters so they cannot collide with
+// document text; they are turned into <mark> after HTML escaping
+const (
+	hlStart = "\x02"
+	hlStop  = "\x03"
+)
+
+// maxAgencyFacets caps the agency facet to the most frequent agencies
+const maxAgencyFacets = 20
+
+var prefixTerm = regexp.MustCompile(`^-?[\pL\pN]+\*$`)
+
+// tsQuery builds the tsquery expression for q, binding its parameters via arg.
+// websearch_to_tsquery has no prefix syntax, so words ending in * are pulled
+// out and matched with to_tsquery's :* instead.
+func tsQuery(q string, arg func(interface{}) string) string {
+	var rest, prefixes []string
+	inQuote := false
+	for _, word := range strings.Fields(q) {
+		if strings.Count(word, `"`)%2 == 1 {
+			inQuote = !inQuote
+		}
+		if !inQuote && prefixTerm.MatchString(word) {
+			term := strings.TrimSuffix(word, "*")
+			if strings.HasPrefix(term, "-") {
+				prefixes = append(prefixes, "!"+term[1:]+":*")
+			} else {
+				prefixes = append(prefixes, term+":*")
+			}
+			continue
+		}
+		rest = append(rest, word)
+	}
+
+	var parts []string
+	if len(rest) > 0 {
+		parts = append(parts, fmt.Sprintf("websearch_to_tsquery('english', %s)", arg(strings.Join(rest, " "))))
+	}
+	if len(prefixes) > 0 {
+		parts = append(parts, fmt.Sprintf("to_tsquery('english', %s)", arg(strings.Join(prefixes, " & "))))
+	}
+	return strings.Join(parts, " && ")
+}
+
+// Search ranks solicitations against q.Query over title, description, agency
+// and extracted document text
+func (r *SolicitationRepository) Search(ctx context.Context, q SearchQuery) (*SearchResults, error) {
+	if q.Limit <= 0 {
+		q.Limit = 20
+	}
+	if q.Limit > 100 {
+		q.Limit = 100
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	results := &SearchResults{Hits: []SearchHit{}, Facets: map[string][]FacetCount{"agency": {}, "due": {}}}
+	tsq := tsQuery(q.Query, arg)
+	if tsq == "" {
+		return results, nil
+	}
+	match := "s.text_search @@ (" + tsq + ")"
+
+	facetQuery := fmt.Sprintf(`
+		SELECT 'agency', COALESCE(NULLIF(s.agency, ''), 'Unknown'), COUNT(*) FROM solicitations s WHERE %[1]s GROUP BY 2
+		UNION ALL
+		SELECT 'due', %[2]s, COUNT(*) FROM solicitations s WHERE %[1]s GROUP BY 2
+		ORDER BY 1, 3 DESC, 2
+	`, match, dueBucketExpr)
+	rows, err := r.db.QueryContext(ctx, facetQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var facet string
+		var fc FacetCount
+		if err := rows.Scan(&facet, &fc.Value, &fc.Count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		results.Facets[facet] = append(results.Facets[facet], fc)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(results.Facets["agency"]) > maxAgencyFacets {
+		results.Facets["agency"] = results.Facets["agency"][:maxAgencyFacets]
+	}
+
+	where := []string{match}
+	if q.Agency != "" {
+		where = append(where, "COALESCE(NULLIF(s.agency, ''), 'Unknown') = "+arg(q.Agency))
+	}
+	if q.Due != "" {
+		where = append(where, dueBucketExpr+" = "+arg(q.Due))
+	}
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM solicitations s WHERE "+strings.Join(where, " AND "), args...).Scan(&results.Total); err != nil {
+		return nil, err
+	}
+
+	opts := arg("StartSel=" + hlStart + ", StopSel=" + hlStop + ", MaxFragments=2, MinWords=8, MaxWords=25, FragmentDelimiter=\" … \"")
+	titleOpts := arg("StartSel=" + hlStart + ", StopSel=" + hlStop + ", HighlightAll=true")
+
+	// Headlines are expensive on long document text, so only build them for the page
+	query := fmt.Sprintf(`
+		WITH hits AS (
+			SELECT s.id, ts_rank_cd(s.text_search, (%[1]s)) AS rank
+			FROM solicitations s
+			WHERE %[2]s
+			ORDER BY rank DESC, s.id
+			LIMIT %[3]d OFFSET %[4]d
+		)
+		SELECT s.id, s.source_id, s.title, COALESCE(s.description, ''), COALESCE(s.agency, ''), s.due_date, COALESCE(s.url, ''), hits.rank,
+			ts_headline('english', s.title, (%[1]s), %[5]s),
+			ts_headline('english', COALESCE(s.description, '') || ' ' || COALESCE(s.document_text, ''), (%[1]s), %[6]s)
+		FROM hits
+		JOIN solicitations s ON s.id = hits.id
+		ORDER BY hits.rank DESC, s.id
+	`, tsq, strings.Join(where, " AND "), q.Limit, q.Offset, titleOpts, opts)
+
+	rows, err = r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hit SearchHit
+		var dueDate sql.NullTime
+		sol := &hit.Solicitation
+		if err := rows.Scan(&sol.ID, &sol.SourceID, &sol.Title, &sol.Description, &sol.Agency, &dueDate, &sol.URL, &hit.Rank, &hit.TitleHTML, &hit.SnippetHTML); err != nil {
+			return nil, err
+		}
+		if dueDate.Valid {
+			sol.DueDate = dueDate.Time
+		}
+		hit.TitleHTML = highlight(hit.TitleHTML)
+		hit.SnippetHTML = highlight(hit.SnippetHTML)
+		results.Hits = append(results.Hits, hit)
+	}
+	return results, rows.Err()
+}
+
+// highlight escapes a ts_headline result and turns its markers into <mark> tags
+func highlight(s string) string {
+	s = html.EscapeString(s)
+	return strings.NewReplacer(hlStart, "<mark>", hlStop, "</mark>").Replace(s)
+}
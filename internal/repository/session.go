@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// Session is a login session. The token itself is only returned by Create;
+// the database stores its SHA-256 hash.
+type Session struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	UserEmail  string     `json:"user_email,omitempty"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type SessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// HashToken returns the stored form of a session token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create starts a session for the user and returns the opaque token to hand to the client
+func (r *SessionRepository) Create(ctx context.Context, userID int, userAgent, ipAddress string, ttl time.Duration) (string, *Session, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	session := &Session{UserID: userID, UserAgent: userAgent, IPAddress: ipAddress}
+	query := `
+		INSERT INTO sessions (user_id, token_hash, user_agent, ip_address, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW(), $5)
+		RETURNING id, created_at, last_seen_at, expires_at
+	`
+	err := r.db.QueryRowContext(ctx, query, userID, HashToken(token), userAgent, ipAddress, time.Now().Add(ttl)).
+		Scan(&session.ID, &session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, session, nil
+}
+
+// Validate returns the active session for a token, or sql.ErrNoRows if the
+// token is unknown, expired or revoked
+func (r *SessionRepository) Validate(ctx context.Context, token string) (*Session, error) {
+	query := `
+		SELECT id, user_id, COALESCE(user_agent, ''), COALESCE(ip_address, ''), created_at, last_seen_at, expires_at
+		FROM sessions
+		WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()
+	`
+	var s Session
+	err := r.db.QueryRowContext(ctx, query, HashToken(token)).
+		Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Touch updates last_seen_at, at most once every few minutes per session
+func (r *SessionRepository) Touch(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE sessions SET last_seen_at = NOW() WHERE id = $1 AND last_seen_at < NOW() - INTERVAL '5 minutes'`, id)
+	return err
+}
+
+// Revoke ends a single session
+func (r *SessionRepository) Revoke(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RevokeAllForUser ends every active session of a user and returns how many were revoked
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, userID int) (int, error) {
+	return r.RevokeOthersForUser(ctx, userID, 0)
+}
+
+// RevokeOthersForUser ends every active session of a user except keepID and
+// returns how many were revoked
+func (r *SessionRepository) RevokeOthersForUser(ctx context.Context, userID, keepID int) (int, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL AND expires_at > NOW()`, userID, keepID)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// ListActive returns unexpired, unrevoked sessions, most recently used first.
+// A userID of 0 lists sessions for every user.
+func (r *SessionRepository) ListActive(ctx context.Context, userID int) ([]Session, error) {
+	query := `
+		SELECT s.id, s.user_id, u.email, COALESCE(s.user_agent, ''), COALESCE(s.ip_address, ''), s.created_at, s.last_seen_at, s.expires_at
+		FROM sessions s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.revoked_at IS NULL AND s.expires_at > NOW() AND ($1 = 0 OR s.user_id = $1)
+		ORDER BY s.last_seen_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserEmail, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// DeleteExpired removes sessions that expired or were revoked more than a day ago
+func (r *SessionRepository) DeleteExpired(ctx context.Context) (int, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at < NOW() - INTERVAL '1 day' OR revoked_at < NOW() - INTERVAL '1 day'`)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
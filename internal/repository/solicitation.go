@@ -43,21 +43,44 @@ func NewSolicitationRepository(db *sql.DB) *SolicitationRepository {
 	return &SolicitationRepository{db: db}
 }
 
-// Upsert inserts a solicitation or updates it if source_id already exists
-func (r *SolicitationRepository) Upsert(ctx context.Context, sol scraper.Solicitation) error {
+// UpsertResult reports what Upsert did with a scraped solicitation
+type UpsertResult int
+
+const (
+	UpsertUnchanged UpsertResult = iota
+	UpsertInserted
+	UpsertUpdated
+)
+
+// Upsert inserts a solicitation or updates it if source_id already exists.
+// Rows whose content is identical to the stored copy are left untouched;
+// otherwise a field-level diff is recorded in solicitation_revisions.
+func (r *SolicitationRepository) Upsert(ctx context.Context, sol scraper.Solicitation) (UpsertResult, error) {
 	rawData, err := json.Marshal(sol.RawData)
 	if err != nil {
-		return fmt.Errorf("error marshalling raw data: %w", err)
+		return UpsertUnchanged, fmt.Errorf("error marshalling raw data: %w", err)
 	}
 
 	docsData, err := json.Marshal(sol.Documents)
 	if err != nil {
-		return fmt.Errorf("error marshalling documents: %w", err)
+		return UpsertUnchanged, fmt.Errorf("error marshalling documents: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return UpsertUnchanged, err
+	}
+	defer tx.Rollback()
+
+	// Lock the current copy (if any) so the diff matches what we overwrite
+	existing, err := r.currentForUpdate(ctx, tx, sol.SourceID)
+	if err != nil {
+		return UpsertUnchanged, err
 	}
 
 	query := `
-		INSERT INTO solicitations (source_id, title, description, agency, due_date, url, raw_data, documents, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		INSERT INTO solicitations (source_id, title, description, agency, due_date, url, raw_data, documents, fingerprint, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
 		ON CONFLICT (source_id) DO UPDATE SET
 			title = EXCLUDED.title,
 			description = EXCLUDED.description,
@@ -66,7 +89,14 @@ func (r *SolicitationRepository) Upsert(ctx context.Context, sol scraper.Solicit
 			url = EXCLUDED.url,
 			raw_data = EXCLUDED.raw_data,
 			documents = EXCLUDED.documents,
-			updated_at = NOW();
+			fingerprint = EXCLUDED.fingerprint,
+			updated_at = NOW()
+		WHERE (solicitations.title, solicitations.description, solicitations.agency, solicitations.due_date,
+		       solicitations.url, solicitations.raw_data, solicitations.documents, solicitations.fingerprint)
+		      IS DISTINCT FROM
+		      (EXCLUDED.title, EXCLUDED.description, EXCLUDED.agency, EXCLUDED.due_date,
+		       EXCLUDED.url, EXCLUDED.raw_data, EXCLUDED.documents, EXCLUDED.fingerprint)
+		RETURNING id, (xmax = 0) AS inserted;
 	`
 
 	// Handle zero time for due_date
@@ -75,7 +105,14 @@ func (r *SolicitationRepository) Upsert(ctx context.Context, sol scraper.Solicit
 		dueDate = sol.DueDate
 	}
 
-	_, err = r.db.ExecContext(ctx, query,
+	var fingerprint interface{}
+	if sol.Fingerprint != "" {
+		fingerprint = sol.Fingerprint
+	}
+
+	var id int
+	var inserted bool
+	err = tx.QueryRowContext(ctx, query,
 		sol.SourceID,
 		sol.Title,
 		sol.Description,
@@ -84,9 +121,45 @@ func (r *SolicitationRepository) Upsert(ctx context.Context, sol scraper.Solicit
 		sol.URL,
 		rawData,
 		docsData,
-	)
+		fingerprint,
+	).Scan(&id, &inserted)
+
+	// No row is returned when the existing copy is identical
+	if err == sql.ErrNoRows {
+		return UpsertUnchanged, nil
+	}
+	if err != nil {
+		return UpsertUnchanged, err
+	}
+	if inserted || existing == nil {
+		return UpsertInserted, tx.Commit()
+	}
 
-	return err
+	if changes := diffSolicitation(existing, &sol); len(changes) > 0 {
+		if err := insertRevision(ctx, tx, id, changes); err != nil {
+			return UpsertUnchanged, fmt.Errorf("error recording revision: %w", err)
+		}
+	}
+	return UpsertUpdated, tx.Commit()
+}
+
+// Fingerprints returns the stored listing fingerprint for every solicitation, keyed by source_id
+func (r *SolicitationRepository) Fingerprints(ctx context.Context) (map[string]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT source_id, fingerprint FROM solicitations WHERE fingerprint IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	known := make(map[string]string)
+	for rows.Next() {
+		var sourceID, fingerprint string
+		if err := rows.Scan(&sourceID, &fingerprint); err != nil {
+			return nil, err
+		}
+		known[sourceID] = fingerprint
+	}
+	return known, rows.Err()
 }
 
 // List retrieves all solicitations from the database
@@ -94,7 +167,10 @@ func (r *SolicitationRepository) List(ctx context.Context) ([]scraper.Solicitati
 	query := `
 		SELECT s.id, s.source_id, s.title, s.description, s.agency, s.due_date, s.url, s.raw_data, s.documents,
 		(SELECT u.full_name FROM claims c JOIN users u ON c.user_id = u.id WHERE c.solicitation_id = s.id AND c.claim_type = 'lead' LIMIT 1),
-		(SELECT STRING_AGG(u.full_name, ', ') FROM claims c JOIN users u ON c.user_id = u.id WHERE c.solicitation_id = s.id AND c.claim_type = 'interested')
+		(SELECT STRING_AGG(u.full_name, ', ') FROM claims c JOIN users u ON c.user_id = u.id WHERE c.solicitation_id = s.id AND c.claim_type = 'interested'),
+		EXISTS (SELECT 1 FROM solicitation_revisions r WHERE r.solicitation_id = s.id),
+		EXISTS (SELECT 1 FROM solicitation_revisions r WHERE r.solicitation_id = s.id AND r.changes ? 'due_date'),
+		COALESCE(s.document_text, ''), s.updated_at
 		FROM solicitations s
 		ORDER BY s.created_at DESC
 	`
@@ -113,6 +189,7 @@ func (r *SolicitationRepository) List(ctx context.Context) ([]scraper.Solicitati
 		var dueDate sql.NullTime
 		var leadName sql.NullString
 		var interestedParties sql.NullString
+		var updatedAt sql.NullTime
 
 		if err := rows.Scan(
 			&sol.ID,
@@ -126,6 +203,10 @@ func (r *SolicitationRepository) List(ctx context.Context) ([]scraper.Solicitati
 			&docsData,
 			&leadName,
 			&interestedParties,
+			&sol.Amended,
+			&sol.DeadlineMoved,
+			&sol.DocumentText,
+			&updatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -133,6 +214,9 @@ func (r *SolicitationRepository) List(ctx context.Context) ([]scraper.Solicitati
 		if dueDate.Valid {
 			sol.DueDate = dueDate.Time
 		}
+		if updatedAt.Valid {
+			sol.UpdatedAt = updatedAt.Time
+		}
 		if leadName.Valid {
 			name := leadName.String
 			sol.LeadName = &name
@@ -161,7 +245,9 @@ func (r *SolicitationRepository) List(ctx context.Context) ([]scraper.Solicitati
 func (r *SolicitationRepository) GetByID(ctx context.Context, idStr string) (*SolicitationDetail, error) {
 	// 1. Fetch Solicitation
 	query := `
-		SELECT id, source_id, title, description, agency, due_date, url, raw_data, documents
+		SELECT id, source_id, title, description, agency, due_date, url, raw_data, documents,
+		EXISTS (SELECT 1 FROM solicitation_revisions r WHERE r.solicitation_id = solicitations.id),
+		EXISTS (SELECT 1 FROM solicitation_revisions r WHERE r.solicitation_id = solicitations.id AND r.changes ? 'due_date')
 		FROM solicitations
 		WHERE source_id = $1
 	`
@@ -187,6 +273,8 @@ func (r *SolicitationRepository) GetByID(ctx context.Context, idStr string) (*So
 		&sol.URL,
 		&rawData,
 		&docsData,
+		&sol.Amended,
+		&sol.DeadlineMoved,
 	)
 	if err != nil {
 		return nil, err
@@ -340,45 +428,3 @@ func (r *SolicitationRepository) UpsertClaim(ctx context.Context, userID, solID
 		_, err := r.db.ExecContext(ctx, query, solID, senderID, recipientEmail, message)
 		return err
 	}
-	
-	func (r *SolicitationRepository) Search(ctx context.Context, query string) ([]scraper.Solicitation, error) {
-	
-		queryStr := `
-	
-			SELECT id, source_id, title, description, agency, due_date, url
-	
-			FROM solicitations
-	
-			WHERE text_search @@ plainto_tsquery('english', 	
-	)
-	
-			ORDER BY ts_rank(text_search, plainto_tsquery('english', 	
-	)) DESC
-	
-			LIMIT 5
-	
-		`
-	
-		rows, err := r.db.QueryContext(ctx, queryStr, query)
-	
-	
-		if err != nil {
-			return nil, err
-		}
-		defer rows.Close()
-	
-		var results []scraper.Solicitation
-		for rows.Next() {
-			var s scraper.Solicitation
-			var dueDate sql.NullTime
-			if err := rows.Scan(&s.ID, &s.SourceID, &s.Title, &s.Description, &s.Agency, &dueDate, &s.URL); err != nil {
-				return nil, err
-			}
-			if dueDate.Valid {
-				s.DueDate = dueDate.Time
-			}
-			results = append(results, s)
-		}
-		return results, nil
-	}
-	
\ No newline at end of file
@@ -0,0 +1,293 @@
+package repository
+
+import (
+	"bd_bot/internal/scraper"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Sort orders accepted by SolicitationFilter.Sort
+const (
+	SortDue     = "due"
+	SortCreated = "created"
+	SortScore   = "score"
+)
+
+// DefaultPageSize and MaxPageSize bound SolicitationFilter.Limit
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// ErrInvalidCursor is returned when a cursor was not produced by ListPage or
+// belongs to a different sort order or direction
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SolicitationFilter selects one page of solicitations. Zero values mean "no filter".
+type SolicitationFilter struct {
+	Agency    string
+	DueAfter  *time.Time
+	DueBefore *time.Time
+	HasLead   *bool
+	Text      string
+
+	// ViewerID is the signed-in user. ClaimedByViewer, Archived and the
+	// score sort are relative to them and require it to be set.
+	ViewerID        int
+	ClaimedByViewer bool
+	Archived        *bool
+
+	Sort   string // SortDue (default), SortCreated or SortScore
+	Desc   *bool  // nil uses the sort's natural direction
+	Cursor string
+	Limit  int
+}
+
+// SolicitationPage is one page of results. NextCursor is empty on the last page.
+type SolicitationPage struct {
+	Items      []scraper.Solicitation `json:"items"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+	Total      int                    `json:"total"`
+}
+
+type sortSpec struct {
+	expr          string // sort key, evaluated per row
+	cast          string // type to cast the cursor key back to
+	descByDefault bool
+}
+
+var sortSpecs = map[string]sortSpec{
+	// Solicitations without a due date sort after every dated one
+	SortDue:     {expr: "COALESCE(s.due_date, 'infinity'::timestamptz)", cast: "timestamptz"},
+	SortCreated: {expr: "s.created_at", cast: "timestamptz", descByDefault: true},
+	SortScore:   {expr: "COALESCE(m.score, -1)", cast: "int", descByDefault: true},
+}
+
+// pageCursor is the keyset position after the last row of a page. The key is
+// kept in Postgres' text form so timestamps round-trip without losing precision.
+type pageCursor struct {
+	Sort string `json:"s"`
+	Desc bool   `json:"d"`
+	Key  string `json:"k"`
+	ID   int    `json:"id"`
+}
+
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// ListPage returns one page of solicitations matching f, using keyset
+// pagination so deep pages cost the same as the first
+func (r *SolicitationRepository) ListPage(ctx context.Context, f SolicitationFilter) (*SolicitationPage, error) {
+	if f.Sort == "" {
+		f.Sort = SortDue
+	}
+	spec, ok := sortSpecs[f.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort %q", f.Sort)
+	}
+	if f.ViewerID == 0 && (f.ClaimedByViewer || f.Archived != nil || f.Sort == SortScore) {
+		return nil, errors.New("filter requires a signed-in user")
+	}
+	if f.Limit <= 0 {
+		f.Limit = DefaultPageSize
+	}
+	if f.Limit > MaxPageSize {
+		f.Limit = MaxPageSize
+	}
+	desc := spec.descByDefault
+	if f.Desc != nil {
+		desc = *f.Desc
+	}
+
+	// A cursor only continues the ordering it came from: with another sort or
+	// direction its keyset condition would skip or repeat rows
+	var cursor *pageCursor
+	if f.Cursor != "" {
+		c, err := decodeCursor(f.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if c.Sort != f.Sort || c.Desc != desc {
+			return nil, ErrInvalidCursor
+		}
+		cursor = c
+	}
+
+	var where []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if f.Agency != "" {
+		where = append(where, "s.agency = "+arg(f.Agency))
+	}
+	if f.DueAfter != nil {
+		where = append(where, "s.due_date >= "+arg(*f.DueAfter))
+	}
+	if f.DueBefore != nil {
+		where = append(where, "s.due_date < "+arg(*f.DueBefore))
+	}
+	if f.HasLead != nil {
+		cond := "EXISTS (SELECT 1 FROM claims c WHERE c.solicitation_id = s.id AND c.claim_type = 'lead')"
+		if !*f.HasLead {
+			cond = "NOT " + cond
+		}
+		where = append(where, cond)
+	}
+	if text := strings.TrimSpace(f.Text); text != "" {
+		q := arg(text)
+		like := arg("%" + escapeLike(text) + "%")
+		where = append(where, fmt.Sprintf("(s.text_search @@ plainto_tsquery('english', %s) OR s.title ILIKE %s OR s.agency ILIKE %s)", q, like, like))
+	}
+	if f.ClaimedByViewer {
+		where = append(where, fmt.Sprintf("EXISTS (SELECT 1 FROM claims c WHERE c.solicitation_id = s.id AND c.user_id = %s AND c.claim_type IN ('lead', 'interested'))", arg(f.ViewerID)))
+	}
+	if f.Archived != nil {
+		cond := fmt.Sprintf("EXISTS (SELECT 1 FROM claims c WHERE c.solicitation_id = s.id AND c.user_id = %s AND c.archived)", arg(f.ViewerID))
+		if !*f.Archived {
+			cond = "NOT " + cond
+		}
+		where = append(where, cond)
+	}
+
+	join := "LEFT JOIN matches m ON FALSE"
+	if f.ViewerID != 0 {
+		join = "LEFT JOIN matches m ON m.solicitation_id = s.id AND m.user_id = " + arg(f.ViewerID)
+	}
+
+	// Count before applying the cursor so every page reports the same total
+	countWhere := "TRUE"
+	if len(where) > 0 {
+		countWhere = strings.Join(where, " AND ")
+	}
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM solicitations s %s WHERE %s", join, countWhere)
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	dir, cmp := "ASC", ">"
+	if desc {
+		dir, cmp = "DESC", "<"
+	}
+	if cursor != nil {
+		where = append(where, fmt.Sprintf("(%s, s.id) %s (%s::%s, %s)", spec.expr, cmp, arg(cursor.Key), spec.cast, arg(cursor.ID)))
+	}
+	if len(where) == 0 {
+		where = append(where, "TRUE")
+	}
+
+	// The page is chosen first; claim names and revision flags are only
+	// computed for the rows that are returned
+	query := fmt.Sprintf(`
+		WITH page AS (
+			SELECT s.id, %[1]s AS sort_key, m.score
+			FROM solicitations s
+			%[2]s
+			WHERE %[3]s
+			ORDER BY sort_key %[4]s, s.id %[4]s
+			LIMIT %[5]d
+		)
+		SELECT s.id, s.source_id, s.title, s.description, s.agency, s.due_date, s.url, s.raw_data, s.documents,
+			cl.lead_name, cl.interested, page.score,
+			EXISTS (SELECT 1 FROM solicitation_revisions r WHERE r.solicitation_id = s.id),
+			EXISTS (SELECT 1 FROM solicitation_revisions r WHERE r.solicitation_id = s.id AND r.changes ? 'due_date'),
+			page.sort_key::text
+		FROM page
+		JOIN solicitations s ON s.id = page.id
+		LEFT JOIN LATERAL (
+			SELECT MIN(u.full_name) FILTER (WHERE c.claim_type = 'lead') AS lead_name,
+				STRING_AGG(u.full_name, ', ') FILTER (WHERE c.claim_type = 'interested') AS interested
+			FROM claims c JOIN users u ON c.user_id = u.id
+			WHERE c.solicitation_id = s.id
+		) cl ON TRUE
+		ORDER BY page.sort_key %[4]s, page.id %[4]s
+	`, spec.expr, join, strings.Join(where, " AND "), dir, f.Limit+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &SolicitationPage{Items: []scraper.Solicitation{}, Total: total}
+	var lastKey string
+	for rows.Next() {
+		var sol scraper.Solicitation
+		var rawData, docsData []byte
+		var description, agency, url sql.NullString
+		var dueDate sql.NullTime
+		var leadName, interested sql.NullString
+		var score sql.NullInt64
+		var sortKey string
+
+		if err := rows.Scan(
+			&sol.ID, &sol.SourceID, &sol.Title, &description, &agency, &dueDate, &url, &rawData, &docsData,
+			&leadName, &interested, &score, &sol.Amended, &sol.DeadlineMoved, &sortKey,
+		); err != nil {
+			return nil, err
+		}
+		if len(page.Items) == f.Limit {
+			page.NextCursor = encodeCursor(pageCursor{Sort: f.Sort, Desc: desc, Key: lastKey, ID: page.Items[len(page.Items)-1].ID})
+			break
+		}
+
+		sol.Description, sol.Agency, sol.URL = description.String, agency.String, url.String
+		if dueDate.Valid {
+			sol.DueDate = dueDate.Time
+		}
+		if leadName.Valid {
+			name := leadName.String
+			sol.LeadName = &name
+		}
+		if interested.Valid {
+			names := interested.String
+			sol.InterestedParties = &names
+		}
+		if score.Valid {
+			s := int(score.Int64)
+			sol.MatchScore = &s
+		}
+		if len(rawData) > 0 {
+			if err := json.Unmarshal(rawData, &sol.RawData); err != nil {
+				return nil, fmt.Errorf("error unmarshalling raw data: %w", err)
+			}
+		}
+		if len(docsData) > 0 {
+			if err := json.Unmarshal(docsData, &sol.Documents); err != nil {
+				return nil, fmt.Errorf("error unmarshalling documents: %w", err)
+			}
+		}
+
+		page.Items = append(page.Items, sol)
+		lastKey = sortKey
+	}
+	return page, rows.Err()
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestListPageRejectsMismatchedCursor(t *testing.T) {
+	asc, desc := false, true
+	dueCursor := encodeCursor(pageCursor{Sort: SortDue, Desc: false, Key: "2026-11-01 00:00:00+00", ID: 42})
+	createdCursor := encodeCursor(pageCursor{Sort: SortCreated, Desc: true, Key: "2026-10-01 12:00:00.123456+00", ID: 7})
+
+	tests := []struct {
+		name   string
+		filter SolicitationFilter
+	}{
+		{"not base64", SolicitationFilter{Cursor: "!!!"}},
+		{"not json", SolicitationFilter{Cursor: "bm90IGpzb24"}},
+		{"no id", SolicitationFilter{Cursor: encodeCursor(pageCursor{Sort: SortDue, Key: "x"})}},
+		{"other sort", SolicitationFilter{Sort: SortCreated, Cursor: dueCursor}},
+		{"due cursor reversed", SolicitationFilter{Sort: SortDue, Desc: &desc, Cursor: dueCursor}},
+		{"created cursor reversed", SolicitationFilter{Sort: SortCreated, Desc: &asc, Cursor: createdCursor}},
+	}
+
+	// The cursor is checked before the database is queried
+	repo := &SolicitationRepository{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := repo.ListPage(context.Background(), tt.filter)
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("ListPage() error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := pageCursor{Sort: SortScore, Desc: true, Key: "87", ID: 1234}
+	got, err := decodeCursor(encodeCursor(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != c {
+		t.Errorf("decodeCursor(encodeCursor(%+v)) = %+v", c, *got)
+	}
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"bd_bot/internal/scraper"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// FieldChange holds the previous and new value of a single solicitation field
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// SolicitationRevision is a field-level diff recorded when a scrape changes a solicitation
+type SolicitationRevision struct {
+	ID             int                    `json:"id"`
+	SolicitationID int                    `json:"solicitation_id"`
+	SourceID       string                 `json:"source_id,omitempty"`
+	Title          string                 `json:"title,omitempty"`
+	Changes        map[string]FieldChange `json:"changes"`
+	CreatedAt      time.Time              `json:"created_at"`
+}
+
+// currentForUpdate loads and row-locks the stored copy of a solicitation.
+// It returns nil if the solicitation has not been seen before.
+func (r *SolicitationRepository) currentForUpdate(ctx context.Context, tx *sql.Tx, sourceID string) (*scraper.Solicitation, error) {
+	query := `
+		SELECT id, title, description, agency, due_date, url, documents
+		FROM solicitations
+		WHERE source_id = $1
+		FOR UPDATE
+	`
+	var sol scraper.Solicitation
+	var description sql.NullString
+	var agency sql.NullString
+	var dueDate sql.NullTime
+	var url sql.NullString
+	var docsData []byte
+
+	err := tx.QueryRowContext(ctx, query, sourceID).Scan(&sol.ID, &sol.Title, &description, &agency, &dueDate, &url, &docsData)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sol.SourceID = sourceID
+	sol.Description = description.String
+	sol.Agency = agency.String
+	sol.URL = url.String
+	if dueDate.Valid {
+		sol.DueDate = dueDate.Time
+	}
+	if len(docsData) > 0 {
+		json.Unmarshal(docsData, &sol.Documents)
+	}
+	return &sol, nil
+}
+
+// diffSolicitation compares the user-visible fields of two copies of a solicitation
+func diffSolicitation(old, new *scraper.Solicitation) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+
+	if old.Title != new.Title {
+		changes["title"] = FieldChange{Old: old.Title, New: new.Title}
+	}
+	if old.Description != new.Description {
+		changes["description"] = FieldChange{Old: old.Description, New: new.Description}
+	}
+	if old.Agency != new.Agency {
+		changes["agency"] = FieldChange{Old: old.Agency, New: new.Agency}
+	}
+	if old.URL != new.URL {
+		changes["url"] = FieldChange{Old: old.URL, New: new.URL}
+	}
+	if !old.DueDate.Equal(new.DueDate) {
+		changes["due_date"] = FieldChange{Old: nullableTime(old.DueDate), New: nullableTime(new.DueDate)}
+	}
+
+	oldDocs, newDocs := old.Documents, new.Documents
+	if oldDocs == nil {
+		oldDocs = []scraper.Document{}
+	}
+	if newDocs == nil {
+		newDocs = []scraper.Document{}
+	}
+	if !reflect.DeepEqual(oldDocs, newDocs) {
+		changes["documents"] = FieldChange{Old: oldDocs, New: newDocs}
+	}
+
+	return changes
+}
+
+func nullableTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+func insertRevision(ctx context.Context, tx *sql.Tx, solicitationID int, changes map[string]FieldChange) error {
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO solicitation_revisions (solicitation_id, changes, created_at) VALUES ($1, $2, NOW())`, solicitationID, data)
+	return err
+}
+
+// ListRevisions returns the change history of a solicitation, newest first
+func (r *SolicitationRepository) ListRevisions(ctx context.Context, solicitationID int) ([]SolicitationRevision, error) {
+	query := `
+		SELECT id, solicitation_id, changes, created_at
+		FROM solicitation_revisions
+		WHERE solicitation_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, solicitationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []SolicitationRevision{}
+	for rows.Next() {
+		var rev SolicitationRevision
+		var changes []byte
+		if err := rows.Scan(&rev.ID, &rev.SolicitationID, &changes, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(changes, &rev.Changes); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+// RevisionsSince returns every revision recorded at or after since, oldest first
+func (r *SolicitationRepository) RevisionsSince(ctx context.Context, since time.Time) ([]SolicitationRevision, error) {
+	query := `
+		SELECT r.id, r.solicitation_id, s.source_id, s.title, r.changes, r.created_at
+		FROM solicitation_revisions r
+		JOIN solicitations s ON r.solicitation_id = s.id
+		WHERE r.created_at >= $1
+		ORDER BY r.created_at ASC, r.id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []SolicitationRevision
+	for rows.Next() {
+		var rev SolicitationRevision
+		var changes []byte
+		if err := rows.Scan(&rev.ID, &rev.SolicitationID, &rev.SourceID, &rev.Title, &changes, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(changes, &rev.Changes); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
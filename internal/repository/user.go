@@ -23,6 +23,9 @@ type User struct {
 	AvatarURL    string    `json:"avatar_url"`
 	Organization string    `json:"organization_name"`
 	MatchThreshold int     `json:"match_threshold"`
+	MatchTopK      int     `json:"match_top_k"`
+	DigestFrequency string    `json:"digest_frequency"`
+	DigestSentAt    *time.Time `json:"digest_sent_at,omitempty"`
 }
 
 type NarrativeVersion struct {
@@ -41,7 +44,7 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 }
 
 func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*User, error) {
-	query := `SELECT id, email, full_name, role, narrative, created_at, last_active_at, password_hash, auth_provider, avatar_url, organization_name, match_threshold FROM users WHERE email = $1`
+	query := `SELECT id, email, full_name, role, narrative, created_at, last_active_at, password_hash, auth_provider, avatar_url, organization_name, match_threshold, COALESCE(match_top_k, 0), digest_frequency, digest_sent_at FROM users WHERE email = $1`
 	row := r.db.QueryRowContext(ctx, query, email)
 
 	var user User
@@ -51,11 +54,16 @@ func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*User,
 	var avatarURL sql.NullString
 	var orgName sql.NullString
 	var matchThreshold sql.NullInt64
+	var digestSentAt sql.NullTime
 
-	err := row.Scan(&user.ID, &user.Email, &user.FullName, &user.Role, &narrative, &user.CreatedAt, &user.LastActiveAt, &passwordHash, &authProvider, &avatarURL, &orgName, &matchThreshold)
+	err := row.Scan(&user.ID, &user.Email, &user.FullName, &user.Role, &narrative, &user.CreatedAt, &user.LastActiveAt, &passwordHash, &authProvider, &avatarURL, &orgName, &matchThreshold, &user.MatchTopK, &user.DigestFrequency, &digestSentAt)
 	if err != nil {
 		return nil, err
 	}
+	if digestSentAt.Valid {
+		t := digestSentAt.Time
+		user.DigestSentAt = &t
+	}
 	user.Narrative = narrative.String
 	user.PasswordHash = passwordHash.String
 	user.AuthProvider = authProvider.String
@@ -70,7 +78,7 @@ func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*User,
 }
 
 func (r *UserRepository) FindByID(ctx context.Context, id int) (*User, error) {
-	query := `SELECT id, email, full_name, role, narrative, created_at, last_active_at, password_hash, auth_provider, avatar_url, organization_name, match_threshold FROM users WHERE id = $1`
+	query := `SELECT id, email, full_name, role, narrative, created_at, last_active_at, password_hash, auth_provider, avatar_url, organization_name, match_threshold, COALESCE(match_top_k, 0), digest_frequency, digest_sent_at FROM users WHERE id = $1`
 	row := r.db.QueryRowContext(ctx, query, id)
 
 	var user User
@@ -80,11 +88,16 @@ func (r *UserRepository) FindByID(ctx context.Context, id int) (*User, error) {
 	var avatarURL sql.NullString
 	var orgName sql.NullString
 	var matchThreshold sql.NullInt64
+	var digestSentAt sql.NullTime
 
-	err := row.Scan(&user.ID, &user.Email, &user.FullName, &user.Role, &narrative, &user.CreatedAt, &user.LastActiveAt, &passwordHash, &authProvider, &avatarURL, &orgName, &matchThreshold)
+	err := row.Scan(&user.ID, &user.Email, &user.FullName, &user.Role, &narrative, &user.CreatedAt, &user.LastActiveAt, &passwordHash, &authProvider, &avatarURL, &orgName, &matchThreshold, &user.MatchTopK, &user.DigestFrequency, &digestSentAt)
 	if err != nil {
 		return nil, err
 	}
+	if digestSentAt.Valid {
+		t := digestSentAt.Time
+		user.DigestSentAt = &t
+	}
 	user.Narrative = narrative.String
 	user.PasswordHash = passwordHash.String
 	user.AuthProvider = authProvider.String
@@ -100,8 +113,8 @@ func (r *UserRepository) FindByID(ctx context.Context, id int) (*User, error) {
 
 func (r *UserRepository) Create(ctx context.Context, email, fullName string) (*User, error) {
 	query := `
-		INSERT INTO users (email, full_name, role, created_at, updated_at, last_active_at)
-		VALUES ($1, $2, 'user', NOW(), NOW(), NOW())
+		INSERT INTO users (email, full_name, created_at, updated_at, last_active_at)
+		VALUES ($1, $2, NOW(), NOW(), NOW())
 		RETURNING id, email, full_name, role, narrative, created_at, last_active_at, organization_name
 	`
 	row := r.db.QueryRowContext(ctx, query, email, fullName)
@@ -120,6 +133,69 @@ func (r *UserRepository) Create(ctx context.Context, email, fullName string) (*U
 	return &user, nil
 }
 
+// FindByExternalID looks up a user linked to an identity provider account
+func (r *UserRepository) FindByExternalID(ctx context.Context, provider, externalID string) (*User, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, `SELECT user_id FROM user_identities WHERE provider = $1 AND external_id = $2`, provider, externalID).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// LinkExternal attaches an identity provider account to an existing user.
+// Their other sign-in methods, such as a password, keep working.
+func (r *UserRepository) LinkExternal(ctx context.Context, userID int, provider, externalID string) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO user_identities (user_id, provider, external_id) VALUES ($1, $2, $3)`, userID, provider, externalID)
+	return err
+}
+
+// CreateExternal provisions a user on first single sign-on. The account has
+// no password; auth_provider records where it came from.
+func (r *UserRepository) CreateExternal(ctx context.Context, email, fullName, orgName, provider, externalID string) (*User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if orgName != "" {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO organizations (name) VALUES ($1) ON CONFLICT (name) DO NOTHING", orgName); err != nil {
+			return nil, fmt.Errorf("failed to sync organization: %w", err)
+		}
+	}
+
+	var id int
+	query := `
+		INSERT INTO users (email, full_name, organization_name, auth_provider, created_at, updated_at, last_active_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, NOW(), NOW(), NOW())
+		RETURNING id
+	`
+	if err := tx.QueryRowContext(ctx, query, email, fullName, orgName, provider).Scan(&id); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO user_identities (user_id, provider, external_id) VALUES ($1, $2, $3)`, id, provider, externalID); err != nil {
+		return nil, fmt.Errorf("failed to link identity: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// SetRole changes a user's role. Callers validate the role; the database
+// rejects unknown values.
+func (r *UserRepository) SetRole(ctx context.Context, userID int, role string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET role = $1, updated_at = NOW() WHERE id = $2`, role, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 func (r *UserRepository) UpdateLastActive(ctx context.Context, userID int) error {
 	query := `UPDATE users SET last_active_at = NOW() WHERE id = $1`
 	_, err := r.db.ExecContext(ctx, query, userID)
@@ -153,17 +229,84 @@ func (r *UserRepository) UpdateNarrative(ctx context.Context, userID int, narrat
 	return tx.Commit()
 }
 
-func (r *UserRepository) UpdateProfile(ctx context.Context, userID int, email, fullName, avatarURL, orgName string, matchThreshold int) error {
+// UpdateProfile saves the fields users edit themselves. Organization
+// membership is not among them; see SetOrganization.
+func (r *UserRepository) UpdateProfile(ctx context.Context, userID int, email, fullName, avatarURL string, matchThreshold, matchTopK int, digestFrequency string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE users SET email = $1, full_name = $2, avatar_url = $3, match_threshold = $4, match_top_k = NULLIF($5, 0), digest_frequency = $6, updated_at = NOW() WHERE id = $7`
+	if _, err := tx.ExecContext(ctx, query, email, fullName, avatarURL, matchThreshold, matchTopK, digestFrequency, userID); err != nil {
+		return err
+	}
+
+	// Matches a lower threshold lets in are new to the digest from now on;
+	// those a higher one shuts out lose their timestamp
+	query = `
+		UPDATE matches SET above_threshold_at = CASE WHEN score >= $2 THEN NOW() END
+		WHERE user_id = $1 AND (above_threshold_at IS NULL) = (score >= $2)
+	`
+	if _, err := tx.ExecContext(ctx, query, userID, matchThreshold); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetOrganization puts a user in an organization, creating it if needed, or
+// takes them out of theirs if orgName is empty. Membership grants access to
+// the organization's narrative and shared inbox, so only admins change it.
+func (r *UserRepository) SetOrganization(ctx context.Context, userID int, orgName string) error {
 	if orgName != "" {
-		// Auto-add to organizations table
-		_, err := r.db.ExecContext(ctx, "INSERT INTO organizations (name) VALUES ($1) ON CONFLICT (name) DO NOTHING", orgName)
-		if err != nil {
+		if _, err := r.db.ExecContext(ctx, "INSERT INTO organizations (name) VALUES ($1) ON CONFLICT (name) DO NOTHING", orgName); err != nil {
 			return fmt.Errorf("failed to sync organization: %w", err)
 		}
 	}
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET organization_name = NULLIF($1, ''), updated_at = NOW() WHERE id = $2`, orgName, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListDigestRecipients returns every user who has not turned the match digest off
+func (r *UserRepository) ListDigestRecipients(ctx context.Context) ([]User, error) {
+	query := `
+		SELECT id, email, full_name, COALESCE(match_threshold, 75), digest_frequency, digest_sent_at
+		FROM users
+		WHERE digest_frequency <> 'off'
+		ORDER BY id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var u User
+		var sentAt sql.NullTime
+		if err := rows.Scan(&u.ID, &u.Email, &u.FullName, &u.MatchThreshold, &u.DigestFrequency, &sentAt); err != nil {
+			return nil, err
+		}
+		if sentAt.Valid {
+			t := sentAt.Time
+			u.DigestSentAt = &t
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
 
-	query := `UPDATE users SET email = $1, full_name = $2, avatar_url = $3, organization_name = $4, match_threshold = $5, updated_at = NOW() WHERE id = $6`
-	_, err := r.db.ExecContext(ctx, query, email, fullName, avatarURL, orgName, matchThreshold, userID)
+// MarkDigestSent records when a user's last digest was queued
+func (r *UserRepository) MarkDigestSent(ctx context.Context, userID int, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET digest_sent_at = $1 WHERE id = $2`, at, userID)
 	return err
 }
 
@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"*/15 9-17 * * 1-5", ""},
+		{"0,30 * 1,15 * *", ""},
+		{"0 0 * * 7", ""},
+		{"@weekly", ""},
+		{"  @DAILY ", ""},
+		{"60 * * * *", "minute"},
+		{"* 24 * * *", "hour"},
+		{"* * 0 * *", "day-of-month"},
+		{"* * 32 * *", "day-of-month"},
+		{"* * * 13 *", "month"},
+		{"* * * * 8", "day-of-week"},
+		{"5-1 * * * *", "out of range"},
+		{"*/0 * * * *", "bad step"},
+		{"*/x * * * *", "bad step"},
+		{"1-x * * * *", "bad range"},
+		{"a * * * *", "bad value"},
+		{"* * * *", "5 fields"},
+		{"* * * * * *", "5 fields"},
+		{"", "5 fields"},
+		{"@sometimes", "5 fields"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseCron(tt.expr)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ParseCron(%q) error = %v", tt.expr, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseCron(%q) error = %v, want it to mention %q", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	utc := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"strictly after", "0 0 * * *", utc(1, 1, 0, 0), utc(1, 2, 0, 0)},
+		{"seconds are dropped", "*/20 * * * *", utc(1, 1, 10, 59).Add(30 * time.Second), utc(1, 1, 11, 0)},
+		{"month boundary", "0 0 1 * *", utc(1, 31, 12, 0), utc(2, 1, 0, 0)},
+		{"short month", "30 9 31 * *", utc(4, 1, 0, 0), utc(5, 31, 9, 30)},
+		{"year boundary", "@yearly", utc(6, 1, 0, 0), time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"never", "0 0 30 2 *", utc(1, 1, 0, 0), time.Time{}},
+		// With both day fields restricted either one matches: the 13th is a
+		// Monday, the 17th a Friday
+		{"day of month or week", "0 12 13 * 5", utc(4, 10, 12, 0), utc(4, 13, 12, 0)},
+		{"day of week or month", "0 12 13 * 5", utc(4, 13, 12, 0), utc(4, 17, 12, 0)},
+		{"day of month only", "0 12 13 * *", utc(4, 10, 12, 0), utc(4, 13, 12, 0)},
+		{"weekdays", "0 9 * * 1-5", utc(10, 16, 10, 0), utc(10, 19, 9, 0)},
+		{"@weekly", "@weekly", utc(10, 17, 10, 0), utc(10, 18, 0, 0)},
+		{"7 is Sunday", "0 0 * * 7", utc(10, 17, 10, 0), utc(10, 18, 0, 0)},
+		{"hour range with step", "0 9-17/4 * * *", utc(1, 1, 10, 0), utc(1, 1, 13, 0)},
+
+		// New York springs forward at 02:00 on March 8 and falls back at
+		// 02:00 on November 1
+		{"midnight before spring forward", "0 0 * * *", time.Date(2026, 3, 7, 12, 0, 0, 0, ny), time.Date(2026, 3, 8, 0, 0, 0, 0, ny)},
+		{"skipped time does not run", "30 2 * * *", time.Date(2026, 3, 7, 12, 0, 0, 0, ny), time.Date(2026, 3, 9, 2, 30, 0, 0, ny)},
+		{"hourly over the gap", "0 * * * *", time.Date(2026, 3, 8, 1, 30, 0, 0, ny), time.Date(2026, 3, 8, 3, 0, 0, 0, ny)},
+		{"after spring forward", "0 3 * * *", time.Date(2026, 3, 8, 1, 0, 0, 0, ny), time.Date(2026, 3, 8, 3, 0, 0, 0, ny)},
+		// 01:30 EDT is followed an hour later by 01:30 EST
+		{"repeated time runs once", "30 1 * * *", time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC).In(ny), time.Date(2026, 11, 2, 1, 30, 0, 0, ny)},
+		{"day after fall back", "0 0 * * *", time.Date(2026, 11, 1, 0, 0, 0, 0, ny), time.Date(2026, 11, 2, 0, 0, 0, 0, ny)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestScheduleNextFallBack checks that a time in the repeated hour runs once
+// whichever side of the change the schedule is computed from
+func TestScheduleNextFallBack(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	s, _ := ParseCron("30 1 * * *")
+	first := s.Next(time.Date(2026, 10, 31, 12, 0, 0, 0, ny))
+	if first.Day() != 1 || first.Hour() != 1 || first.Minute() != 30 {
+		t.Fatalf("first run at %s, want 01:30 on November 1", first)
+	}
+	for at := first; at.Before(first.Add(2 * time.Hour)); at = at.Add(10 * time.Minute) {
+		if next := s.Next(at); next.Day() != 2 {
+			t.Errorf("Next(%s) = %s, want November 2", at, next)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package scheduler
+
+import (
+	"bd_bot/internal/db"
+	"bd_bot/internal/documents"
+	"bd_bot/internal/matching"
+	"bd_bot/internal/notify"
+	"bd_bot/internal/repository"
+	"bd_bot/internal/scraper"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// scrapeLockKey is the Postgres advisory lock that keeps replicas from
+// running the pipeline at the same time
+const scrapeLockKey int64 = 0x4a4f534855410001
+
+// runTimeout bounds a single scheduled pipeline run
+const runTimeout = 2 * time.Hour
+
+// documentFetchLimit bounds how many attachments one run downloads
+const documentFetchLimit = 500
+
+// ErrRunInProgress is returned when another process holds the pipeline lock
+var ErrRunInProgress = errors.New("a scrape run is already in progress")
+
+// Pipeline scrapes every registered source, saves the results and then
+// re-runs matching so inboxes reflect the new solicitations
+type Pipeline struct {
+	engine   *scraper.Engine
+	solRepo  *repository.SolicitationRepository
+	runRepo  *repository.ScrapeRunRepository
+	notifier *notify.Notifier
+	fetcher  *documents.Fetcher
+	runner   *matching.Runner
+	digester *notify.Digester
+}
+
+// NewPipeline creates a pipeline. notifier, fetcher, runner and digester may
+// be nil to skip claimant notifications, document downloads, matching and
+// digest emails respectively.
+func NewPipeline(engine *scraper.Engine, solRepo *repository.SolicitationRepository, runRepo *repository.ScrapeRunRepository, notifier *notify.Notifier, fetcher *documents.Fetcher, runner *matching.Runner, digester *notify.Digester) *Pipeline {
+	return &Pipeline{engine: engine, solRepo: solRepo, runRepo: runRepo, notifier: notifier, fetcher: fetcher, runner: runner, digester: digester}
+}
+
+// Run executes one pipeline pass and records it in scrape_runs. Unless full
+// is set, scrapers are given the stored fingerprints so they can skip detail
+// fetches for unchanged solicitations.
+func (p *Pipeline) Run(ctx context.Context, trigger string, full bool) (*repository.ScrapeRun, error) {
+	var opts scraper.ScrapeOptions
+	if !full {
+		known, err := p.solRepo.Fingerprints(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load known solicitations: %w", err)
+		}
+		opts.Known = known
+	}
+
+	run, err := p.runRepo.Start(ctx, trigger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record scrape run: %w", err)
+	}
+	id := run.ID
+	run.Status = "succeeded"
+
+	slog.Info("Launching Midnight Bot", "run_id", id, "trigger", trigger)
+
+	var failed []string
+	for _, res := range p.engine.RunSources(ctx, opts) {
+		run.SourceCounts[res.Source] = len(res.Items)
+		run.ItemsFound += len(res.Items)
+		if res.Err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", res.Source, res.Err))
+		}
+
+		sourceResult := repository.ScrapeSourceResult{
+			RunID:      id,
+			Source:     res.Source,
+			ItemsFound: len(res.Items),
+			DurationMS: res.Duration.Milliseconds(),
+		}
+		if res.Err != nil {
+			sourceResult.Error = res.Err.Error()
+		}
+
+		for _, sol := range res.Items {
+			// Unchanged listings were not re-fetched, so the stored copy is already current
+			if sol.Unchanged {
+				continue
+			}
+
+			outcome, err := p.solRepo.Upsert(ctx, sol)
+			if err != nil {
+				slog.Error("Failed to upsert solicitation", "source_id", sol.SourceID, "error", err)
+				continue
+			}
+			run.ItemsSaved++
+
+			switch outcome {
+			case repository.UpsertInserted:
+				sourceResult.ItemsNew++
+			case repository.UpsertUpdated:
+				sourceResult.ItemsUpdated++
+			}
+		}
+
+		if err := p.runRepo.AddSource(ctx, sourceResult); err != nil {
+			slog.Error("Failed to record scraper result", "run_id", id, "scraper", res.Source, "error", err)
+		}
+	}
+
+	// Fetch attachments before matching so the matcher sees their text
+	if p.fetcher != nil && ctx.Err() == nil {
+		stats, err := p.fetcher.FetchPending(ctx, 0, documentFetchLimit)
+		if err != nil {
+			slog.Error("Failed to fetch documents", "run_id", id, "error", err)
+		} else if stats.Fetched+stats.Failed > 0 {
+			slog.Info("Fetched solicitation documents", "run_id", id, "fetched", stats.Fetched, "failed", stats.Failed)
+		}
+	}
+
+	if p.notifier != nil {
+		count, err := p.notifier.NotifyChangesSince(ctx, run.StartedAt)
+		if err != nil {
+			slog.Error("Failed to notify claimants", "run_id", id, "error", err)
+		} else if count > 0 {
+			slog.Info("Notified claimants of changed solicitations", "run_id", id, "notifications", count)
+		}
+	}
+
+	if p.runner != nil && ctx.Err() == nil {
+		// Only solicitations this run added or changed need scoring, plus
+		// everything for users and organizations whose narrative changed
+		var matchOpts matching.Options
+		if !full {
+			matchOpts.Since = run.StartedAt
+		}
+		stats, err := p.runner.RunForAllUsers(ctx, matchOpts)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("matching: %v", err))
+		}
+		slog.Info("Matching complete", "run_id", id, "scored", stats.Scored, "above_threshold", stats.AboveThreshold, "failed", stats.Failed, "filtered", stats.Filtered, "unchanged", stats.Unchanged)
+
+		if ctx.Err() == nil {
+			stats, err := p.runner.RunForAllOrganizations(ctx, matchOpts)
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("organization matching: %v", err))
+			}
+			slog.Info("Organization matching complete", "run_id", id, "scored", stats.Scored, "above_threshold", stats.AboveThreshold, "failed", stats.Failed, "filtered", stats.Filtered, "unchanged", stats.Unchanged)
+		}
+
+		if p.digester != nil {
+			count, err := p.digester.SendDue(ctx, time.Now())
+			if err != nil {
+				slog.Error("Failed to send match digests", "run_id", id, "error", err)
+			} else if count > 0 {
+				slog.Info("Queued match digests", "run_id", id, "digests", count)
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		run.Status = "failed"
+		run.Error = strings.Join(failed, "; ")
+	}
+
+	// Record the outcome even if the run context was cancelled
+	if err := p.runRepo.Finish(context.Background(), run); err != nil {
+		slog.Error("Failed to record scrape run result", "run_id", id, "error", err)
+	}
+
+	slog.Info("Scraper run complete", "run_id", id, "found", run.ItemsFound, "saved_or_updated", run.ItemsSaved, "status", run.Status)
+	return run, nil
+}
+
+// Scheduler triggers the pipeline on a cron schedule inside the serve process
+type Scheduler struct {
+	schedule *Schedule
+	db       *sql.DB
+	pipeline *Pipeline
+}
+
+func New(expr string, database *sql.DB, pipeline *Pipeline) (*Scheduler, error) {
+	schedule, err := ParseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &Scheduler{schedule: schedule, db: database, pipeline: pipeline}, nil
+}
+
+// Start blocks, running the pipeline at each scheduled time until ctx is done
+func (s *Scheduler) Start(ctx context.Context) {
+	for {
+		next := s.schedule.Next(time.Now())
+		if next.IsZero() {
+			slog.Error("Scrape schedule never fires; scheduler stopped")
+			return
+		}
+		slog.Info("Next scheduled scrape", "at", next)
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		runCtx, cancel := context.WithTimeout(ctx, runTimeout)
+		if _, err := RunLocked(runCtx, s.db, s.pipeline, "schedule", false); err != nil {
+			if errors.Is(err, ErrRunInProgress) {
+				slog.Info("Skipping scheduled scrape; another run holds the lock")
+			} else {
+				slog.Error("Scheduled scrape failed", "error", err)
+			}
+		}
+		cancel()
+	}
+}
+
+// RunLocked runs the pipeline while holding the cluster-wide advisory lock,
+// returning ErrRunInProgress if another run is active
+func RunLocked(ctx context.Context, database *sql.DB, pipeline *Pipeline, trigger string, full bool) (*repository.ScrapeRun, error) {
+	release, ok, err := db.TryAdvisoryLock(ctx, database, scrapeLockKey)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrRunInProgress
+	}
+	defer release()
+
+	return pipeline.Run(ctx, trigger, full)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"sync"
+	"time"
 )
 
 // Engine manages the execution of multiple scrapers
@@ -11,6 +12,14 @@ type Engine struct {
 	scrapers []Scraper
 }
 
+// SourceResult holds the outcome of a single scraper within a run
+type SourceResult struct {
+	Source   string
+	Items    []Solicitation
+	Err      error
+	Duration time.Duration
+}
+
 // NewEngine creates a new scraper engine
 func NewEngine() *Engine {
 	return &Engine{
@@ -24,36 +33,54 @@ func (e *Engine) Register(s Scraper) {
 }
 
 // Run executes all registered scrapers
-func (e *Engine) Run(ctx context.Context) ([]Solicitation, error) {
+func (e *Engine) Run(ctx context.Context, opts ScrapeOptions) ([]Solicitation, error) {
 	var allSolicitations []Solicitation
-	var mu sync.Mutex
+	for _, res := range e.RunSources(ctx, opts) {
+		allSolicitations = append(allSolicitations, res.Items...)
+	}
+	return allSolicitations, nil
+}
+
+// RunSources executes all registered scrapers and reports the outcome of each
+// one separately, in registration order
+func (e *Engine) RunSources(ctx context.Context, opts ScrapeOptions) []SourceResult {
+	results := make([]SourceResult, len(e.scrapers))
 	var wg sync.WaitGroup
 
 	slog.Info("Starting Scraper Engine", "source_count", len(e.scrapers))
 
-	for _, s := range e.scrapers {
+	for i, s := range e.scrapers {
 		wg.Add(1)
-		go func(scraper Scraper) {
+		go func(i int, scraper Scraper) {
 			defer wg.Done()
 			slog.Info("Running scraper", "scraper", scraper.Name())
-			
-			results, err := scraper.Scrape(ctx)
+
+			start := time.Now()
+			items, err := scraper.Scrape(ctx, opts)
+			results[i] = SourceResult{
+				Source:   scraper.Name(),
+				Items:    items,
+				Err:      err,
+				Duration: time.Since(start),
+			}
 			if err != nil {
+				// Partial results from a failed scraper are discarded
+				results[i].Items = nil
 				slog.Error("Scraper failed", "scraper", scraper.Name(), "error", err)
 				return
 			}
 
-			mu.Lock()
-			allSolicitations = append(allSolicitations, results...)
-			mu.Unlock()
-			
-			slog.Info("Scraper finished", "scraper", scraper.Name(), "items_found", len(results))
-		}(s)
+			slog.Info("Scraper finished", "scraper", scraper.Name(), "items_found", len(items))
+		}(i, s)
 	}
 
 	wg.Wait()
-	
-	slog.Info("Scraper Engine run complete", "total_items", len(allSolicitations))
 
-	return allSolicitations, nil
-}
\ No newline at end of file
+	total := 0
+	for _, res := range results {
+		total += len(res.Items)
+	}
+	slog.Info("Scraper Engine run complete", "total_items", total)
+
+	return results
+}
@@ -3,6 +3,8 @@ package georgia
 import (
 	"bd_bot/internal/scraper"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -50,8 +52,8 @@ type GPRResponse struct {
 	Data            []map[string]interface{} `json:"data"`
 }
 
-func (s *GPRScraper) Scrape(ctx context.Context) ([]scraper.Solicitation, error) {
-	slog.Info("Starting GPR scrape", "url", s.BaseURL)
+func (s *GPRScraper) Scrape(ctx context.Context, opts scraper.ScrapeOptions) ([]scraper.Solicitation, error) {
+	slog.Info("Starting GPR scrape", "url", s.BaseURL, "incremental", opts.Known != nil)
 
 	// 1. Establish session
 	req, err := http.NewRequestWithContext(ctx, "GET", s.BaseURL, nil)
@@ -68,6 +70,7 @@ func (s *GPRScraper) Scrape(ctx context.Context) ([]scraper.Solicitation, error)
 
 	// 2. Pagination Loop
 	var allSolicitations []scraper.Solicitation
+	skipped := 0
 	start := 0
 	length := 100 // Fetch 100 at a time
 
@@ -89,6 +92,7 @@ func (s *GPRScraper) Scrape(ctx context.Context) ([]scraper.Solicitation, error)
 				Title:       getString(item, "title"),
 				Agency:      getString(item, "agencyName"),
 				RawData:     item,
+				Fingerprint: fingerprint(item),
 			}
 
 			eSourceNumberKey := getString(item, "esourceNumberKey")
@@ -105,12 +109,22 @@ func (s *GPRScraper) Scrape(ctx context.Context) ([]scraper.Solicitation, error)
 				}
 			}
 
-			// Fetch details for each item
+			// Skip the detail fetch if the listing row hasn't changed since the last run
+			if known, ok := opts.Known[sol.SourceID]; ok && known == sol.Fingerprint {
+				sol.Unchanged = true
+				skipped++
+				allSolicitations = append(allSolicitations, sol)
+				continue
+			}
+
+			// Fetch details for new or changed items
 			if sol.URL != "" {
 				// Add a small delay to be polite
 				time.Sleep(100 * time.Millisecond)
 				if err := s.ScrapeDetails(ctx, &sol); err != nil {
 					slog.Warn("Failed to scrape details", "source_id", sol.SourceID, "error", err)
+					// Store no fingerprint, so the next incremental run retries the details
+					sol.Fingerprint = ""
 				}
 			}
 
@@ -126,6 +140,7 @@ func (s *GPRScraper) Scrape(ctx context.Context) ([]scraper.Solicitation, error)
 		time.Sleep(1 * time.Second)
 	}
 
+	slog.Info("GPR scrape complete", "items", len(allSolicitations), "unchanged_skipped", skipped)
 	return allSolicitations, nil
 }
 
@@ -263,6 +278,17 @@ func (s *GPRScraper) setHeaders(req *http.Request) {
 	req.Header.Set("Origin", "https://ssl.doas.state.ga.us")
 }
 
+// fingerprint hashes a DataTables row so unchanged listings can be detected.
+// json.Marshal sorts map keys, giving a stable encoding.
+func fingerprint(item map[string]interface{}) string {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
 func getString(m map[string]interface{}, key string) string {
 	if v, ok := m[key]; ok {
 		if s, ok := v.(string); ok {
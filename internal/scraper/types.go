@@ -18,6 +18,21 @@ type Solicitation struct {
 	RawData     map[string]interface{} `json:"raw_data"`
 	LeadName    *string                `json:"lead_name,omitempty"`      // Populated by repo
 	InterestedParties *string          `json:"interested_parties,omitempty"` // Populated by repo (comma separated)
+	Fingerprint string                 `json:"fingerprint,omitempty"` // Hash of the listing row, used for incremental scraping
+	Unchanged   bool                   `json:"-"`                     // Set by scrapers when the fingerprint matches a known item
+	Amended       bool                 `json:"amended,omitempty"`        // Populated by repo: changed since first scraped
+	DeadlineMoved bool                 `json:"deadline_moved,omitempty"` // Populated by repo: due date changed since first scraped
+	MatchScore    *int                 `json:"match_score,omitempty"`    // Populated by repo: the viewer's match score, when listing for a user
+	DocumentText  string               `json:"-"`                        // Populated by repo: text extracted from the attachments
+	UpdatedAt     time.Time            `json:"-"`                        // Populated by repo: when the content last changed
+}
+
+// ScrapeOptions controls a single scraper run
+type ScrapeOptions struct {
+	// Known maps SourceID to the fingerprint stored by a previous run.
+	// Scrapers may skip detail fetches for items whose fingerprint is unchanged
+	// and return them with Unchanged set. A nil map forces a full refresh.
+	Known map[string]string
 }
 
 // Document represents a file attached to a solicitation
@@ -33,5 +48,5 @@ type Scraper interface {
 	Name() string
 	
 	// Scrape executes the scraping logic and returns a list of found solicitations
-	Scrape(ctx context.Context) ([]Solicitation, error)
+	Scrape(ctx context.Context, opts ScrapeOptions) ([]Solicitation, error)
 }
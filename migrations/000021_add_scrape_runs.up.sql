@@ -0,0 +1,13 @@
+CREATE TABLE scrape_runs (
+    id SERIAL PRIMARY KEY,
+    trigger TEXT NOT NULL DEFAULT 'schedule', -- schedule, manual
+    status TEXT NOT NULL DEFAULT 'running', -- running, succeeded, failed
+    items_found INT DEFAULT 0,
+    items_saved INT DEFAULT 0,
+    source_counts JSONB DEFAULT '{}'::jsonb, -- {"<scraper name>": <items found>}
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_scrape_runs_started ON scrape_runs(started_at DESC);
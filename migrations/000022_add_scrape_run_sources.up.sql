@@ -0,0 +1,13 @@
+CREATE TABLE scrape_run_sources (
+    id SERIAL PRIMARY KEY,
+    run_id INT REFERENCES scrape_runs(id) ON DELETE CASCADE,
+    source TEXT NOT NULL,
+    items_found INT DEFAULT 0,
+    items_new INT DEFAULT 0,
+    items_updated INT DEFAULT 0,
+    duration_ms BIGINT DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_scrape_run_sources_source ON scrape_run_sources(source, created_at DESC);
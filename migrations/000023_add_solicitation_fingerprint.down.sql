@@ -0,0 +1 @@
+ALTER TABLE solicitations DROP COLUMN IF EXISTS fingerprint;
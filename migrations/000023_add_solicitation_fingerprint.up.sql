@@ -0,0 +1 @@
+ALTER TABLE solicitations ADD COLUMN fingerprint TEXT;
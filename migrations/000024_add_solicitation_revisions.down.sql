@@ -0,0 +1 @@
+DROP TABLE IF EXISTS solicitation_revisions;
@@ -0,0 +1,8 @@
+CREATE TABLE solicitation_revisions (
+    id SERIAL PRIMARY KEY,
+    solicitation_id INT REFERENCES solicitations(id) ON DELETE CASCADE,
+    changes JSONB NOT NULL, -- {"<field>": {"old": ..., "new": ...}}
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_solicitation_revisions_sol ON solicitation_revisions(solicitation_id, created_at DESC);
@@ -0,0 +1,13 @@
+CREATE TABLE notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES users(id) ON DELETE CASCADE,
+    solicitation_id INT REFERENCES solicitations(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL, -- solicitation_changed
+    title TEXT NOT NULL,
+    body TEXT,
+    data JSONB,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_notifications_user ON notifications(user_id, created_at DESC);
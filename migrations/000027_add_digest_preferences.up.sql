@@ -0,0 +1,5 @@
+-- daily, weekly, off. Existing users are not opted in to email they never
+-- asked for; only accounts created from now on default to a daily digest.
+ALTER TABLE users ADD COLUMN digest_frequency TEXT NOT NULL DEFAULT 'off';
+ALTER TABLE users ALTER COLUMN digest_frequency SET DEFAULT 'daily';
+ALTER TABLE users ADD COLUMN digest_sent_at TIMESTAMP WITH TIME ZONE;
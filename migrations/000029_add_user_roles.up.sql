@@ -0,0 +1,4 @@
+-- The original schema only used 'user' and 'admin'; plain users become researchers
+UPDATE users SET role = 'researcher' WHERE role NOT IN ('admin', 'developer', 'bd_manager', 'researcher', 'viewer');
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'researcher';
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('admin', 'developer', 'bd_manager', 'researcher', 'viewer'));
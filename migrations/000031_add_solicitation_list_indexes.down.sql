@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_matches_user_score;
+DROP INDEX IF EXISTS idx_claims_solicitation;
+DROP INDEX IF EXISTS idx_solicitations_agency;
+DROP INDEX IF EXISTS idx_solicitations_created;
+DROP INDEX IF EXISTS idx_solicitations_due;
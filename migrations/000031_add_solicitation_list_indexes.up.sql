@@ -0,0 +1,6 @@
+-- Keyset pagination and filters for GET /api/solicitations
+CREATE INDEX idx_solicitations_due ON solicitations ((COALESCE(due_date, 'infinity'::timestamptz)), id);
+CREATE INDEX idx_solicitations_created ON solicitations (created_at, id);
+CREATE INDEX idx_solicitations_agency ON solicitations (agency);
+CREATE INDEX idx_claims_solicitation ON claims (solicitation_id, claim_type);
+CREATE INDEX idx_matches_user_score ON matches (user_id, score);
@@ -0,0 +1,14 @@
+CREATE OR REPLACE FUNCTION solicitations_tsvector_trigger() RETURNS trigger AS $$
+BEGIN
+  new.text_search :=
+  setweight(to_tsvector('english', coalesce(new.title,'')), 'A') ||
+  setweight(to_tsvector('english', coalesce(new.description,'')), 'B');
+  return new;
+END
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE solicitations DROP COLUMN IF EXISTS document_text;
+
+UPDATE solicitations SET text_search =
+    setweight(to_tsvector('english', coalesce(title,'')), 'A') ||
+    setweight(to_tsvector('english', coalesce(description,'')), 'B');
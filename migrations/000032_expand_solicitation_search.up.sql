@@ -0,0 +1,18 @@
+-- Text extracted from attached documents, searched alongside the listing fields
+ALTER TABLE solicitations ADD COLUMN document_text TEXT;
+
+CREATE OR REPLACE FUNCTION solicitations_tsvector_trigger() RETURNS trigger AS $$
+BEGIN
+  new.text_search :=
+  setweight(to_tsvector('english', coalesce(new.title,'')), 'A') ||
+  setweight(to_tsvector('english', coalesce(new.description,'')), 'B') ||
+  setweight(to_tsvector('english', coalesce(new.agency,'')), 'C') ||
+  setweight(to_tsvector('english', coalesce(new.document_text,'')), 'D');
+  return new;
+END
+$$ LANGUAGE plpgsql;
+
+UPDATE solicitations SET text_search =
+    setweight(to_tsvector('english', coalesce(title,'')), 'A') ||
+    setweight(to_tsvector('english', coalesce(description,'')), 'B') ||
+    setweight(to_tsvector('english', coalesce(agency,'')), 'C');
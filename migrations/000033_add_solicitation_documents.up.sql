@@ -0,0 +1,19 @@
+CREATE TABLE solicitation_documents (
+    id SERIAL PRIMARY KEY,
+    solicitation_id INT NOT NULL REFERENCES solicitations(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    title TEXT,
+    format TEXT,                -- pdf, docx, xlsx, html, text or unknown
+    content_type TEXT,          -- as reported by the server
+    sha256 TEXT,                -- content address in the document store
+    storage_path TEXT,          -- relative to uploads/documents
+    size_bytes BIGINT,
+    text TEXT,                  -- extracted plain text
+    status TEXT NOT NULL,       -- fetched or failed
+    error TEXT,
+    attempts INT NOT NULL DEFAULT 1,
+    fetched_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(solicitation_id, url)
+);
+
+CREATE INDEX idx_solicitation_documents_sha ON solicitation_documents(sha256);
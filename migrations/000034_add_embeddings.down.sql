@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS match_top_k;
+DROP TABLE IF EXISTS embeddings;
@@ -0,0 +1,13 @@
+CREATE TABLE embeddings (
+    entity_type TEXT NOT NULL,      -- solicitation or narrative
+    entity_id INT NOT NULL,         -- solicitations.id or users.id
+    model TEXT NOT NULL,
+    content_hash TEXT NOT NULL,     -- sha256 of the embedded text, to detect changes
+    vector REAL[] NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (entity_type, entity_id, model)
+);
+
+-- How many of the most similar solicitations are sent to the LLM. Opt-in:
+-- NULL (the default) or 0 scores all
+ALTER TABLE users ADD COLUMN match_top_k INT;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_solicitations_updated_at;
+ALTER TABLE matches DROP COLUMN IF EXISTS content_hash;
+ALTER TABLE matches DROP COLUMN IF EXISTS narrative_hash;
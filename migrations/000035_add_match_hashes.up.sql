@@ -0,0 +1,5 @@
+-- Hashes of what was scored, so unchanged pairs are not sent to the LLM again
+ALTER TABLE matches ADD COLUMN narrative_hash TEXT;
+ALTER TABLE matches ADD COLUMN content_hash TEXT;
+
+CREATE INDEX idx_solicitations_updated_at ON solicitations (updated_at);
@@ -0,0 +1,3 @@
+-- Structured explanation: per-criterion verdicts, matched keywords,
+-- disqualifiers hit and the model's confidence
+ALTER TABLE matches ADD COLUMN details JSONB;
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS organization_matches;
+DROP TABLE IF EXISTS organization_narrative_versions;
+
+ALTER TABLE organizations
+    DROP COLUMN IF EXISTS narrative,
+    DROP COLUMN IF EXISTS match_threshold,
+    DROP COLUMN IF EXISTS match_top_k,
+    DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,30 @@
+-- Organizations get their own narrative and inbox, matched like a user's
+ALTER TABLE organizations
+    ADD COLUMN narrative TEXT,
+    ADD COLUMN match_threshold INT NOT NULL DEFAULT 75,
+    ADD COLUMN match_top_k INT,
+    ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW();
+
+CREATE TABLE organization_narrative_versions (
+    id SERIAL PRIMARY KEY,
+    organization_id INT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    content TEXT,
+    author_id INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_org_narrative_versions_org ON organization_narrative_versions (organization_id, id DESC);
+
+CREATE TABLE organization_matches (
+    id SERIAL PRIMARY KEY,
+    organization_id INT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    solicitation_id INT NOT NULL REFERENCES solicitations(id) ON DELETE CASCADE,
+    score INT NOT NULL DEFAULT 0,
+    explanation TEXT,
+    details JSONB,
+    narrative_hash TEXT,
+    content_hash TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (organization_id, solicitation_id)
+);
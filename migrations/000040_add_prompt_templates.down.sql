@@ -0,0 +1,4 @@
+ALTER TABLE organization_matches DROP COLUMN IF EXISTS prompt_version;
+ALTER TABLE matches DROP COLUMN IF EXISTS prompt_version;
+
+DROP TABLE IF EXISTS prompt_templates;
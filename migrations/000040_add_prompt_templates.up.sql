@@ -0,0 +1,15 @@
+-- LLM prompts are versioned templates; the newest version of each name is in
+-- use, and the built-in default applies while a name has none
+CREATE TABLE prompt_templates (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    version INT NOT NULL,
+    body TEXT NOT NULL,
+    author_id INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (name, version)
+);
+
+-- Version of the match prompt each score came from; 0 is the built-in default
+ALTER TABLE matches ADD COLUMN prompt_version INT;
+ALTER TABLE organization_matches ADD COLUMN prompt_version INT;
@@ -0,0 +1,16 @@
+-- A user's thumbs up/down on a solicitation matched for them. It is keyed by
+-- user and solicitation rather than by match row, so it outlives the match
+-- being cleared and re-scored. The score they saw is kept so that feedback
+-- can be compared with scores after re-scoring.
+CREATE TABLE match_feedback (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    solicitation_id INT NOT NULL REFERENCES solicitations(id) ON DELETE CASCADE,
+    rating TEXT NOT NULL CHECK (rating IN ('up', 'down')),
+    score INT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (user_id, solicitation_id)
+);
+
+CREATE INDEX idx_match_feedback_user ON match_feedback (user_id, updated_at DESC);
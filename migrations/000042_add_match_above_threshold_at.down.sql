@@ -0,0 +1 @@
+ALTER TABLE matches DROP COLUMN IF EXISTS above_threshold_at;
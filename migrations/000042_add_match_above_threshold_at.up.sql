@@ -0,0 +1,8 @@
+-- When the match last reached the user's threshold, NULL while it is below.
+-- Digests list matches by this rather than created_at, so a match that is
+-- re-scored above the threshold, or that a lower threshold lets in, is new.
+ALTER TABLE matches ADD COLUMN above_threshold_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE matches m SET above_threshold_at = m.created_at
+FROM users u
+WHERE u.id = m.user_id AND m.score >= COALESCE(u.match_threshold, 75);
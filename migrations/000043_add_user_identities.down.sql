@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS external_id TEXT;
+CREATE INDEX IF NOT EXISTS idx_users_auth ON users(auth_provider, external_id);
+
+-- users.external_id holds one identity per user; keep the first linked
+UPDATE users u SET external_id = i.external_id
+FROM (
+    SELECT DISTINCT ON (user_id) user_id, external_id
+    FROM user_identities
+    ORDER BY user_id, id
+) i
+WHERE i.user_id = u.id;
+
+DROP TABLE IF EXISTS user_identities;
@@ -0,0 +1,28 @@
+-- Identity provider accounts linked to a user, kept apart from
+-- users.auth_provider so that linking single sign-on to a local account
+-- leaves its password working
+CREATE TABLE user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    external_id TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (provider, external_id)
+);
+
+CREATE INDEX idx_user_identities_user ON user_identities (user_id);
+
+INSERT INTO user_identities (user_id, provider, external_id)
+SELECT id, auth_provider, external_id
+FROM users
+WHERE auth_provider IS NOT NULL AND auth_provider <> 'local' AND external_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+-- Local accounts that were switched over when linked still have their
+-- password; let them sign in with it again
+UPDATE users SET auth_provider = 'local'
+WHERE auth_provider <> 'local' AND COALESCE(password_hash, '') <> '';
+
+-- user_identities is now the only record of linked accounts
+DROP INDEX IF EXISTS idx_users_auth;
+ALTER TABLE users DROP COLUMN IF EXISTS external_id;
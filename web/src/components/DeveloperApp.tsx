@@ -360,7 +360,7 @@ const RequirementsEditor: React.FC = () => {
 const DeveloperApp: React.FC = () => {
     const { user } = useAuth();
 
-    if (!user || (user.role !== 'admin' && user.role !== 'developer')) {
+    if (!user || !user.permissions?.includes('tasks:read')) {
         return <div style={{ padding: '2rem', textAlign: 'center', color: 'var(--text-secondary)' }}>Access Denied. Developer role required.</div>;
     }
 
@@ -37,7 +37,7 @@ const LandingPage: React.FC = () => {
         }
     ];
 
-    if (user?.role === 'admin' || user?.role === 'developer') {
+    if (user?.permissions?.includes('tasks:read')) {
         apps.push({
             name: "Developer Tools",
             description: "Manage system requirements and configuration.",
@@ -1,6 +1,6 @@
-import React, { useState } from 'react';
+import React, { useEffect, useState } from 'react';
 import { useAuth } from '../context/AuthContext';
-import { LogIn, LogOut, User, X } from 'lucide-react';
+import { KeyRound, LogIn, LogOut, User, X } from 'lucide-react';
 import { NavLink } from 'react-router-dom';
 
 export const LoginButton: React.FC = () => {
@@ -10,6 +10,19 @@ export const LoginButton: React.FC = () => {
     const [password, setPassword] = useState("");
     const [error, setError] = useState("");
     const [isSubmitting, setIsSubmitting] = useState(false);
+    const [sso, setSso] = useState<{ oidc_enabled: boolean, provider_name?: string } | null>(null);
+
+    useEffect(() => {
+        fetch('/api/auth/config')
+            .then(res => res.json())
+            .then(setSso)
+            .catch(console.error);
+    }, []);
+
+    const handleSSO = () => {
+        const redirect = window.location.pathname + window.location.search;
+        window.location.href = `/api/auth/oidc/login?redirect=${encodeURIComponent(redirect)}`;
+    };
 
     const handleLogin = async (e: React.FormEvent) => {
         e.preventDefault();
@@ -69,6 +82,20 @@ export const LoginButton: React.FC = () => {
                             </button>
                         </div>
 
+                        {sso?.oidc_enabled && (
+                            <>
+                                <button
+                                    type="button"
+                                    onClick={handleSSO}
+                                    className="btn-primary"
+                                    style={{ width: '100%', justifyContent: 'center', marginBottom: '1rem' }}
+                                >
+                                    <KeyRound size={16} /> Sign in with {sso.provider_name || 'SSO'}
+                                </button>
+                                <div style={{ textAlign: 'center', fontSize: '0.85rem', color: 'var(--text-secondary)', marginBottom: '1rem' }}>or use a local account</div>
+                            </>
+                        )}
+
                         <form onSubmit={handleLogin} style={{ display: 'flex', flexDirection: 'column', gap: '1rem' }}>
                             <div>
                                 <label style={{ display: 'block', marginBottom: '0.5rem', fontSize: '0.9rem', color: 'var(--text-primary)', fontWeight: '600' }}>Email Address</label>
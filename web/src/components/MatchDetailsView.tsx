@@ -0,0 +1,66 @@
+import React from 'react';
+import { CheckCircle, XCircle, HelpCircle, AlertTriangle } from 'lucide-react';
+import type { CriterionVerdict, MatchDetails } from '../types';
+
+interface MatchDetailsViewProps {
+    details: MatchDetails;
+}
+
+const verdictIcon = (verdict: CriterionVerdict['verdict']) => {
+    switch (verdict) {
+        case 'met':
+            return <CheckCircle size={16} style={{ color: 'var(--success-color)' }} />;
+        case 'not_met':
+            return <XCircle size={16} style={{ color: 'var(--error-color)' }} />;
+        default:
+            return <HelpCircle size={16} style={{ color: 'var(--text-secondary)' }} />;
+    }
+};
+
+// Structured breakdown of an AI match: rubric verdicts, keywords, disqualifiers and confidence
+const MatchDetailsView: React.FC<MatchDetailsViewProps> = ({ details }) => {
+    const criteria = details.criteria ?? [];
+    const keywords = details.matched_keywords ?? [];
+    const disqualifiers = details.disqualifiers ?? [];
+
+    return (
+        <div style={{ marginTop: '1rem', display: 'flex', flexDirection: 'column', gap: '0.75rem' }}>
+            <div className="text-muted" style={{ fontSize: '0.85rem' }}>
+                Confidence: {Math.round(details.confidence * 100)}%
+            </div>
+
+            {disqualifiers.length > 0 && (
+                <div style={{ color: 'var(--error-color)' }}>
+                    <strong style={{ display: 'inline-flex', alignItems: 'center', gap: '4px' }}>
+                        <AlertTriangle size={16} /> Disqualifiers:
+                    </strong>{' '}
+                    {disqualifiers.join('; ')}
+                </div>
+            )}
+
+            {criteria.length > 0 && (
+                <ul style={{ listStyle: 'none', padding: 0, margin: 0 }}>
+                    {criteria.map((c, idx) => (
+                        <li key={idx} style={{ display: 'flex', gap: '0.5rem', alignItems: 'flex-start', marginBottom: '0.4rem' }}>
+                            <span style={{ paddingTop: '2px' }}>{verdictIcon(c.verdict)}</span>
+                            <span>
+                                <strong>{c.criterion}</strong>
+                                {c.evidence && <span className="text-muted"> — {c.evidence}</span>}
+                            </span>
+                        </li>
+                    ))}
+                </ul>
+            )}
+
+            {keywords.length > 0 && (
+                <div style={{ display: 'flex', flexWrap: 'wrap', gap: '0.4rem' }}>
+                    {keywords.map((k) => (
+                        <span key={k} className="filter-chip" style={{ cursor: 'default' }}>{k}</span>
+                    ))}
+                </div>
+            )}
+        </div>
+    );
+};
+
+export default MatchDetailsView;
@@ -0,0 +1,144 @@
+import React, { useEffect, useState } from 'react';
+import { Users } from 'lucide-react';
+import { useAuth } from '../context/AuthContext';
+import type { Organization } from '../types';
+import MarkdownEditor from './MarkdownEditor';
+
+// Shared narrative and match settings for the user's organization. Everyone in
+// the organization can read them; organization:manage is needed to edit.
+const OrganizationNarrative: React.FC = () => {
+    const { user } = useAuth();
+    const [org, setOrg] = useState<Organization | null>(null);
+    const [versions, setVersions] = useState<any[]>([]);
+    const [matchThreshold, setMatchThreshold] = useState(75);
+    const [matchTopK, setMatchTopK] = useState(0);
+    const [status, setStatus] = useState<{ msg: string, type: 'success' | 'error' } | null>(null);
+
+    const canEdit = !!user?.permissions?.includes('organization:manage');
+
+    const fetchOrg = async () => {
+        const res = await fetch('/api/organization');
+        if (!res.ok) {
+            setOrg(null);
+            return;
+        }
+        const data: Organization = await res.json();
+        setOrg(data);
+        setMatchThreshold(data.match_threshold);
+        setMatchTopK(data.match_top_k);
+    };
+
+    const fetchVersions = async () => {
+        const res = await fetch('/api/organization/narrative/versions');
+        if (res.ok) {
+            const data = await res.json();
+            setVersions(data || []);
+        }
+    };
+
+    useEffect(() => {
+        if (!user?.organization_name) return;
+        fetchOrg().catch(console.error);
+        fetchVersions().catch(console.error);
+    }, [user?.organization_name]);
+
+    if (!org) return null;
+
+    const fetchNarrativeContent = async (id: number) => {
+        const res = await fetch(`/api/organization/narrative/version?version=${id}`);
+        const data = await res.json();
+        return data.content || "";
+    };
+
+    const handleNarrativeSave = async (text: string) => {
+        const res = await fetch('/api/organization/narrative', {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ narrative: text }),
+        });
+        if (!res.ok) throw new Error("Failed to save organization narrative");
+        await fetchOrg();
+        fetchVersions();
+    };
+
+    const handleSettingsSave = async () => {
+        setStatus(null);
+        const res = await fetch('/api/organization/settings', {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ match_threshold: matchThreshold, match_top_k: matchTopK }),
+        });
+        if (!res.ok) {
+            const text = await res.text();
+            setStatus({ msg: text || "Failed to update settings", type: 'error' });
+            return;
+        }
+        setOrg(await res.json());
+        setStatus({ msg: "Settings saved", type: 'success' });
+    };
+
+    return (
+        <div className="chart-card" style={{ padding: '2rem', marginTop: '2rem' }}>
+            <div style={{ display: 'flex', alignItems: 'center', gap: '0.5rem', marginBottom: '1rem' }}>
+                <Users size={20} color="var(--text-primary)" />
+                <h3 style={{ margin: 0, color: 'var(--text-primary)' }}>{org.name} Narrative</h3>
+            </div>
+
+            <p className="text-muted" style={{ marginBottom: '1.5rem', lineHeight: '1.5' }}>
+                The shared narrative for everyone in {org.name}. Opportunities matching it appear in the team inbox.
+                {!canEdit && " Only BD managers and admins can edit it."}
+            </p>
+
+            <div style={{ display: 'flex', gap: '2rem', alignItems: 'flex-end', flexWrap: 'wrap', marginBottom: '2rem' }}>
+                <div style={{ flex: 1, minWidth: '250px', maxWidth: '400px' }}>
+                    <label style={{ display: 'flex', justifyContent: 'space-between', marginBottom: '0.5rem', color: 'var(--text-body)', fontSize: '0.9rem', fontWeight: 600 }}>
+                        <span>Team Match Threshold</span>
+                        <span style={{ color: 'var(--primary-color)' }}>{matchThreshold}</span>
+                    </label>
+                    <input
+                        type="range" min="1" max="100"
+                        value={matchThreshold}
+                        disabled={!canEdit}
+                        onChange={(e) => setMatchThreshold(parseInt(e.target.value))}
+                        style={{ width: '100%' }}
+                    />
+                </div>
+                <div>
+                    <label style={{ display: 'block', marginBottom: '0.5rem', color: 'var(--text-body)', fontSize: '0.9rem', fontWeight: 600 }}>
+                        Opportunities Scored per Run
+                    </label>
+                    <input
+                        type="number" min="0" max="10000"
+                        value={matchTopK}
+                        disabled={!canEdit}
+                        onChange={(e) => setMatchTopK(Math.max(0, parseInt(e.target.value) || 0))}
+                        style={{ width: '120px' }}
+                    />
+                </div>
+                {canEdit && (
+                    <button type="button" className="btn-outline" onClick={handleSettingsSave}>
+                        Save Settings
+                    </button>
+                )}
+            </div>
+            {status && (
+                <p style={{ color: status.type === 'success' ? 'var(--success-color)' : 'var(--error-color)', marginTop: '-1rem' }}>
+                    {status.msg}
+                </p>
+            )}
+
+            <MarkdownEditor
+                initialContent={org.narrative || ""}
+                onSave={handleNarrativeSave}
+                versions={versions}
+                onLoadVersion={fetchNarrativeContent}
+                title="Organization Narrative"
+                icon={<Users size={16} />}
+                height="400px"
+                readOnly={!canEdit}
+            />
+        </div>
+    );
+};
+
+export default OrganizationNarrative;
@@ -1,4 +1,4 @@
-import React, { useEffect, useState, useMemo } from 'react';
+import React, { useCallback, useEffect, useRef, useState, useMemo } from 'react';
 import { Link } from 'react-router-dom';
 import type { Match, Solicitation } from '../types';
 import { useAuth } from '../context/AuthContext';
@@ -10,17 +10,24 @@ import {
     Sparkles,
     ArrowUpDown,
     User,
-    Users
+    Users,
+    Play,
+    ThumbsUp,
+    ThumbsDown
 } from 'lucide-react';
 import { useAnalytics, getDaysRemaining, getDateBucket } from '../hooks/useAnalytics';
+import { useMatchJob } from '../hooks/useMatchJob';
 import DashboardCharts from './DashboardCharts';
 import FilterControls from './FilterControls';
+import MatchDetailsView from './MatchDetailsView';
 
 const PersonalInbox: React.FC = () => {
     const { user } = useAuth();
     const [matches, setMatches] = useState<Match[]>([]);
     const [loading, setLoading] = useState<boolean>(true);
     const [expandedRow, setExpandedRow] = useState<string | null>(null);
+    // 'organization' shows the team-wide inbox scored against the organization's narrative
+    const [scope, setScope] = useState<'personal' | 'organization'>('personal');
 
     // Filtering & Sorting State
     const [filterText, setFilterText] = useState("");
@@ -28,23 +35,52 @@ const PersonalInbox: React.FC = () => {
     const [agencyFilter, setAgencyFilter] = useState<string | null>(null);
     const [sortConfig, setSortConfig] = useState<{ key: keyof Match | keyof Solicitation; direction: 'asc' | 'desc' } | null>({ key: 'score', direction: 'desc' });
 
+    const fetchMatches = useCallback(async () => {
+        try {
+            const response = await fetch(scope === 'organization' ? '/api/organization/matches' : '/api/matches');
+            if (response.ok) {
+                const data = await response.json();
+                setMatches(data || []);
+            } else {
+                setMatches([]);
+            }
+        } finally {
+            setLoading(false);
+        }
+    }, [scope]);
+
     useEffect(() => {
         if (!user) return;
+        fetchMatches();
+    }, [user, fetchMatches]);
 
-        const fetchMatches = async () => {
-            try {
-                const response = await fetch('/api/matches');
-                if (response.ok) {
-                    const data = await response.json();
-                    setMatches(data || []);
-                }
-            } finally {
-                setLoading(false);
-            }
-        };
+    // Scores arrive quickly during a run; refresh the inbox at most once a second
+    const refreshTimer = useRef<number | null>(null);
+    const scheduleRefresh = useCallback(() => {
+        if (refreshTimer.current !== null) return;
+        refreshTimer.current = window.setTimeout(() => {
+            refreshTimer.current = null;
+            fetchMatches();
+        }, 1000);
+    }, [fetchMatches]);
+    useEffect(() => () => {
+        if (refreshTimer.current !== null) window.clearTimeout(refreshTimer.current);
+    }, []);
 
-        fetchMatches();
-    }, [user]);
+    const { job, running, error: jobError, run: runMatching } = useMatchJob(scheduleRefresh);
+
+    // Clicking the current rating again clears it
+    const rateMatch = async (match: Match, rating: 'up' | 'down') => {
+        const next = match.feedback === rating ? undefined : rating;
+        try {
+            const res = await fetch(`/api/matches/${match.match_id}/feedback`, next
+                ? { method: 'POST', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify({ rating: next }) }
+                : { method: 'DELETE' });
+            if (res.ok) {
+                setMatches(prev => prev.map(m => m.match_id === match.match_id ? { ...m, feedback: next } : m));
+            }
+        } catch (err) { console.error(err); }
+    };
 
     // 1. Text Filtered (Base)
     const textFilteredMatches = useMemo(() => {
@@ -103,18 +139,72 @@ const PersonalInbox: React.FC = () => {
     if (!user) return <div>Please login to view your inbox.</div>;
     if (loading) return <div className="loading">Loading AI matches...</div>;
 
+    const formatEta = (seconds?: number) => {
+        if (!seconds) return '';
+        if (seconds < 60) return `${seconds}s left`;
+        return `${Math.round(seconds / 60)} min left`;
+    };
+
+    const scopeToggle = user.organization_name && (
+        <div style={{ display: 'flex', gap: '0.5rem', marginBottom: '1rem' }}>
+            <button className={scope === 'personal' ? 'btn-primary' : 'btn-outline'} onClick={() => setScope('personal')}>
+                <User size={14} /> My Matches
+            </button>
+            <button className={scope === 'organization' ? 'btn-primary' : 'btn-outline'} onClick={() => setScope('organization')}>
+                <Users size={14} /> {user.organization_name}
+            </button>
+        </div>
+    );
+
+    const matchingControls = scope === 'organization' ? null : (
+        <div style={{ display: 'flex', alignItems: 'center', gap: '1rem', marginBottom: '1rem', flexWrap: 'wrap' }}>
+            <button className="btn-primary" onClick={runMatching} disabled={running} style={{ display: 'flex', alignItems: 'center', gap: '0.4rem' }}>
+                <Play size={14} /> {running ? 'Matching...' : 'Run Matching'}
+            </button>
+            {job && running && (
+                <div style={{ flex: 1, minWidth: '200px' }}>
+                    <div style={{ height: '6px', background: 'var(--border-color, #e0e0e0)', borderRadius: '3px', overflow: 'hidden' }}>
+                        <div style={{ width: `${job.total ? (job.done / job.total) * 100 : 0}%`, height: '100%', background: 'var(--primary-color)', transition: 'width 0.3s' }} />
+                    </div>
+                    <div style={{ fontSize: '0.75rem', color: 'var(--text-secondary)', marginTop: '0.3rem' }}>
+                        {job.status === 'queued'
+                            ? 'Waiting to start...'
+                            : `${job.done} of ${job.total} scored, ${job.above_threshold} new matches${job.per_minute ? ` · ${job.per_minute.toFixed(1)}/min` : ''} ${formatEta(job.eta_seconds)}`}
+                    </div>
+                </div>
+            )}
+            {job && job.status === 'succeeded' && (
+                <span style={{ fontSize: '0.85rem', color: 'var(--text-secondary)' }}>
+                    Scored {job.done} opportunities{job.failed > 0 ? ` (${job.failed} failed)` : ''}.
+                </span>
+            )}
+            {job && job.status === 'failed' && (
+                <span style={{ fontSize: '0.85rem', color: '#c0392b' }}>Matching failed: {job.error}</span>
+            )}
+            {jobError && <span style={{ fontSize: '0.85rem', color: '#c0392b' }}>{jobError}</span>}
+        </div>
+    );
+
     if (matches.length === 0) {
         return (
-            <div style={{ textAlign: 'center', padding: '3rem', color: '#7f8c8d' }}>
-                <Sparkles size={48} style={{ marginBottom: '1rem', opacity: 0.5 }} />
-                <h3>No matches found yet.</h3>
-                <p>Ensure you have set your narrative and run the matching engine.</p>
+            <div className="solicitation-list">
+                {scopeToggle}
+                <div style={{ textAlign: 'center', padding: '3rem', color: '#7f8c8d' }}>
+                    <Sparkles size={48} style={{ marginBottom: '1rem', opacity: 0.5 }} />
+                    <h3>No matches found yet.</h3>
+                    {scope === 'organization'
+                        ? <p>Team matches appear after the organization narrative is set and the next matching run.</p>
+                        : <p>Ensure you have set your narrative, then run the matching engine.</p>}
+                    <div style={{ display: 'flex', justifyContent: 'center' }}>{matchingControls}</div>
+                </div>
             </div>
         );
     }
 
     return (
         <div className="solicitation-list">
+            {scopeToggle}
+            {matchingControls}
             <DashboardCharts
                 timeData={timeData}
                 agencyData={agencyData}
@@ -165,6 +255,11 @@ const PersonalInbox: React.FC = () => {
                                         }}>
                                             {match.score}
                                         </span>
+                                        {match.personal_score !== undefined && (
+                                            <div className="text-muted" style={{ fontSize: '0.75rem', marginTop: '4px' }} title="Your personal score">
+                                                You: {match.personal_score}
+                                            </div>
+                                        )}
                                     </td>
                                     <td>{match.solicitation.agency}</td>
                                                                         <td>
@@ -190,15 +285,37 @@ const PersonalInbox: React.FC = () => {
                                         </div>
                                     </td>
                                     <td>
-                                        <a
-                                            href={match.solicitation.url}
-                                            target="_blank"
-                                            rel="noreferrer"
-                                            className="btn-link"
-                                            onClick={(e) => e.stopPropagation()}
-                                        >
-                                            <ExternalLink size={16} />
-                                        </a>
+                                        <div style={{ display: 'flex', alignItems: 'center', gap: '0.5rem' }}>
+                                            <a
+                                                href={match.solicitation.url}
+                                                target="_blank"
+                                                rel="noreferrer"
+                                                className="btn-link"
+                                                onClick={(e) => e.stopPropagation()}
+                                            >
+                                                <ExternalLink size={16} />
+                                            </a>
+                                            {scope === 'personal' && (
+                                                <>
+                                                    <button
+                                                        className="btn-link"
+                                                        title="Good match"
+                                                        onClick={(e) => { e.stopPropagation(); rateMatch(match, 'up'); }}
+                                                        style={{ color: match.feedback === 'up' ? 'var(--success-color)' : 'var(--text-secondary)' }}
+                                                    >
+                                                        <ThumbsUp size={16} fill={match.feedback === 'up' ? 'currentColor' : 'none'} />
+                                                    </button>
+                                                    <button
+                                                        className="btn-link"
+                                                        title="Not relevant"
+                                                        onClick={(e) => { e.stopPropagation(); rateMatch(match, 'down'); }}
+                                                        style={{ color: match.feedback === 'down' ? 'var(--error-color)' : 'var(--text-secondary)' }}
+                                                    >
+                                                        <ThumbsDown size={16} fill={match.feedback === 'down' ? 'currentColor' : 'none'} />
+                                                    </button>
+                                                </>
+                                            )}
+                                        </div>
                                     </td>
                                 </tr>
                                 {expandedRow === match.solicitation.source_id && (
@@ -208,6 +325,7 @@ const PersonalInbox: React.FC = () => {
                                                 <div style={{ marginBottom: '1.5rem', padding: '1rem', backgroundColor: 'var(--bg-card)', borderLeft: '4px solid #3498db' }}>
                                                     <strong>AI Analysis:</strong>
                                                     <p style={{ marginTop: '0.5rem' }}>{match.explanation}</p>
+                                                    {match.details && <MatchDetailsView details={match.details} />}
                                                 </div>
 
                                                 {match.solicitation.description && (
@@ -1,6 +1,6 @@
-import React, { useEffect, useState, useMemo } from 'react';
+import React, { useEffect, useState, useCallback } from 'react';
 import { Link } from 'react-router-dom';
-import type { Solicitation } from '../types';
+import type { Solicitation, SolicitationPage } from '../types';
 import { 
     ChevronDown, 
     ChevronRight, 
@@ -10,85 +10,110 @@ import {
     User,
     Users
 } from 'lucide-react';
-import { useAnalytics, getDaysRemaining, getDateBucket } from '../hooks/useAnalytics';
+import { useAnalytics } from '../hooks/useAnalytics';
+import { useAuth } from '../context/AuthContext';
 import DashboardCharts from './DashboardCharts';
 import FilterControls from './FilterControls';
 
+const PAGE_SIZE = 50;
+
+// Maps a chart date bucket to the due_after/due_before range the API filters on
+const bucketRange = (bucket: string): { due_after?: string; due_before?: string } => {
+    const now = new Date();
+    const days = (n: number) => new Date(now.getTime() + n * 24 * 60 * 60 * 1000).toISOString();
+    switch (bucket) {
+        case "Expired": return { due_before: now.toISOString() };
+        case "0-7 Days": return { due_after: now.toISOString(), due_before: days(7) };
+        case "8-14 Days": return { due_after: days(7), due_before: days(14) };
+        case "15-30 Days": return { due_after: days(14), due_before: days(30) };
+        case "30+ Days": return { due_after: days(30) };
+        default: return {};
+    }
+};
+
 const SolicitationList: React.FC = () => {
+    const { user } = useAuth();
     const [solicitations, setSolicitations] = useState<Solicitation[]>([]);
+    const [total, setTotal] = useState<number>(0);
+    const [nextCursor, setNextCursor] = useState<string | null>(null);
     const [loading, setLoading] = useState<boolean>(true);
+    const [loadingMore, setLoadingMore] = useState<boolean>(false);
     const [error, setError] = useState<string | null>(null);
     const [expandedRow, setExpandedRow] = useState<string | null>(null);
     
-    // Filtering & Sorting State
+    // Filtering & Sorting State (applied server-side)
     const [filterText, setFilterText] = useState("");
+    const [debouncedText, setDebouncedText] = useState("");
     const [dateFilter, setDateFilter] = useState<string | null>(null);
     const [agencyFilter, setAgencyFilter] = useState<string | null>(null);
-    const [sortConfig, setSortConfig] = useState<{ key: keyof Solicitation; direction: 'asc' | 'desc' } | null>({ key: 'due_date', direction: 'asc' });
+    const [sortConfig, setSortConfig] = useState<{ key: 'due' | 'created' | 'score'; direction: 'asc' | 'desc' }>({ key: 'due', direction: 'asc' });
 
     useEffect(() => {
-        const fetchData = async () => {
-            try {
-                const response = await fetch('/api/solicitations');
-                if (!response.ok) {
-                    throw new Error(`HTTP error! Status: ${response.status}`);
-                }
-                const data = await response.json();
-                setSolicitations(data);
-            } catch (err: any) {
-                setError(err.message);
-            } finally {
-                setLoading(false);
-            }
-        };
-
-        fetchData();
-    }, []);
-
-    // 1. Text Filtered (Base for all charts and table)
-    const textFilteredSolicitations = useMemo(() => {
-        if (!filterText) return solicitations;
-        const lower = filterText.toLowerCase();
-        return solicitations.filter(item => 
-            item.title.toLowerCase().includes(lower) ||
-            item.agency.toLowerCase().includes(lower)
-        );
-    }, [solicitations, filterText]);
-
-    // 2. Analytics Hook
-    const { timeData, agencyData } = useAnalytics(
-        textFilteredSolicitations,
-        (s) => s.due_date,
-        (s) => s.agency,
-        dateFilter,
-        agencyFilter
-    );
-
-    // 3. Main Table Logic (Derived from textFiltered + both filters)
-    const processedSolicitations = useMemo(() => {
-        let items = [...textFilteredSolicitations];
+        const t = setTimeout(() => setDebouncedText(filterText), 300);
+        return () => clearTimeout(t);
+    }, [filterText]);
 
+    const buildQuery = useCallback((cursor?: string) => {
+        const params = new URLSearchParams({ sort: sortConfig.key, order: sortConfig.direction, limit: String(PAGE_SIZE) });
+        if (debouncedText) params.set('q', debouncedText);
+        if (agencyFilter) params.set('agency', agencyFilter);
         if (dateFilter) {
-            items = items.filter(item => getDateBucket(getDaysRemaining(item.due_date)) === dateFilter);
+            Object.entries(bucketRange(dateFilter)).forEach(([k, v]) => v && params.set(k, v));
         }
-        if (agencyFilter) {
-            items = items.filter(item => item.agency === agencyFilter);
+        if (cursor) params.set('cursor', cursor);
+        return params.toString();
+    }, [debouncedText, agencyFilter, dateFilter, sortConfig]);
+
+    const fetchPage = useCallback(async (cursor?: string) => {
+        const response = await fetch(`/api/solicitations?${buildQuery(cursor)}`);
+        if (!response.ok) {
+            throw new Error(`HTTP error! Status: ${response.status}`);
         }
+        return response.json() as Promise<SolicitationPage>;
+    }, [buildQuery]);
 
-        if (sortConfig) {
-            items.sort((a, b) => {
-                if (a[sortConfig.key] < b[sortConfig.key]) return sortConfig.direction === 'asc' ? -1 : 1;
-                if (a[sortConfig.key] > b[sortConfig.key]) return sortConfig.direction === 'asc' ? 1 : -1;
-                return 0;
-            });
+    useEffect(() => {
+        let cancelled = false;
+        setLoading(true);
+        fetchPage()
+            .then(page => {
+                if (cancelled) return;
+                setSolicitations(page.items);
+                setTotal(page.total);
+                setNextCursor(page.next_cursor || null);
+                setError(null);
+            })
+            .catch((err: any) => !cancelled && setError(err.message))
+            .finally(() => !cancelled && setLoading(false));
+        return () => { cancelled = true; };
+    }, [fetchPage]);
+
+    const loadMore = async () => {
+        if (!nextCursor) return;
+        setLoadingMore(true);
+        try {
+            const page = await fetchPage(nextCursor);
+            setSolicitations(prev => [...prev, ...page.items]);
+            setNextCursor(page.next_cursor || null);
+        } catch (err: any) {
+            setError(err.message);
+        } finally {
+            setLoadingMore(false);
         }
+    };
 
-        return items;
-    }, [textFilteredSolicitations, dateFilter, agencyFilter, sortConfig]);
+    // Charts summarise the rows loaded so far
+    const { timeData, agencyData } = useAnalytics(
+        solicitations,
+        (s) => s.due_date,
+        (s) => s.agency,
+        dateFilter,
+        agencyFilter
+    );
 
-    const requestSort = (key: keyof Solicitation) => {
-        let direction: 'asc' | 'desc' = 'asc';
-        if (sortConfig && sortConfig.key === key && sortConfig.direction === 'asc') direction = 'desc';
+    const requestSort = (key: 'due' | 'created' | 'score') => {
+        let direction: 'asc' | 'desc' = key === 'due' ? 'asc' : 'desc';
+        if (sortConfig.key === key) direction = sortConfig.direction === 'asc' ? 'desc' : 'asc';
         setSortConfig({ key, direction });
     };
 
@@ -96,7 +121,7 @@ const SolicitationList: React.FC = () => {
         setExpandedRow(expandedRow === id ? null : id);
     };
 
-    if (loading) return <div className="loading">Loading opportunities...</div>;
+    if (loading && solicitations.length === 0) return <div className="loading">Loading opportunities...</div>;
     if (error) return <div className="error">Error: {error}</div>;
 
     return (
@@ -110,6 +135,15 @@ const SolicitationList: React.FC = () => {
                 setAgencyFilter={setAgencyFilter}
             />
 
+            <div className="sort-controls" style={{display: 'flex', gap: '0.5rem', alignItems: 'center', marginBottom: '0.5rem'}}>
+                <span className="text-muted">Sort:</span>
+                <button className={`btn-link${sortConfig.key === 'due' ? ' active' : ''}`} onClick={() => requestSort('due')}>Due date</button>
+                <button className={`btn-link${sortConfig.key === 'created' ? ' active' : ''}`} onClick={() => requestSort('created')}>Newest</button>
+                {user && (
+                    <button className={`btn-link${sortConfig.key === 'score' ? ' active' : ''}`} onClick={() => requestSort('score')}>Match score</button>
+                )}
+            </div>
+
             <FilterControls 
                 filterText={filterText}
                 setFilterText={setFilterText}
@@ -117,8 +151,8 @@ const SolicitationList: React.FC = () => {
                 setDateFilter={setDateFilter}
                 agencyFilter={agencyFilter}
                 setAgencyFilter={setAgencyFilter}
-                count={processedSolicitations.length}
-                total={solicitations.length}
+                count={solicitations.length}
+                total={total}
             />
 
             {/* Table */}
@@ -127,15 +161,15 @@ const SolicitationList: React.FC = () => {
                     <thead>
                         <tr>
                             <th style={{width: '40px'}}></th>
-                            <th onClick={() => requestSort('agency')} className="sortable">Agency <ArrowUpDown size={14} /></th>
-                            <th onClick={() => requestSort('title')} className="sortable">Title <ArrowUpDown size={14} /></th>
-                            <th onClick={() => requestSort('due_date')} className="sortable">Due Date <ArrowUpDown size={14} /></th>
+                            <th>Agency</th>
+                            <th>Title</th>
+                            <th onClick={() => requestSort('due')} className="sortable">Due Date <ArrowUpDown size={14} /></th>
                             <th>Docs</th>
                             <th>Action</th>
                         </tr>
                     </thead>
                     <tbody>
-                        {processedSolicitations.map((sol) => (
+                        {solicitations.map((sol) => (
                             <React.Fragment key={sol.source_id}>
                                 <tr 
                                     onClick={() => toggleRow(sol.source_id)} 
@@ -164,6 +198,11 @@ const SolicitationList: React.FC = () => {
                                                     <Users size={12} /> Interested: {sol.interested_parties}
                                                 </div>
                                             )}
+                                            {sol.deadline_moved ? (
+                                                <span className="badge" style={{fontSize: '0.75rem', color: '#c0392b'}}>Deadline moved</span>
+                                            ) : sol.amended && (
+                                                <span className="badge" style={{fontSize: '0.75rem'}}>Amended</span>
+                                            )}
                                         </div>
                                     </td>
                                     <td>{sol.due_date === "0001-01-01T00:00:00Z" ? "N/A" : new Date(sol.due_date).toLocaleDateString()}</td>
@@ -204,6 +243,13 @@ const SolicitationList: React.FC = () => {
                     </tbody>
                 </table>
             </div>
+            {nextCursor && (
+                <div style={{textAlign: 'center', margin: '1rem 0'}}>
+                    <button className="btn" onClick={loadMore} disabled={loadingMore}>
+                        {loadingMore ? 'Loading...' : `Load more (${total - solicitations.length} remaining)`}
+                    </button>
+                </div>
+            )}
         </div>
     );
 };
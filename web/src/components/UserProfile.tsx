@@ -1,19 +1,20 @@
 import React, { useState, useRef, useEffect } from 'react';
 import { useAuth } from '../context/AuthContext';
 import { useTheme } from '../context/ThemeContext';
-import { User, Lock, Save, Camera, FileText, Palette } from 'lucide-react';
+import { User, Lock, Save, Camera, FileText, Palette, Mail } from 'lucide-react';
 import MarkdownEditor from './MarkdownEditor';
+import OrganizationNarrative from './OrganizationNarrative';
 
 const UserProfile: React.FC = () => {
-    const { user, refreshUser } = useAuth();
+    const { user, refreshUser, logoutEverywhere } = useAuth();
     const { theme, setTheme } = useTheme();
 
     // Profile State
     const [fullName, setFullName] = useState(user?.full_name || "");
     const [email, setEmail] = useState(user?.email || "");
-    const [orgName, setOrgName] = useState(user?.organization_name || "");
     const [matchThreshold, setMatchThreshold] = useState(user?.match_threshold || 75);
-    const [orgSuggestions, setOrgSuggestions] = useState<string[]>([]);
+    const [matchTopK, setMatchTopK] = useState(user?.match_top_k ?? 0);
+    const [digestFrequency, setDigestFrequency] = useState<string>(user?.digest_frequency || "daily");
 
     const [avatarFile, setAvatarFile] = useState<File | null>(null);
     const [avatarPreview, setAvatarPreview] = useState<string | null>(user?.avatar_url || null);
@@ -47,17 +48,12 @@ const UserProfile: React.FC = () => {
         if (user) {
             setFullName(user.full_name || "");
             setEmail(user.email || "");
-            setOrgName(user.organization_name || "");
             setMatchThreshold(user.match_threshold || 75);
+            setMatchTopK(user.match_top_k ?? 0);
+            setDigestFrequency(user.digest_frequency || "daily");
             setAvatarPreview(user.avatar_url || null);
             fetchVersions();
         }
-
-        // Fetch orgs
-        fetch('/api/organizations')
-            .then(res => res.json())
-            .then(data => setOrgSuggestions(data || []))
-            .catch(console.error);
     }, [user]);
 
     if (!user) return <div>Please login to view profile.</div>;
@@ -96,8 +92,9 @@ const UserProfile: React.FC = () => {
                     email: email,
                     full_name: fullName,
                     avatar_url: avatarUrl,
-                    organization_name: orgName,
-                    match_threshold: matchThreshold
+                    match_threshold: matchThreshold,
+                    match_top_k: matchTopK,
+                    digest_frequency: digestFrequency
                 }),
             });
 
@@ -226,14 +223,11 @@ const UserProfile: React.FC = () => {
                                 type="text"
                                 className="search-input"
                                 style={{ border: '1px solid var(--border-input)', padding: '0.75rem', borderRadius: '4px', width: '100%', boxSizing: 'border-box' }}
-                                value={orgName}
-                                onChange={(e) => setOrgName(e.target.value)}
-                                list="org-suggestions"
-                                placeholder="Type or select your organization"
+                                value={user.organization_name || "None"}
+                                readOnly
+                                disabled
                             />
-                            <datalist id="org-suggestions">
-                                {orgSuggestions.map((org, i) => <option key={i} value={org} />)}
-                            </datalist>
+                            <p style={{ fontSize: '0.8rem', color: 'var(--text-secondary)', marginTop: '0.5rem' }}>Assigned by an administrator</p>
                         </div>
 
                         <div style={{ marginBottom: '1rem' }}>
@@ -259,6 +253,23 @@ const UserProfile: React.FC = () => {
                             />
                         </div>
 
+                        {/* Digest Email */}
+                        <div style={{ marginBottom: '1.5rem' }}>
+                            <label style={{ display: 'flex', alignItems: 'center', gap: '0.5rem', marginBottom: '0.5rem', color: 'var(--text-body)' }}>
+                                <Mail size={16} /> Match Digest Email
+                            </label>
+                            <select
+                                className="search-input"
+                                style={{ border: '1px solid var(--border-input)', padding: '0.75rem', borderRadius: '4px', width: '100%', boxSizing: 'border-box' }}
+                                value={digestFrequency}
+                                onChange={(e) => setDigestFrequency(e.target.value)}
+                            >
+                                <option value="daily">Daily</option>
+                                <option value="weekly">Weekly</option>
+                                <option value="off">Off</option>
+                            </select>
+                        </div>
+
                         {/* Theme Selector */}
                         <div style={{ marginBottom: '1.5rem' }}>
                             <label style={{ display: 'flex', alignItems: 'center', gap: '0.5rem', marginBottom: '0.5rem', color: 'var(--text-body)' }}>
@@ -362,6 +373,20 @@ const UserProfile: React.FC = () => {
                             <Lock size={18} /> Update Password
                         </button>
                     </form>
+
+                    <div style={{ marginTop: '1.5rem', paddingTop: '1rem', borderTop: '1px solid var(--border-color)' }}>
+                        <p style={{ fontSize: '0.85rem', color: 'var(--text-secondary)', marginTop: 0 }}>
+                            Signed in on a shared or lost device? End every session, including this one.
+                        </p>
+                        <button
+                            type="button"
+                            className="btn-outline"
+                            onClick={() => { if (window.confirm("Log out of all devices?")) logoutEverywhere(); }}
+                            style={{ width: '100%', justifyContent: 'center' }}
+                        >
+                            Log Out Everywhere
+                        </button>
+                    </div>
                 </div>
             </div>
 
@@ -394,6 +419,22 @@ const UserProfile: React.FC = () => {
                     </p>
                 </div>
 
+                {/* Semantic pre-filter size */}
+                <div style={{ marginBottom: '2rem', maxWidth: '400px' }}>
+                    <label style={{ display: 'block', marginBottom: '0.5rem', color: 'var(--text-body)', fontSize: '0.9rem', fontWeight: 600 }}>
+                        Opportunities Scored per Run
+                    </label>
+                    <input
+                        type="number" min="0" max="10000"
+                        value={matchTopK}
+                        onChange={(e) => setMatchTopK(Math.max(0, parseInt(e.target.value) || 0))}
+                        style={{ width: '120px' }}
+                    />
+                    <p style={{ fontSize: '0.75rem', color: 'var(--text-secondary)', marginTop: '0.4rem' }}>
+                        When set, only the opportunities most similar to your narrative are sent to the AI for scoring (if the server has an embedding model). Use 0 to score all of them.
+                    </p>
+                </div>
+
                 <MarkdownEditor
                     initialContent={user.narrative || ""}
                     onSave={handleNarrativeSave}
@@ -404,6 +445,8 @@ const UserProfile: React.FC = () => {
                     height="500px"
                 />
             </div>
+
+            {user.organization_name && <OrganizationNarrative />}
         </div>
     );
 };
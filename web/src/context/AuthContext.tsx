@@ -11,6 +11,9 @@ interface User {
     avatar_url?: string;
     organization_name?: string;
     match_threshold?: number;
+    match_top_k?: number;
+    digest_frequency?: 'daily' | 'weekly' | 'off';
+    permissions?: string[];
 }
 
 interface AuthContextType {
@@ -18,6 +21,7 @@ interface AuthContextType {
     isLoading: boolean;
     login: (email: string, password?: string) => Promise<void>;
     logout: () => Promise<void>;
+    logoutEverywhere: () => Promise<void>;
     refreshUser: () => Promise<void>;
 }
 
@@ -75,8 +79,17 @@ export const AuthProvider: React.FC<{ children: ReactNode }> = ({ children }) =>
         }
     };
 
+    const logoutEverywhere = async () => {
+        try {
+            await fetch('/api/auth/logout-all', { method: 'POST' });
+            setUser(null);
+        } catch (err) {
+            console.error("Logout failed", err);
+        }
+    };
+
     return (
-        <AuthContext.Provider value={{ user, isLoading, login, logout, refreshUser }}>
+        <AuthContext.Provider value={{ user, isLoading, login, logout, logoutEverywhere, refreshUser }}>
             {children}
         </AuthContext.Provider>
     );
@@ -0,0 +1,55 @@
+import { useCallback, useEffect, useRef, useState } from 'react';
+import type { MatchJob } from '../types';
+
+// Starts a background matching job for the current user and follows its
+// progress over server-sent events. onMatch is called whenever a new score
+// reaches the user's threshold, and once more when the job finishes.
+export const useMatchJob = (onMatch: () => void) => {
+    const [job, setJob] = useState<MatchJob | null>(null);
+    const [error, setError] = useState<string | null>(null);
+    const sourceRef = useRef<EventSource | null>(null);
+    const onMatchRef = useRef(onMatch);
+    onMatchRef.current = onMatch;
+
+    const follow = useCallback((id: number) => {
+        sourceRef.current?.close();
+        const source = new EventSource(`/api/matches/jobs/${id}/events`);
+        sourceRef.current = source;
+
+        source.addEventListener('progress', (e) => {
+            setJob(JSON.parse((e as MessageEvent).data).job);
+        });
+        source.addEventListener('match', (e) => {
+            const event = JSON.parse((e as MessageEvent).data);
+            setJob(event.job);
+            if (event.match?.above_threshold) onMatchRef.current();
+        });
+        source.addEventListener('done', (e) => {
+            setJob(JSON.parse((e as MessageEvent).data).job);
+            source.close();
+            onMatchRef.current();
+        });
+        source.onerror = () => {
+            // The browser reconnects on its own unless the stream was closed
+            if (source.readyState === EventSource.CLOSED) sourceRef.current = null;
+        };
+    }, []);
+
+    useEffect(() => () => sourceRef.current?.close(), []);
+
+    const run = useCallback(async () => {
+        setError(null);
+        const res = await fetch('/api/matches/run', { method: 'POST' });
+        if (res.status === 202 || res.status === 409) {
+            // 409: a job is already running; follow it instead
+            const started: MatchJob = await res.json();
+            setJob(started);
+            follow(started.id);
+            return;
+        }
+        setError((await res.text()) || 'Failed to start matching');
+    }, [follow]);
+
+    const running = job !== null && (job.status === 'queued' || job.status === 'running');
+    return { job, running, error, run };
+};
@@ -9,6 +9,22 @@ export interface Solicitation {
     raw_data: any;
     lead_name?: string;
     interested_parties?: string;
+    amended?: boolean;
+    deadline_moved?: boolean;
+    match_score?: number;
+}
+
+export interface SolicitationPage {
+    items: Solicitation[];
+    next_cursor?: string;
+    total: number;
+}
+
+export interface SolicitationRevision {
+    id: number;
+    solicitation_id: number;
+    changes: Record<string, { old: any; new: any }>;
+    created_at: string;
 }
 
 export interface Document {
@@ -17,9 +33,51 @@ export interface Document {
     type: string;
 }
 
+export interface CriterionVerdict {
+    criterion: string;
+    verdict: 'met' | 'not_met' | 'unclear';
+    evidence: string;
+}
+
+export interface MatchDetails {
+    confidence: number;
+    criteria: CriterionVerdict[] | null;
+    matched_keywords: string[] | null;
+    disqualifiers: string[] | null;
+}
+
 export interface Match {
     match_id: number;
     score: number;
     explanation: string;
+    details?: MatchDetails;
+    personal_score?: number;
+    prompt_version?: number;
+    feedback?: 'up' | 'down';
     solicitation: Solicitation;
+}
+
+export interface Organization {
+    id: number;
+    name: string;
+    narrative: string;
+    match_threshold: number;
+    match_top_k: number;
+    updated_at: string;
+}
+
+export interface MatchJob {
+    id: number;
+    user_id: number;
+    status: 'queued' | 'running' | 'succeeded' | 'failed';
+    total: number;
+    done: number;
+    failed: number;
+    above_threshold: number;
+    error?: string;
+    created_at: string;
+    started_at?: string;
+    finished_at?: string;
+    per_minute?: number;
+    eta_seconds?: number;
 }
\ No newline at end of file